@@ -6,9 +6,13 @@ import (
 	"investment-game-backend/internal/config"
 	pgrepo "investment-game-backend/internal/repo/pg"
 	additionalinfos "investment-game-backend/internal/services/additional_infos"
+	"investment-game-backend/internal/services/admins"
 	"investment-game-backend/internal/services/auth"
+	"investment-game-backend/internal/services/bonds"
 	"investment-game-backend/internal/services/companies"
 	"investment-game-backend/internal/services/games"
+	"investment-game-backend/internal/services/market"
+	randomevents "investment-game-backend/internal/services/random_events"
 	"investment-game-backend/internal/services/settings"
 	"investment-game-backend/internal/services/teams"
 	v1 "investment-game-backend/internal/transport/http/v1"
@@ -40,17 +44,34 @@ func Run(cfg *config.Config) {
 	log.Info().Msg("successfully applied migrations")
 
 	additionalInfosRepo := pgrepo.NewAdditionalInfosRepo(pg)
+	adminActionsRepo := pgrepo.NewAdminActionsRepo(pg)
+	adminsRepo := pgrepo.NewAdminsRepo(pg)
 	authRepo := pgrepo.NewAuthRepo(pg)
 	balanceTransactionsRepo := pgrepo.NewBalanceTransactionsRepo(pg)
 	balancesRepo := pgrepo.NewBalancesRepo(pg)
+	bondsRepo := pgrepo.NewBondsRepo(pg)
 	companiesRepo := pgrepo.NewCompaniesRepo(pg)
 	companySharesRepo := pgrepo.NewCompanySharesRepo(pg)
 	gamesRepo := pgrepo.NewGamesRepo(pg)
+	joinCodesRepo := pgrepo.NewJoinCodesRepo(pg)
+	marketOrdersRepo := pgrepo.NewMarketOrdersRepo(pg)
+	randomEventsRepo := pgrepo.NewRandomEventsRepo(pg)
 	settingsRepo := pgrepo.NewSettingsRepo(pg)
+	teamBondsRepo := pgrepo.NewTeamBondsRepo(pg)
+	teamMembersRepo := pgrepo.NewTeamMembersRepo(pg)
 	teamsRepo := pgrepo.NewTeamsRepo(pg)
+	txManager := pgrepo.NewTxManager(pg)
 
+	adminsService := admins.New(adminsRepo, adminActionsRepo, log)
+	if err = adminsService.Bootstrap(context.Background(), cfg.Admin.Username, cfg.Admin.Password); err != nil {
+		log.Fatal().Err(err).Msg("failed to bootstrap admin")
+	}
+
+	teamNotifier := games.NewTeamsNotifier(log)
 	authService := auth.New(
 		teamsRepo,
+		teamMembersRepo,
+		adminsRepo,
 		authRepo,
 		gamesRepo,
 		auth.JWTConfig{
@@ -59,16 +80,20 @@ func Run(cfg *config.Config) {
 			JWTAccessSecretKey:       cfg.JWT.JWTAccessSecretKey,
 			JWTRefreshSecretKey:      cfg.JWT.JWTRefreshSecretKey,
 		},
-		auth.AdminCredentials{
-			Username: cfg.Admin.Username,
-			Password: cfg.Admin.Password,
-		},
+		teamNotifier.CloseAuthSessions,
 		log,
 	)
+	if err = authService.RestoreRevokedAccessTokens(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("failed to restore revoked access tokens")
+	}
 	companiesService := companies.New(
 		companiesRepo,
 		companySharesRepo,
 		gamesRepo,
+		settingsRepo,
+		balanceTransactionsRepo,
+		additionalInfosRepo,
+		txManager,
 		log,
 	)
 	teamsService := teams.New(
@@ -80,23 +105,74 @@ func Run(cfg *config.Config) {
 		balanceTransactionsRepo,
 		gamesRepo,
 		companiesRepo,
+		randomEventsRepo,
+		bondsRepo,
+		teamBondsRepo,
+		teamMembersRepo,
+		joinCodesRepo,
+		txManager,
+		teamNotifier.NotifyBalanceChanged,
+		teamNotifier.NotifyAdditionalInfoPurchased,
+		authService.KickTeamMember,
+		authService.KickTeamSharedSessions,
 		log,
 	)
+	bondsService := bonds.New(bondsRepo, log)
 	additionalInfosService := additionalinfos.New(additionalInfosRepo, settingsRepo, log)
-	teamNotifier := games.NewTeamsNotifier(log)
+	randomEventsService := randomevents.New(
+		randomEventsRepo,
+		teamsRepo,
+		balancesRepo,
+		balanceTransactionsRepo,
+		companySharesRepo,
+		gamesRepo,
+		txManager,
+		log,
+	)
+	marketService := market.New(
+		marketOrdersRepo,
+		teamsRepo,
+		balancesRepo,
+		settingsRepo,
+		gamesRepo,
+		txManager,
+		teamsService.CheckTradingNotFrozen,
+		teamsService.SettleMarketFill,
+		teamsService.NotifyBalanceChanged,
+		teamNotifier.NotifyOrderBookChanged,
+		log,
+	)
+	if err = marketService.RestoreOrderBooks(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("failed to restore order books")
+	}
 
 	settingTmp, err := settingsRepo.Get(context.Background())
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to get settings")
 	}
-	tradeController := games.NewTradeController(settingTmp.RoundsDuration)
-	gameController := &games.GameController{}
+	controllers := games.NewControllers(settingTmp.RoundsDuration)
 
-	tradeController.RegisterNotify(teamsService.NotifyTradePeriodUpdated)
-	tradeController.RegisterNotify(teamNotifier.NotifyTradePeriodChanged)
-	gameController.RegisterNotify(teamsService.NotifyGameRegistrationPeriodUpdated)
+	controllers.RegisterTradeNotify(teamsService.NotifyTradePeriodUpdated)
+	controllers.RegisterTradeNotify(marketService.NotifyTradePeriodUpdated)
+	controllers.RegisterTradeNotify(teamNotifier.NotifyTradePeriodChanged)
+	controllers.RegisterGameNotify(teamsService.NotifyGameRegistrationPeriodUpdated)
 
-	gamesService := games.New(gamesRepo, tradeController, gameController, teamNotifier, log)
+	gamesService := games.New(
+		gamesRepo,
+		settingsRepo,
+		controllers,
+		teamNotifier,
+		randomEventsService.Roll,
+		teamsService.MarkShortsToMarket,
+		companiesService.AdjustPricesByDemand,
+		teamsService.PayDividends,
+		teamsService.PayBondCoupons,
+		teamsService.ChargeLoanInterest,
+		log,
+	)
+	if err = gamesService.RestoreState(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("failed to restore games state")
+	}
 	settingsService := settings.New(
 		settingsRepo,
 		gamesService.UpdateTradePeriod,
@@ -115,6 +191,11 @@ func Run(cfg *config.Config) {
 		TeamsService:          teamsService,
 		AuthService:           authService,
 		AdditionalInfoService: additionalInfosService,
+		RandomEventsService:   randomEventsService,
+		MarketService:         marketService,
+		BondsService:          bondsService,
+		AdminsService:         adminsService,
+		JoinURL:               cfg.HTTP.JoinURL,
 		Log:                   log,
 		TeamsNotifier:         teamNotifier,
 	})
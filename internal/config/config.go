@@ -14,6 +14,8 @@ type Config struct {
 
 type HTTPConfig struct {
 	Addr string `yaml:"addr" env-default:"0.0.0.0:11864"`
+	// JoinURL — страница фронтенда для регистрации по коду, на неё ведут QR-коды.
+	JoinURL string `yaml:"join_url"`
 }
 
 type PostgresConfig struct {
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type Admin struct {
+	ID           int64
+	CreatedAt    time.Time
+	Username     string
+	PasswordHash string
+}
+
+// AdminAction — запись аудита действия администратора над игрой сессии.
+// AdminID пуст, если администратор с тех пор удалён.
+type AdminAction struct {
+	ID        int64
+	CreatedAt time.Time
+	AdminID   *int64
+	SessionID int64
+	Action    string
+}
@@ -1,6 +1,49 @@
 package models
 
+import "time"
+
 type JWTPair struct {
 	AccessToken  string
 	RefreshToken string
 }
+
+// Role определяет набор действий, доступных владельцу токена.
+type Role string
+
+const (
+	// RoleAdmin — ведущий игры, управляет игрой и справочниками.
+	RoleAdmin Role = "admin"
+	// RoleTeam — команда, торгует только от своего имени.
+	RoleTeam Role = "team"
+	// RoleSpectator — наблюдатель, только просматривает состояние игры. Токены наблюдателя
+	// выдаёт администратор.
+	RoleSpectator Role = "spectator"
+)
+
+// AuthSession — вход с одного устройства. У команды или администратора может быть
+// несколько активных сессий, каждая со своим refresh-токеном.
+type AuthSession struct {
+	ID        int64
+	CreatedAt time.Time
+	Role      Role
+	SubjectID int64
+	// MemberID заполнен, если вошёл участник команды, а не команда общим паролем.
+	MemberID     *int64
+	UserAgent    string
+	RefreshToken string
+	// AccessJTI и AccessExpiresAt описывают последний выданный access-токен, чтобы его можно
+	// было отозвать вместе с сессией.
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	RevokedAt       *time.Time
+}
+
+func (s *AuthSession) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+// RevokedAccessToken — отозванный access-токен, хранится до истечения его срока действия.
+type RevokedAccessToken struct {
+	JTI       string
+	ExpiresAt time.Time
+}
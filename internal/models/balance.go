@@ -3,14 +3,99 @@ package models
 type Balance struct {
 	ID     int64
 	Amount int64
+	// Collateral — часть Amount, удерживаемая залогом под короткие позиции.
+	Collateral int64
+	// Loan — непогашенный долг команды перед банком, уже зачисленный в Amount.
+	Loan    int64
+	Version int64
 }
 
+// Available возвращает средства, которые команда может потратить.
+func (b *Balance) Available() int64 {
+	return b.Amount - b.Collateral
+}
+
+type BalanceTransactionType int8
+
+const (
+	// BalanceTransactionTypeShares — покупка и продажа акций за раунд.
+	BalanceTransactionTypeShares BalanceTransactionType = iota + 1
+	BalanceTransactionTypeAdditionalInfo
+	BalanceTransactionTypeRandomEvent
+	// BalanceTransactionTypeMarginCall — принудительное закрытие коротких позиций при нехватке залога.
+	BalanceTransactionTypeMarginCall
+	// BalanceTransactionTypeMarketFill — сделка с другой командой по заявке из стакана.
+	BalanceTransactionTypeMarketFill
+	// BalanceTransactionTypeCommission — комиссия за сделку, ParentID указывает на саму сделку.
+	BalanceTransactionTypeCommission
+	// BalanceTransactionTypeCapitalGainsTax — налог с прибыли от продажи, ParentID указывает на сделку.
+	BalanceTransactionTypeCapitalGainsTax
+	// BalanceTransactionTypeDividend — выплата дивидендов по окончании раунда, Details хранит позиции команды.
+	BalanceTransactionTypeDividend
+	// BalanceTransactionTypeBondPurchase — покупка облигаций или открытие вклада.
+	BalanceTransactionTypeBondPurchase
+	// BalanceTransactionTypeBondCoupon — выплата купона или процентов по вкладу.
+	BalanceTransactionTypeBondCoupon
+	// BalanceTransactionTypeBondRedemption — возврат номинала при погашении.
+	BalanceTransactionTypeBondRedemption
+	// BalanceTransactionTypeLoan — получение кредита в банке.
+	BalanceTransactionTypeLoan
+	// BalanceTransactionTypeLoanRepayment — погашение части или всего долга.
+	BalanceTransactionTypeLoanRepayment
+	// BalanceTransactionTypeLoanInterest — проценты по долгу, начисляемые в начале раунда.
+	BalanceTransactionTypeLoanInterest
+)
+
 type BalanceTransaction struct {
 	ID               int64
 	BalanceID        int64
+	Type             BalanceTransactionType
 	Round            int
 	Amount           int64
 	Details          map[int64]int64
 	AdditionalInfoID *int64
 	RandomEventID    *int64
+	// ParentID заполнен у комиссий и налогов и указывает на сделку, за которую они списаны.
+	ParentID *int64
+	// MemberID — участник команды, совершивший сделку. Пуст у начислений и операций,
+	// выполненных под общим входом команды.
+	MemberID *int64
+}
+
+// BalanceTransactionFilter ограничивает выборку транзакций балансов, пустые поля не фильтруют.
+type BalanceTransactionFilter struct {
+	BalanceIDs []int64
+	Round      *int
+	Type       *BalanceTransactionType
+}
+
+// MarginCall описывает принудительно закрытые короткие позиции команды.
+// Closed: ключ - ID компании, значение - количество выкупленных акций.
+// Capitalized — часть стоимости выкупа, которую не хватило средств оплатить и которая добавлена к долгу.
+type MarginCall struct {
+	TeamID      int64
+	Round       int
+	Closed      map[int64]int64
+	Amount      int64
+	Capitalized int64
+}
+
+// DividendPayout описывает дивиденды, начисленные команде за раунд. Shares: ключ - ID компании,
+// значение - количество акций. Короткие позиции выплачивают дивиденд, поэтому Amount может быть отрицательным.
+// Capitalized — часть выплаты по шортам, которую не хватило средств оплатить и которая добавлена к долгу.
+type DividendPayout struct {
+	TeamID      int64
+	Round       int
+	Shares      map[int64]int64
+	Amount      int64
+	Capitalized int64
+}
+
+// LoanInterest описывает проценты по долгу команды, начисленные в начале раунда.
+// Capitalized — часть процентов, которую не хватило средств оплатить и которая добавлена к долгу.
+type LoanInterest struct {
+	TeamID      int64
+	Round       int
+	Amount      int64
+	Capitalized int64
 }
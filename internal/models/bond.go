@@ -0,0 +1,63 @@
+package models
+
+type BondType int8
+
+const (
+	// BondTypeBond — облигация с купоном.
+	BondTypeBond BondType = iota + 1
+	// BondTypeDeposit — банковский вклад, проценты по которому выплачиваются так же, как купон.
+	BondTypeDeposit
+)
+
+// Bond — инструмент с фиксированной доходностью. Купон CouponPercent от цены выплачивается
+// в начале каждого раунда, номинал возвращается через MaturityRounds раундов после покупки.
+type Bond struct {
+	ID             int64
+	Name           string
+	Type           BondType
+	Price          int64
+	CouponPercent  int64
+	MaturityRounds int
+	Archived       *bool
+}
+
+func (b *Bond) IsArchived() bool {
+	if b.Archived == nil {
+		return false
+	}
+	return *b.Archived
+}
+
+// TeamBond — купленный командой пакет облигаций. Цена и купон фиксируются на момент покупки,
+// чтобы изменение инструмента администратором не меняло условия уже купленных пакетов.
+type TeamBond struct {
+	ID            int64
+	TeamID        int64
+	BondID        int64
+	Count         int64
+	Price         int64
+	CouponPercent int64
+	PurchaseRound int
+	MaturityRound int
+	Redeemed      bool
+}
+
+// Principal возвращает номинал пакета.
+func (b *TeamBond) Principal() int64 {
+	return b.Price * b.Count
+}
+
+// Coupon возвращает купон пакета за один раунд.
+func (b *TeamBond) Coupon() int64 {
+	return b.Principal() * b.CouponPercent / 100
+}
+
+// BondPayment описывает выплату по пакету облигаций в начале раунда.
+// Principal заполнен, если в этом раунде наступило погашение.
+type BondPayment struct {
+	TeamID     int64
+	TeamBondID int64
+	Round      int
+	Coupon     int64
+	Principal  int64
+}
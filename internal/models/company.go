@@ -16,4 +16,6 @@ func (c *Company) IsArchived() bool {
 type CompanyWithShares struct {
 	Company
 	Shares map[int]int64
+	// Dividends: ключ - раунд, значение - дивиденд на одну акцию.
+	Dividends map[int]int64
 }
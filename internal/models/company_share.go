@@ -5,4 +5,27 @@ type CompanyShare struct {
 	CompanyID int64
 	Round     int
 	Price     int64
+	// Dividend — дивиденд на одну акцию, выплачиваемый держателям по окончании раунда.
+	Dividend int64
+}
+
+// SessionSharePrice — цена акции компании на раунд игры сессии, пересчитанная по спросу команд.
+// Перекрывает цену администратора только для этой игры, CompanyShare при этом не меняется.
+type SessionSharePrice struct {
+	SessionID int64
+	GameID    int64
+	CompanyID int64
+	Round     int
+	Price     int64
+}
+
+// SharePriceAdjustment описывает пересчёт цены акции компании на раунд по спросу команд.
+// NetDemand — разница купленных и проданных акций в прошлом раунде, Volume — их сумма.
+type SharePriceAdjustment struct {
+	CompanyID int64
+	Round     int
+	BasePrice int64
+	NetDemand int64
+	Volume    int64
+	Price     int64
 }
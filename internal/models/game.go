@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type GameState int8
 
 const (
@@ -17,9 +19,53 @@ const (
 	TradeStateStarted
 )
 
+// Game описывает игровую сессию (комнату). Несколько сессий могут проходить одновременно,
+// в рамках одной сессии игры проводятся последовательно (CurrentGame).
 type Game struct {
+	ID           int64
+	Name         string
 	State        GameState
 	CurrentRound int
 	TradeState   TradeState
 	CurrentGame  int64
+	// TradeStartedAt и TradeDeadline заполнены, пока идут торги, и нужны для восстановления
+	// отсчёта после перезапуска сервера. TradeRemaining задан, если отсчёт приостановлен.
+	TradeStartedAt *time.Time
+	TradeDeadline  *time.Time
+	TradeRemaining *time.Duration
+	// RoundStartPending означает, что действия начала раунда CurrentRound ещё не завершены:
+	// повторный запуск раунда выполняет их снова, не переходя к следующему раунду.
+	RoundStartPending bool
+	// AutopilotStatus сохраняется отдельно от остальных полей через GamesRepo.SetAutopilotStatus.
+	AutopilotStatus AutopilotStatus
+}
+
+type AutopilotPhase int8
+
+const (
+	// AutopilotPhaseBriefing — вводная часть раунда, торги закрыты.
+	AutopilotPhaseBriefing AutopilotPhase = iota + 1
+	// AutopilotPhaseTrading — идут торги, отсчётом управляет TradeController.
+	AutopilotPhaseTrading
+	// AutopilotPhaseResults — подведение итогов раунда.
+	AutopilotPhaseResults
+)
+
+// AutopilotStatus — сохранённое состояние автопилота сессии, переживающее перезапуск сервера.
+type AutopilotStatus int8
+
+const (
+	AutopilotStatusOff AutopilotStatus = iota
+	AutopilotStatusRunning
+	// AutopilotStatusInterrupted — автопилот прерван перезапуском сервера, сессия переведена
+	// в ручной режим до следующего запуска автопилота.
+	AutopilotStatusInterrupted
+)
+
+// AutopilotState описывает текущее положение автопилота сессии.
+type AutopilotState struct {
+	Phase     AutopilotPhase
+	Round     int
+	Remaining time.Duration
+	IsPaused  bool
 }
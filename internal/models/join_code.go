@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// JoinCode — одноразовый код регистрации команды в текущей игре сессии. Код перестаёт
+// приниматься после истечения срока, исчерпания лимита использований или отзыва.
+type JoinCode struct {
+	ID        int64
+	CreatedAt time.Time
+	SessionID int64
+	GameID    int64
+	Code      string
+	// ExpiresAt пуст у бессрочных кодов.
+	ExpiresAt *time.Time
+	MaxUses   int
+	Uses      int
+	RevokedAt *time.Time
+}
+
+func (c *JoinCode) IsActive(now time.Time) bool {
+	if c.RevokedAt != nil || c.Uses >= c.MaxUses {
+		return false
+	}
+	return c.ExpiresAt == nil || now.Before(*c.ExpiresAt)
+}
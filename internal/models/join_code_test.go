@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinCodeIsActive(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		code JoinCode
+		want bool
+	}{
+		{
+			name: "no expiry",
+			code: JoinCode{MaxUses: 1},
+			want: true,
+		},
+		{
+			name: "not expired",
+			code: JoinCode{ExpiresAt: &future, MaxUses: 3, Uses: 2},
+			want: true,
+		},
+		{
+			name: "expired",
+			code: JoinCode{ExpiresAt: &past, MaxUses: 1},
+			want: false,
+		},
+		{
+			name: "expires now",
+			code: JoinCode{ExpiresAt: &now, MaxUses: 1},
+			want: false,
+		},
+		{
+			name: "uses exhausted",
+			code: JoinCode{MaxUses: 2, Uses: 2},
+			want: false,
+		},
+		{
+			name: "revoked",
+			code: JoinCode{MaxUses: 1, RevokedAt: &past},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.code.IsActive(now); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrBuyerCannotSettle и ErrSellerCannotSettle указывают сторону сделки, которая не может её исполнить.
+	ErrBuyerCannotSettle  = errors.New("buyer cannot settle fill")
+	ErrSellerCannotSettle = errors.New("seller cannot settle fill")
+)
+
+type OrderSide int8
+
+const (
+	OrderSideBuy OrderSide = iota + 1
+	OrderSideSell
+)
+
+type OrderStatus int8
+
+const (
+	OrderStatusOpen OrderStatus = iota + 1
+	OrderStatusFilled
+	OrderStatusCancelled
+)
+
+// MarketOrder — лимитная заявка команды на покупку или продажу акций компании другим командам.
+type MarketOrder struct {
+	ID        int64
+	SessionID int64
+	TeamID    int64
+	// MemberID — участник, выставивший заявку, пуст при входе общим паролем команды.
+	MemberID  *int64
+	CompanyID int64
+	Side      OrderSide
+	Round     int
+	Price     int64
+	Count     int64
+	// Filled — количество уже исполненных акций заявки.
+	Filled    int64
+	Status    OrderStatus
+	CreatedAt time.Time
+}
+
+func (o *MarketOrder) Remaining() int64 {
+	return o.Count - o.Filled
+}
+
+// Fill отмечает исполнение count акций заявки и закрывает её, когда исполнено всё.
+func (o *MarketOrder) Fill(count int64) {
+	o.Filled += count
+	if o.Remaining() == 0 {
+		o.Status = OrderStatusFilled
+	}
+}
+
+// MarketFill описывает сделку между двумя заявками по цене заявки, стоявшей в стакане.
+type MarketFill struct {
+	BuyOrderID   int64
+	SellOrderID  int64
+	BuyerTeamID  int64
+	SellerTeamID int64
+	CompanyID    int64
+	Round        int
+	Price        int64
+	Count        int64
+	// BuyerMemberID и SellerMemberID — участники, выставившие заявки сторон.
+	BuyerMemberID  *int64
+	SellerMemberID *int64
+}
+
+type OrderBookLevel struct {
+	Price int64
+	Count int64
+}
+
+// OrderBook — агрегированная глубина стакана компании: Bids по убыванию цены, Asks по возрастанию.
+type OrderBook struct {
+	CompanyID int64
+	Bids      []OrderBookLevel
+	Asks      []OrderBookLevel
+}
@@ -0,0 +1,42 @@
+package models
+
+type RandomEventEffectType int8
+
+const (
+	// RandomEventEffectTypeBalanceDelta изменяет баланс команды на RandomEvent.BalanceDelta.
+	RandomEventEffectTypeBalanceDelta RandomEventEffectType = iota + 1
+	// RandomEventEffectTypeSharePriceMultiplier переоценивает пакет акций команды в компании RandomEvent.CompanyID
+	// с коэффициентом RandomEvent.PriceMultiplier, разница зачисляется (или списывается) с баланса.
+	RandomEventEffectTypeSharePriceMultiplier
+	// RandomEventEffectTypeTradingFrozen запрещает команде торговлю до конца раунда.
+	RandomEventEffectTypeTradingFrozen
+)
+
+type RandomEvent struct {
+	ID              int64
+	Name            string
+	Description     string
+	EffectType      RandomEventEffectType
+	BalanceDelta    int64
+	CompanyID       *int64
+	PriceMultiplier float64
+	// Probability - вероятность (в процентах) выпадения события для команды в начале раунда.
+	Probability int
+	Archived    *bool
+}
+
+func (e *RandomEvent) IsArchived() bool {
+	if e.Archived == nil {
+		return false
+	}
+	return *e.Archived
+}
+
+// TeamRandomEvent описывает событие, выпавшее команде в начале раунда.
+type TeamRandomEvent struct {
+	TeamID int64
+	Round  int
+	Event  RandomEvent
+	// Amount - сумма, на которую изменился баланс команды.
+	Amount int64
+}
@@ -7,8 +7,27 @@ const DefaultRoundsCount = 3
 type Settings struct {
 	RoundsCount               int
 	RoundsDuration            time.Duration
+	BriefingDuration          time.Duration
+	ResultsDuration           time.Duration
 	LinkToPDF                 string
 	EnableRandomEvents        bool
 	DefaultBalanceAmount      int64
 	DefaultAdditionalInfoCost int64
+	// ShortSellingLimit — максимальное количество акций одной компании в короткой позиции, 0 запрещает шорты.
+	ShortSellingLimit int64
+	// ShortMarginPercent — залог под короткую позицию в процентах от её текущей стоимости.
+	ShortMarginPercent int64
+	// EnableDemandPricing включает пересчёт цен нового раунда по спросу команд в прошлом раунде.
+	EnableDemandPricing bool
+	// DemandElasticityPercent — максимальное изменение цены в процентах, когда все сделки раунда были в одну сторону.
+	DemandElasticityPercent int64
+	// CommissionFlat и CommissionPercent — комиссия за сделку: фиксированная часть и процент от её оборота.
+	CommissionFlat    int64
+	CommissionPercent int64
+	// CapitalGainsTaxPercent — налог в процентах с прибыли от продажи акций дороже средней цены покупки.
+	CapitalGainsTaxPercent int64
+	// CreditLimit — максимальный долг команды перед банком, 0 запрещает кредиты.
+	CreditLimit int64
+	// LoanInterestPercent — проценты по долгу за раунд.
+	LoanInterestPercent int64
 }
@@ -11,18 +11,86 @@ var ErrSharesCountCannotBeNegative = errors.New("count of shares cannot be negat
 // Ключ - ID компании (Company.ID), значение - количество акций.
 type TeamSharesState map[int64]int64
 
+// TeamAveragePrices — средние цены покупки длинных позиций команды: ключ - ID компании, значение - цена.
+type TeamAveragePrices map[int64]int64
+
 type Team struct {
-	ID              int64
-	CreatedAt       time.Time
-	UpdatedAt       *time.Time
-	Name            string
-	Members         []string
-	Credentials     string
+	ID        int64
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	Name      string
+	Members   []string
+	// PasswordHash — bcrypt-хэш пароля команды, пуст у команд, созданных до перехода на хэши.
+	PasswordHash string
+	// Credentials — устаревшие учётные данные "name:password" в открытом виде,
+	// заменяются на PasswordHash при следующем входе команды.
+	Credentials     *string
 	BalanceID       int64
 	Shares          TeamSharesState
 	AdditionalInfos []int64
 	RandomEventID   *int64
+	SessionID       int64
 	GameID          int64
+	Version         int64
+	// AveragePrices пересчитываются при каждой сделке и не заполнены (nil) у команд,
+	// созданных до их появления.
+	AveragePrices TeamAveragePrices
+}
+
+// ApplyTrade пересчитывает средние цены после сделки changes по ценам tradePrices, positions — позиции
+// до сделки. Продажа не меняет среднюю цену, закрытие длинной позиции её удаляет.
+func (prices TeamAveragePrices) ApplyTrade(
+	positions TeamSharesState,
+	changes map[int64]int64,
+	tradePrices map[int64]int64,
+) {
+	for companyID, count := range changes {
+		position := positions[companyID]
+		if bought := min(count, position+count); bought > 0 {
+			long := max(position, 0)
+			prices[companyID] = (prices[companyID]*long + tradePrices[companyID]*bought) / (long + bought)
+		}
+		if position+count <= 0 {
+			delete(prices, companyID)
+		}
+	}
+}
+
+var ErrShortLimitExceeded = errors.New("short position exceeds limit")
+
+// ApplyChanges применяет изменения без проверок, допустимость позиций проверяет ValidateShortLimit.
+func (shares TeamSharesState) ApplyChanges(changes map[int64]int64) {
+	for share, count := range changes {
+		shares[share] += count
+	}
+}
+
+// ValidateShortLimit проверяет, что короткие позиции не превышают limit акций по каждой компании.
+// При limit == 0 короткие продажи запрещены.
+func (shares TeamSharesState) ValidateShortLimit(limit int64) error {
+	for _, count := range shares {
+		if count >= 0 {
+			continue
+		}
+		if limit == 0 {
+			return ErrSharesCountCannotBeNegative
+		}
+		if -count > limit {
+			return ErrShortLimitExceeded
+		}
+	}
+	return nil
+}
+
+// ShortPositions возвращает короткие позиции: ключ - ID компании, значение - количество акций в шорте.
+func (shares TeamSharesState) ShortPositions() map[int64]int64 {
+	shorts := make(map[int64]int64)
+	for share, count := range shares {
+		if count < 0 {
+			shorts[share] = -count
+		}
+	}
+	return shorts
 }
 
 func (shares TeamSharesState) MergeChanges(changes map[int64]int64) error {
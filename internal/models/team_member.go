@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// TeamMember — участник команды со своим входом. Торговать может только капитан,
+// остальные участники видят состояние команды, но не совершают сделок.
+type TeamMember struct {
+	ID           int64
+	CreatedAt    time.Time
+	TeamID       int64
+	Name         string
+	PasswordHash string
+	IsCaptain    bool
+}
+
+func (m *TeamMember) CanTrade() bool {
+	return m.IsCaptain
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTeamSharesStateValidateShortLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares TeamSharesState
+		limit  int64
+		want   error
+	}{
+		{
+			name:   "long positions without shorts allowed",
+			shares: TeamSharesState{1: 10, 2: 0},
+			limit:  0,
+			want:   nil,
+		},
+		{
+			name:   "short without shorts allowed",
+			shares: TeamSharesState{1: 10, 2: -1},
+			limit:  0,
+			want:   ErrSharesCountCannotBeNegative,
+		},
+		{
+			name:   "short within limit",
+			shares: TeamSharesState{1: -5, 2: -3},
+			limit:  5,
+			want:   nil,
+		},
+		{
+			name:   "short above limit",
+			shares: TeamSharesState{1: -6},
+			limit:  5,
+			want:   ErrShortLimitExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.shares.ValidateShortLimit(tt.limit); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateShortLimit() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeamAveragePricesApplyTrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		prices      TeamAveragePrices
+		positions   TeamSharesState
+		changes     map[int64]int64
+		tradePrices map[int64]int64
+		want        TeamAveragePrices
+	}{
+		{
+			name:        "first purchase",
+			prices:      TeamAveragePrices{},
+			positions:   TeamSharesState{},
+			changes:     map[int64]int64{1: 10},
+			tradePrices: map[int64]int64{1: 100},
+			want:        TeamAveragePrices{1: 100},
+		},
+		{
+			name:        "purchase averages with long position",
+			prices:      TeamAveragePrices{1: 100},
+			positions:   TeamSharesState{1: 10},
+			changes:     map[int64]int64{1: 30},
+			tradePrices: map[int64]int64{1: 200},
+			want:        TeamAveragePrices{1: 175},
+		},
+		{
+			name:        "partial sale keeps average",
+			prices:      TeamAveragePrices{1: 100},
+			positions:   TeamSharesState{1: 10},
+			changes:     map[int64]int64{1: -4},
+			tradePrices: map[int64]int64{1: 150},
+			want:        TeamAveragePrices{1: 100},
+		},
+		{
+			name:        "closing long removes average",
+			prices:      TeamAveragePrices{1: 100},
+			positions:   TeamSharesState{1: 10},
+			changes:     map[int64]int64{1: -15},
+			tradePrices: map[int64]int64{1: 150},
+			want:        TeamAveragePrices{},
+		},
+		{
+			name:        "covering short does not create average",
+			prices:      TeamAveragePrices{},
+			positions:   TeamSharesState{1: -10},
+			changes:     map[int64]int64{1: 6},
+			tradePrices: map[int64]int64{1: 80},
+			want:        TeamAveragePrices{},
+		},
+		{
+			name:        "flipping short to long averages only bought part",
+			prices:      TeamAveragePrices{},
+			positions:   TeamSharesState{1: -10},
+			changes:     map[int64]int64{1: 14},
+			tradePrices: map[int64]int64{1: 80},
+			want:        TeamAveragePrices{1: 80},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prices.ApplyTrade(tt.positions, tt.changes, tt.tradePrices)
+			if !reflect.DeepEqual(tt.prices, tt.want) {
+				t.Errorf("ApplyTrade() = %v, want %v", tt.prices, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package repo
 import "errors"
 
 var (
-	ErrNothingUpdated = errors.New("nothing updated")
-	ErrNotFound       = errors.New("not found")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrNothingUpdated  = errors.New("nothing updated")
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
 )
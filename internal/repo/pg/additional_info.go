@@ -36,8 +36,9 @@ returning id
 `
 
 func (r *AdditionalInfosRepo) Create(ctx context.Context, info *models.AdditionalInfo) (int64, error) {
-	rows, err := r.db.NamedQueryContext(
+	rows, err := sqlx.NamedQueryContext(
 		ctx,
+		getExecutor(ctx, r.db),
 		additionalInfosQueryCreate,
 		struct {
 			Name        string `db:"name"`
@@ -91,7 +92,7 @@ where id = :id
 `
 
 func (r *AdditionalInfosRepo) Update(ctx context.Context, info *models.AdditionalInfo) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		additionalInfosQueryUpdate,
 		struct {
@@ -146,7 +147,7 @@ func (r *AdditionalInfosRepo) GetAllActualWithType(
 	infoType models.AdditionalInfoType,
 ) ([]models.AdditionalInfo, error) {
 	var infos []additionalInfo
-	if err := r.db.SelectContext(ctx, &infos, additionalInfosQueryGetAllActual, infoType); err != nil {
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &infos, additionalInfosQueryGetAllActual, infoType); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -180,7 +181,7 @@ where id = $1
 
 func (r *AdditionalInfosRepo) GetByID(ctx context.Context, id int64) (*models.AdditionalInfo, error) {
 	var info additionalInfo
-	if err := r.db.GetContext(ctx, &info, additionalInfosQueryGetByID, id); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &info, additionalInfosQueryGetByID, id); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return &models.AdditionalInfo{
@@ -215,7 +216,7 @@ func (r *AdditionalInfosRepo) GetByIDs(ctx context.Context, ids []int64) ([]mode
 	query = r.db.Rebind(query)
 
 	var infos []additionalInfo
-	if err = r.db.SelectContext(ctx, &infos, query, args...); err != nil {
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &infos, query, args...); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -240,7 +241,7 @@ where id = $1
 `
 
 func (r *AdditionalInfosRepo) Delete(ctx context.Context, id int64) error {
-	if _, err := r.db.ExecContext(ctx, additionalInfosQueryDelete, id); err != nil {
+	if _, err := getExecutor(ctx, r.db).ExecContext(ctx, additionalInfosQueryDelete, id); err != nil {
 		return fmt.Errorf("exec error: %w", err)
 	}
 	return nil
@@ -0,0 +1,204 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"time"
+)
+
+type AdminsRepo struct {
+	db *sqlx.DB
+}
+
+func NewAdminsRepo(db *sqlx.DB) *AdminsRepo {
+	return &AdminsRepo{db: db}
+}
+
+type admin struct {
+	ID           int64     `db:"id"`
+	CreatedAt    time.Time `db:"created_at"`
+	Username     string    `db:"username"`
+	PasswordHash string    `db:"password_hash"`
+}
+
+func (a admin) toModel() models.Admin {
+	return models.Admin{
+		ID:           a.ID,
+		CreatedAt:    a.CreatedAt,
+		Username:     a.Username,
+		PasswordHash: a.PasswordHash,
+	}
+}
+
+const adminsQueryCreate = `
+insert into backend.admin (username, password_hash)
+values ($1, $2)
+returning id
+`
+
+func (r *AdminsRepo) Create(ctx context.Context, a *models.Admin) (int64, error) {
+	var id int64
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &id, adminsQueryCreate, a.Username, a.PasswordHash); err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	return id, nil
+}
+
+const adminsQueryUpdate = `
+update backend.admin
+set username = $1, password_hash = $2
+where id = $3
+`
+
+func (r *AdminsRepo) Update(ctx context.Context, a *models.Admin) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, adminsQueryUpdate, a.Username, a.PasswordHash, a.ID)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const adminsQueryDelete = `
+delete from backend.admin
+where id = $1
+`
+
+func (r *AdminsRepo) Delete(ctx context.Context, id int64) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, adminsQueryDelete, id)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+const adminsQueryGetByID = `
+select id, created_at, username, password_hash
+from backend.admin
+where id = $1
+`
+
+func (r *AdminsRepo) GetByID(ctx context.Context, id int64) (*models.Admin, error) {
+	var a admin
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &a, adminsQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(a.toModel()), nil
+}
+
+const adminsQueryGetByUsername = `
+select id, created_at, username, password_hash
+from backend.admin
+where username = $1
+`
+
+func (r *AdminsRepo) GetByUsername(ctx context.Context, username string) (*models.Admin, error) {
+	var a admin
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &a, adminsQueryGetByUsername, username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(a.toModel()), nil
+}
+
+const adminsQueryGetAll = `
+select id, created_at, username, password_hash
+from backend.admin
+order by id
+`
+
+func (r *AdminsRepo) GetAll(ctx context.Context) ([]models.Admin, error) {
+	var admins []admin
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &admins, adminsQueryGetAll); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(admins, func(item admin, _ int) models.Admin {
+		return item.toModel()
+	}), nil
+}
+
+type AdminActionsRepo struct {
+	db *sqlx.DB
+}
+
+func NewAdminActionsRepo(db *sqlx.DB) *AdminActionsRepo {
+	return &AdminActionsRepo{db: db}
+}
+
+type adminAction struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	AdminID   *int64    `db:"admin_id"`
+	SessionID int64     `db:"session_id"`
+	Action    string    `db:"action"`
+}
+
+const adminActionsQueryCreate = `
+insert into backend.admin_action (admin_id, session_id, action)
+values ($1, $2, $3)
+returning id
+`
+
+func (r *AdminActionsRepo) Create(ctx context.Context, action *models.AdminAction) (int64, error) {
+	var id int64
+	if err := getExecutor(ctx, r.db).GetContext(
+		ctx,
+		&id,
+		adminActionsQueryCreate,
+		action.AdminID,
+		action.SessionID,
+		action.Action,
+	); err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	return id, nil
+}
+
+const adminActionsQueryGetAllBySessionID = `
+select id, created_at, admin_id, session_id, action
+from backend.admin_action
+where session_id = $1
+order by created_at, id
+`
+
+func (r *AdminActionsRepo) GetAllBySessionID(ctx context.Context, sessionID int64) ([]models.AdminAction, error) {
+	var actions []adminAction
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &actions, adminActionsQueryGetAllBySessionID, sessionID); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(actions, func(item adminAction, _ int) models.AdminAction {
+		return models.AdminAction{
+			ID:        item.ID,
+			CreatedAt: item.CreatedAt,
+			AdminID:   item.AdminID,
+			SessionID: item.SessionID,
+			Action:    item.Action,
+		}
+	}), nil
+}
@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
+	"time"
 )
 
 type AuthRepo struct {
@@ -17,32 +20,236 @@ func NewAuthRepo(db *sqlx.DB) *AuthRepo {
 	return &AuthRepo{db: db}
 }
 
-const authQuerySetRefreshToken = `
-insert into backend.team_refresh_token(team_id, token)
-values ($1, $2)
-on conflict (team_id) do update set token = $2
+type authSession struct {
+	ID              int64      `db:"id"`
+	CreatedAt       time.Time  `db:"created_at"`
+	Role            string     `db:"role"`
+	SubjectID       int64      `db:"subject_id"`
+	MemberID        *int64     `db:"member_id"`
+	UserAgent       string     `db:"user_agent"`
+	RefreshToken    string     `db:"refresh_token"`
+	AccessJTI       string     `db:"access_jti"`
+	AccessExpiresAt time.Time  `db:"access_expires_at"`
+	RevokedAt       *time.Time `db:"revoked_at"`
+}
+
+func (s authSession) toModel() models.AuthSession {
+	return models.AuthSession{
+		ID:              s.ID,
+		CreatedAt:       s.CreatedAt,
+		Role:            models.Role(s.Role),
+		SubjectID:       s.SubjectID,
+		MemberID:        s.MemberID,
+		UserAgent:       s.UserAgent,
+		RefreshToken:    s.RefreshToken,
+		AccessJTI:       s.AccessJTI,
+		AccessExpiresAt: s.AccessExpiresAt,
+		RevokedAt:       s.RevokedAt,
+	}
+}
+
+type revokedAccessToken struct {
+	JTI       string    `db:"jti"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+const authQueryCreateSession = `
+insert into backend.auth_session (role, subject_id, member_id, user_agent)
+values ($1, $2, $3, $4)
+returning id
 `
 
-func (r *AuthRepo) SetRefreshToken(ctx context.Context, teamID int64, token string) error {
-	if _, err := r.db.ExecContext(ctx, authQuerySetRefreshToken, teamID, token); err != nil {
-		return fmt.Errorf("exec error: %w", err)
+func (r *AuthRepo) CreateSession(ctx context.Context, session *models.AuthSession) (int64, error) {
+	var id int64
+	if err := getExecutor(ctx, r.db).GetContext(
+		ctx,
+		&id,
+		authQueryCreateSession,
+		string(session.Role),
+		session.SubjectID,
+		session.MemberID,
+		session.UserAgent,
+	); err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	return id, nil
+}
+
+const authQueryUpdateSessionTokens = `
+update backend.auth_session
+set refresh_token = $1, access_jti = $2, access_expires_at = $3
+where id = $4 and revoked_at isnull and refresh_token = $5
+`
+
+// UpdateSessionTokens сохраняет токены, выданные сессии, если её refresh-токен всё ещё равен
+// previousRefreshToken. Отозванные и уже обновлённые параллельно сессии не обновляются.
+func (r *AuthRepo) UpdateSessionTokens(
+	ctx context.Context,
+	session *models.AuthSession,
+	previousRefreshToken string,
+) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		authQueryUpdateSessionTokens,
+		session.RefreshToken,
+		session.AccessJTI,
+		session.AccessExpiresAt,
+		session.ID,
+		previousRefreshToken,
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
 	}
 	return nil
 }
 
-const authQueryVerifyRefreshToken = `
-select 1
-from backend.team_refresh_token
-where team_id = $1 and token = $2
+const authQueryGetSessionByID = `
+select
+    id,
+    created_at,
+    role,
+    subject_id,
+    member_id,
+    user_agent,
+    refresh_token,
+    access_jti,
+    access_expires_at,
+    revoked_at
+from backend.auth_session
+where id = $1
 `
 
-func (r *AuthRepo) VerifyRefreshToken(ctx context.Context, userID int64, token string) (bool, error) {
-	var ok int8
-	if err := r.db.GetContext(ctx, &ok, authQueryVerifyRefreshToken, userID, token); err != nil {
+func (r *AuthRepo) GetSessionByID(ctx context.Context, id int64) (*models.AuthSession, error) {
+	var s authSession
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &s, authQueryGetSessionByID, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return false, repo.ErrNotFound
+			return nil, repo.ErrNotFound
 		}
-		return false, fmt.Errorf("query error: %w", err)
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(s.toModel()), nil
+}
+
+const authQueryGetActiveSessionsBySubject = `
+select
+    id,
+    created_at,
+    role,
+    subject_id,
+    member_id,
+    user_agent,
+    refresh_token,
+    access_jti,
+    access_expires_at,
+    revoked_at
+from backend.auth_session
+where role = $1 and subject_id = $2 and revoked_at isnull
+order by id
+`
+
+func (r *AuthRepo) GetActiveSessionsBySubject(
+	ctx context.Context,
+	role models.Role,
+	subjectID int64,
+) ([]models.AuthSession, error) {
+	var sessions []authSession
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&sessions,
+		authQueryGetActiveSessionsBySubject,
+		string(role),
+		subjectID,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(sessions, func(item authSession, _ int) models.AuthSession {
+		return item.toModel()
+	}), nil
+}
+
+const authQueryRevokeSessions = `
+update backend.auth_session
+set revoked_at = now()
+where id in (?) and revoked_at isnull
+`
+
+func (r *AuthRepo) RevokeSessions(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
 	}
-	return true, nil
+
+	query, args, err := sqlx.In(authQueryRevokeSessions, ids)
+	if err != nil {
+		return fmt.Errorf("sqlx.In: %w", err)
+	}
+	query = r.db.Rebind(query)
+	if _, err = getExecutor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
+}
+
+const authQueryRevokeAccessTokens = `
+insert into backend.revoked_access_token (jti, expires_at)
+values (:jti, :expires_at)
+on conflict (jti) do nothing
+`
+
+func (r *AuthRepo) RevokeAccessTokens(ctx context.Context, tokens []models.RevokedAccessToken) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	if _, err := getExecutor(ctx, r.db).NamedExecContext(
+		ctx,
+		authQueryRevokeAccessTokens,
+		lo.Map(tokens, func(item models.RevokedAccessToken, _ int) revokedAccessToken {
+			return revokedAccessToken{
+				JTI:       item.JTI,
+				ExpiresAt: item.ExpiresAt,
+			}
+		}),
+	); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
+}
+
+const authQueryGetActiveRevokedAccessTokens = `
+select jti, expires_at
+from backend.revoked_access_token
+where expires_at > now()
+`
+
+func (r *AuthRepo) GetActiveRevokedAccessTokens(ctx context.Context) ([]models.RevokedAccessToken, error) {
+	var tokens []revokedAccessToken
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &tokens, authQueryGetActiveRevokedAccessTokens); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(tokens, func(item revokedAccessToken, _ int) models.RevokedAccessToken {
+		return models.RevokedAccessToken{
+			JTI:       item.JTI,
+			ExpiresAt: item.ExpiresAt,
+		}
+	}), nil
+}
+
+const authQueryDeleteExpiredRevokedAccessTokens = `
+delete from backend.revoked_access_token
+where expires_at <= now()
+`
+
+func (r *AuthRepo) DeleteExpiredRevokedAccessTokens(ctx context.Context) error {
+	if _, err := getExecutor(ctx, r.db).ExecContext(ctx, authQueryDeleteExpiredRevokedAccessTokens); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
 )
@@ -22,37 +23,47 @@ func NewBalanceTransactionsRepo(db *sqlx.DB) *BalanceTransactionsRepo {
 type balanceTransaction struct {
 	ID               int64  `db:"id"`
 	BalanceID        int64  `db:"balance_id"`
+	Type             int8   `db:"type"`
 	Round            int    `db:"round"`
 	Amount           int64  `db:"amount"`
 	Details          []byte `db:"details"`
 	AdditionalInfoID *int64 `db:"additional_info_id"`
 	RandomEventID    *int64 `db:"random_event_id"`
+	ParentID         *int64 `db:"parent_id"`
+	MemberID         *int64 `db:"member_id"`
 }
 
 const balanceTransactionsQueryCreate = `
-insert into backend.balance_transaction (balance_id, round, amount, details, additional_info_id, random_event_id) 
-values (:balance_id, :round, :amount, :details, :additional_info_id, :random_event_id)
+insert into backend.balance_transaction (balance_id, type, round, amount, details, additional_info_id, random_event_id, parent_id, member_id) 
+values (:balance_id, :type, :round, :amount, :details, :additional_info_id, :random_event_id, :parent_id, :member_id)
 returning id
 `
 
 func (r *BalanceTransactionsRepo) Create(ctx context.Context, tr *models.BalanceTransaction) (int64, error) {
-	rows, err := r.db.NamedQueryContext(
+	rows, err := sqlx.NamedQueryContext(
 		ctx,
+		getExecutor(ctx, r.db),
 		balanceTransactionsQueryCreate,
 		struct {
 			BalanceID        int64  `db:"balance_id"`
+			Type             int8   `db:"type"`
 			Round            int    `db:"round"`
 			Amount           int64  `db:"amount"`
 			Details          any    `db:"details"`
 			AdditionalInfoID *int64 `db:"additional_info_id"`
 			RandomEventID    *int64 `db:"random_event_id"`
+			ParentID         *int64 `db:"parent_id"`
+			MemberID         *int64 `db:"member_id"`
 		}{
 			BalanceID:        tr.BalanceID,
+			Type:             int8(tr.Type),
 			Round:            tr.Round,
 			Amount:           tr.Amount,
 			Details:          tr.Details,
 			AdditionalInfoID: tr.AdditionalInfoID,
 			RandomEventID:    tr.RandomEventID,
+			ParentID:         tr.ParentID,
+			MemberID:         tr.MemberID,
 		},
 	)
 	if err != nil {
@@ -73,95 +84,46 @@ func (r *BalanceTransactionsRepo) Create(ctx context.Context, tr *models.Balance
 	return id, nil
 }
 
-const balanceTransactionsQueryUpdate = `
-update backend.balance_transaction
-set (
-     amount, 
-     details, 
-     additional_info_id, 
-     random_event_id
-) = (
-     :amount, 
-     :details, 
-     :additional_info_id, 
-     :random_event_id
-)
-where balance_id = :balance_id and round = :round and (additional_info_id isnull and random_event_id isnull)
-`
-
-func (r *BalanceTransactionsRepo) Update(ctx context.Context, tr *models.BalanceTransaction) error {
-	result, err := r.db.NamedExecContext(
-		ctx,
-		balanceTransactionsQueryUpdate,
-		struct {
-			BalanceID        int64  `db:"balance_id"`
-			Round            int    `db:"round"`
-			Amount           int64  `db:"amount"`
-			Details          any    `db:"details"`
-			AdditionalInfoID *int64 `db:"additional_info_id"`
-			RandomEventID    *int64 `db:"random_event_id"`
-		}{
-			BalanceID:        tr.BalanceID,
-			Round:            tr.Round,
-			Amount:           tr.Amount,
-			Details:          tr.Details,
-			AdditionalInfoID: tr.AdditionalInfoID,
-			RandomEventID:    tr.RandomEventID,
-		},
-	)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return repo.ErrNotFound
-		}
-		return fmt.Errorf("query error: %w", err)
-	}
-
-	affected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("get affected rows: %w", err)
-	}
-	if affected == 0 {
-		return repo.ErrNothingUpdated
-	}
-	return nil
-}
-
 const balanceTransactionsQueryDelete = `
 delete from backend.balance_transaction
-where balance_id = $1 and round = $2 and (additional_info_id isnull and random_event_id isnull)
+where id = $1
 `
 
-func (r *BalanceTransactionsRepo) Delete(
-	ctx context.Context,
-	balanceID int64,
-	round int,
-) error {
-	if _, err := r.db.ExecContext(ctx, balanceTransactionsQueryDelete, balanceID, round); err != nil {
+func (r *BalanceTransactionsRepo) Delete(ctx context.Context, id int64) error {
+	if _, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		balanceTransactionsQueryDelete,
+		id,
+	); err != nil {
 		return fmt.Errorf("exec error: %w", err)
 	}
 	return nil
 }
 
-const balanceTransactionsQueryGet = `
+const balanceTransactionsQueryGetByID = `
 select
     id, 
     balance_id, 
+    type,
     round, 
     amount, 
     details, 
     additional_info_id, 
-    random_event_id
+    random_event_id,
+    parent_id,
+    member_id
 from backend.balance_transaction
-where balance_id = $1 and round = $2 and (additional_info_id isnull and random_event_id isnull)
+where id = $1
 `
 
-func (r *BalanceTransactionsRepo) Get(
-	ctx context.Context,
-	balanceID int64,
-	round int,
-) (*models.BalanceTransaction, error) {
+func (r *BalanceTransactionsRepo) GetByID(ctx context.Context, id int64) (*models.BalanceTransaction, error) {
 	var tr balanceTransaction
-	if err := r.db.GetContext(ctx, &tr, balanceTransactionsQueryGet, balanceID, round); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(
+		ctx,
+		&tr,
+		balanceTransactionsQueryGetByID,
+		id,
+	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repo.ErrNotFound
 		}
@@ -170,11 +132,14 @@ func (r *BalanceTransactionsRepo) Get(
 	model := &models.BalanceTransaction{
 		ID:               tr.ID,
 		BalanceID:        tr.BalanceID,
+		Type:             models.BalanceTransactionType(tr.Type),
 		Round:            tr.Round,
 		Amount:           tr.Amount,
 		Details:          nil,
 		AdditionalInfoID: tr.AdditionalInfoID,
 		RandomEventID:    tr.RandomEventID,
+		ParentID:         tr.ParentID,
+		MemberID:         tr.MemberID,
 	}
 	if len(tr.Details) != 0 {
 		if err := jsoniter.Unmarshal(tr.Details, &model.Details); err != nil {
@@ -183,3 +148,246 @@ func (r *BalanceTransactionsRepo) Get(
 	}
 	return model, nil
 }
+
+const balanceTransactionsQueryGetAllByBalanceIDAndRound = `
+select
+    id,
+    balance_id,
+    type,
+    round,
+    amount,
+    details,
+    additional_info_id,
+    random_event_id,
+    parent_id,
+    member_id
+from backend.balance_transaction
+where balance_id = $1 and round = $2
+order by id
+`
+
+func (r *BalanceTransactionsRepo) GetAllByBalanceIDAndRound(
+	ctx context.Context,
+	balanceID int64,
+	round int,
+) ([]models.BalanceTransaction, error) {
+	var trs []balanceTransaction
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&trs,
+		balanceTransactionsQueryGetAllByBalanceIDAndRound,
+		balanceID,
+		round,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	result := make([]models.BalanceTransaction, 0, len(trs))
+	for _, tr := range trs {
+		model := models.BalanceTransaction{
+			ID:               tr.ID,
+			BalanceID:        tr.BalanceID,
+			Type:             models.BalanceTransactionType(tr.Type),
+			Round:            tr.Round,
+			Amount:           tr.Amount,
+			Details:          nil,
+			AdditionalInfoID: tr.AdditionalInfoID,
+			RandomEventID:    tr.RandomEventID,
+			ParentID:         tr.ParentID,
+			MemberID:         tr.MemberID,
+		}
+		if len(tr.Details) != 0 {
+			if err := jsoniter.Unmarshal(tr.Details, &model.Details); err != nil {
+				return nil, fmt.Errorf("unmarshal json: %T:%w", model.Details, err)
+			}
+		}
+		result = append(result, model)
+	}
+	return result, nil
+}
+
+const balanceTransactionsQueryGetAllByGameIDAndRound = `
+select
+    bt.id,
+    bt.balance_id,
+    bt.type,
+    bt.round,
+    bt.amount,
+    bt.details,
+    bt.additional_info_id,
+    bt.random_event_id,
+    bt.parent_id,
+    bt.member_id
+from backend.balance_transaction bt
+join backend.team t on t.balance_id = bt.balance_id
+where t.session_id = $1 and t.game_id = $2 and bt.round = $3 and bt.type = $4
+`
+
+func (r *BalanceTransactionsRepo) GetAllByGameIDAndRound(
+	ctx context.Context,
+	sessionID int64,
+	gameID int64,
+	round int,
+	trType models.BalanceTransactionType,
+) ([]models.BalanceTransaction, error) {
+	var trs []balanceTransaction
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&trs,
+		balanceTransactionsQueryGetAllByGameIDAndRound,
+		sessionID,
+		gameID,
+		round,
+		trType,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	result := make([]models.BalanceTransaction, 0, len(trs))
+	for _, tr := range trs {
+		model := models.BalanceTransaction{
+			ID:               tr.ID,
+			BalanceID:        tr.BalanceID,
+			Type:             models.BalanceTransactionType(tr.Type),
+			Round:            tr.Round,
+			Amount:           tr.Amount,
+			Details:          nil,
+			AdditionalInfoID: tr.AdditionalInfoID,
+			RandomEventID:    tr.RandomEventID,
+			ParentID:         tr.ParentID,
+			MemberID:         tr.MemberID,
+		}
+		if len(tr.Details) != 0 {
+			if err := jsoniter.Unmarshal(tr.Details, &model.Details); err != nil {
+				return nil, fmt.Errorf("unmarshal json: %T:%w", model.Details, err)
+			}
+		}
+		result = append(result, model)
+	}
+	return result, nil
+}
+
+const balanceTransactionsQueryGetAllByBalanceID = `
+select
+    id,
+    balance_id,
+    type,
+    round,
+    amount,
+    details,
+    additional_info_id,
+    random_event_id,
+    parent_id,
+    member_id
+from backend.balance_transaction
+where balance_id = $1
+order by id
+`
+
+func (r *BalanceTransactionsRepo) GetAllByBalanceID(ctx context.Context, balanceID int64) ([]models.BalanceTransaction, error) {
+	var trs []balanceTransaction
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&trs,
+		balanceTransactionsQueryGetAllByBalanceID,
+		balanceID,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	result := make([]models.BalanceTransaction, 0, len(trs))
+	for _, tr := range trs {
+		model := models.BalanceTransaction{
+			ID:               tr.ID,
+			BalanceID:        tr.BalanceID,
+			Type:             models.BalanceTransactionType(tr.Type),
+			Round:            tr.Round,
+			Amount:           tr.Amount,
+			Details:          nil,
+			AdditionalInfoID: tr.AdditionalInfoID,
+			RandomEventID:    tr.RandomEventID,
+			ParentID:         tr.ParentID,
+			MemberID:         tr.MemberID,
+		}
+		if len(tr.Details) != 0 {
+			if err := jsoniter.Unmarshal(tr.Details, &model.Details); err != nil {
+				return nil, fmt.Errorf("unmarshal json: %T:%w", model.Details, err)
+			}
+		}
+		result = append(result, model)
+	}
+	return result, nil
+}
+
+const balanceTransactionsQueryGetList = `
+select
+    id,
+    balance_id,
+    type,
+    round,
+    amount,
+    details,
+    additional_info_id,
+    random_event_id,
+    parent_id,
+    member_id
+from backend.balance_transaction
+where balance_id in (?)
+  and (?::integer is null or round = ?)
+  and (?::smallint is null or type = ?)
+order by id
+`
+
+func (r *BalanceTransactionsRepo) GetList(
+	ctx context.Context,
+	filter models.BalanceTransactionFilter,
+) ([]models.BalanceTransaction, error) {
+	if len(filter.BalanceIDs) == 0 {
+		return nil, nil
+	}
+
+	var trType *int8
+	if filter.Type != nil {
+		trType = lo.ToPtr(int8(*filter.Type))
+	}
+	query, args, err := sqlx.In(
+		balanceTransactionsQueryGetList,
+		filter.BalanceIDs,
+		filter.Round,
+		filter.Round,
+		trType,
+		trType,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.In: %w", err)
+	}
+	query = r.db.Rebind(query)
+
+	var trs []balanceTransaction
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &trs, query, args...); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	result := make([]models.BalanceTransaction, 0, len(trs))
+	for _, tr := range trs {
+		model := models.BalanceTransaction{
+			ID:               tr.ID,
+			BalanceID:        tr.BalanceID,
+			Type:             models.BalanceTransactionType(tr.Type),
+			Round:            tr.Round,
+			Amount:           tr.Amount,
+			Details:          nil,
+			AdditionalInfoID: tr.AdditionalInfoID,
+			RandomEventID:    tr.RandomEventID,
+			ParentID:         tr.ParentID,
+			MemberID:         tr.MemberID,
+		}
+		if len(tr.Details) != 0 {
+			if err := jsoniter.Unmarshal(tr.Details, &model.Details); err != nil {
+				return nil, fmt.Errorf("unmarshal json: %T:%w", model.Details, err)
+			}
+		}
+		result = append(result, model)
+	}
+	return result, nil
+}
@@ -2,9 +2,12 @@ package pg
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
 )
 
 type BalancesRepo struct {
@@ -16,8 +19,11 @@ func NewBalancesRepo(db *sqlx.DB) *BalancesRepo {
 }
 
 type balance struct {
-	ID     int64 `db:"id"`
-	Amount int64 `db:"amount"`
+	ID         int64 `db:"id"`
+	Amount     int64 `db:"amount"`
+	Collateral int64 `db:"collateral"`
+	Loan       int64 `db:"loan"`
+	Version    int64 `db:"version"`
 }
 
 const balancesQueryCreate = `
@@ -27,7 +33,7 @@ returning id
 `
 
 func (r *BalancesRepo) Create(ctx context.Context, balance *models.Balance) (int64, error) {
-	rows, err := r.db.QueryxContext(ctx, balancesQueryCreate, balance.Amount)
+	rows, err := getExecutor(ctx, r.db).QueryxContext(ctx, balancesQueryCreate, balance.Amount)
 	if err != nil {
 		return 0, fmt.Errorf("query error: %w", err)
 	}
@@ -48,30 +54,83 @@ func (r *BalancesRepo) Create(ctx context.Context, balance *models.Balance) (int
 
 const balancesQueryUpdate = `
 update backend.balance 
-set amount = $1 
-where id = $2
+set amount = $1, collateral = $2, loan = $3, version = version + 1
+where id = $4 and version = $5
 `
 
 func (r *BalancesRepo) Update(ctx context.Context, balance *models.Balance) error {
-	if _, err := r.db.ExecContext(ctx, balancesQueryUpdate, balance.Amount, balance.ID); err != nil {
+	result, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		balancesQueryUpdate,
+		balance.Amount,
+		balance.Collateral,
+		balance.Loan,
+		balance.ID,
+		balance.Version,
+	)
+	if err != nil {
 		return fmt.Errorf("query error: %w", err)
 	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return versionConflictOrNotFound(ctx, r.db, balancesQueryExists, balance.ID)
+	}
+	balance.Version++
 	return nil
 }
 
+const balancesQueryExists = `
+select exists(select 1 from backend.balance where id = $1)
+`
+
 const balancesQueryGet = `
-select id, amount 
+select id, amount, collateral, loan, version
 from backend.balance 
 where id = $1
 `
 
 func (r *BalancesRepo) GetByID(ctx context.Context, id int64) (*models.Balance, error) {
 	var b balance
-	if err := r.db.GetContext(ctx, &b, balancesQueryGet, id); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &b, balancesQueryGet, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return &models.Balance{
+		ID:         b.ID,
+		Amount:     b.Amount,
+		Collateral: b.Collateral,
+		Loan:       b.Loan,
+		Version:    b.Version,
+	}, nil
+}
+
+const balancesQueryGetForUpdate = `
+select id, amount, collateral, loan, version
+from backend.balance 
+where id = $1
+for update
+`
+
+// GetByIDForUpdate блокирует строку баланса до конца транзакции, вызывается внутри TxManager.WithinTx.
+func (r *BalancesRepo) GetByIDForUpdate(ctx context.Context, id int64) (*models.Balance, error) {
+	var b balance
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &b, balancesQueryGetForUpdate, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return &models.Balance{
-		ID:     b.ID,
-		Amount: b.Amount,
+		ID:         b.ID,
+		Amount:     b.Amount,
+		Collateral: b.Collateral,
+		Loan:       b.Loan,
+		Version:    b.Version,
 	}, nil
 }
@@ -0,0 +1,358 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+)
+
+type BondsRepo struct {
+	db *sqlx.DB
+}
+
+func NewBondsRepo(db *sqlx.DB) *BondsRepo {
+	return &BondsRepo{db: db}
+}
+
+type bond struct {
+	ID             int64  `db:"id"`
+	Name           string `db:"name"`
+	Type           int8   `db:"type"`
+	Price          int64  `db:"price"`
+	CouponPercent  int64  `db:"coupon_percent"`
+	MaturityRounds int    `db:"maturity_rounds"`
+	Archived       *bool  `db:"archived"`
+}
+
+func (b bond) toModel() models.Bond {
+	return models.Bond{
+		ID:             b.ID,
+		Name:           b.Name,
+		Type:           models.BondType(b.Type),
+		Price:          b.Price,
+		CouponPercent:  b.CouponPercent,
+		MaturityRounds: b.MaturityRounds,
+		Archived:       b.Archived,
+	}
+}
+
+const bondsQueryCreate = `
+insert into backend.bond (name, type, price, coupon_percent, maturity_rounds, archived)
+values (:name, :type, :price, :coupon_percent, :maturity_rounds, :archived)
+returning id
+`
+
+func (r *BondsRepo) Create(ctx context.Context, b *models.Bond) (int64, error) {
+	rows, err := sqlx.NamedQueryContext(
+		ctx,
+		getExecutor(ctx, r.db),
+		bondsQueryCreate,
+		bond{
+			Name:           b.Name,
+			Type:           int8(b.Type),
+			Price:          b.Price,
+			CouponPercent:  b.CouponPercent,
+			MaturityRounds: b.MaturityRounds,
+			Archived:       b.Archived,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var id int64
+	for rows.Next() {
+		if err = rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	return id, nil
+}
+
+const bondsQueryUpdate = `
+update backend.bond
+set (
+    name,
+    type,
+    price,
+    coupon_percent,
+    maturity_rounds,
+    archived
+) = (
+    :name,
+    :type,
+    :price,
+    :coupon_percent,
+    :maturity_rounds,
+    :archived
+)
+where id = :id
+`
+
+func (r *BondsRepo) Update(ctx context.Context, b *models.Bond) error {
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
+		ctx,
+		bondsQueryUpdate,
+		bond{
+			ID:             b.ID,
+			Name:           b.Name,
+			Type:           int8(b.Type),
+			Price:          b.Price,
+			CouponPercent:  b.CouponPercent,
+			MaturityRounds: b.MaturityRounds,
+			Archived:       b.Archived,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const bondsQueryGetByID = `
+select
+    id,
+    name,
+    type,
+    price,
+    coupon_percent,
+    maturity_rounds,
+    archived
+from backend.bond
+where id = $1
+`
+
+func (r *BondsRepo) GetByID(ctx context.Context, id int64) (*models.Bond, error) {
+	var b bond
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &b, bondsQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(b.toModel()), nil
+}
+
+const bondsQueryGetAllNotArchived = `
+select
+    id,
+    name,
+    type,
+    price,
+    coupon_percent,
+    maturity_rounds,
+    archived
+from backend.bond
+where not archived or archived isnull
+order by id
+`
+
+func (r *BondsRepo) GetAllNotArchived(ctx context.Context) ([]models.Bond, error) {
+	var bonds []bond
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &bonds, bondsQueryGetAllNotArchived); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(bonds, func(item bond, _ int) models.Bond {
+		return item.toModel()
+	}), nil
+}
+
+type TeamBondsRepo struct {
+	db *sqlx.DB
+}
+
+func NewTeamBondsRepo(db *sqlx.DB) *TeamBondsRepo {
+	return &TeamBondsRepo{db: db}
+}
+
+type teamBond struct {
+	ID            int64 `db:"id"`
+	TeamID        int64 `db:"team_id"`
+	BondID        int64 `db:"bond_id"`
+	Count         int64 `db:"count"`
+	Price         int64 `db:"price"`
+	CouponPercent int64 `db:"coupon_percent"`
+	PurchaseRound int   `db:"purchase_round"`
+	MaturityRound int   `db:"maturity_round"`
+	Redeemed      bool  `db:"redeemed"`
+}
+
+func (b teamBond) toModel() models.TeamBond {
+	return models.TeamBond{
+		ID:            b.ID,
+		TeamID:        b.TeamID,
+		BondID:        b.BondID,
+		Count:         b.Count,
+		Price:         b.Price,
+		CouponPercent: b.CouponPercent,
+		PurchaseRound: b.PurchaseRound,
+		MaturityRound: b.MaturityRound,
+		Redeemed:      b.Redeemed,
+	}
+}
+
+const teamBondsQueryCreate = `
+insert into backend.team_bond (
+    team_id,
+    bond_id,
+    count,
+    price,
+    coupon_percent,
+    purchase_round,
+    maturity_round,
+    redeemed
+)
+values (
+    :team_id,
+    :bond_id,
+    :count,
+    :price,
+    :coupon_percent,
+    :purchase_round,
+    :maturity_round,
+    :redeemed
+)
+returning id
+`
+
+func (r *TeamBondsRepo) Create(ctx context.Context, b *models.TeamBond) (int64, error) {
+	rows, err := sqlx.NamedQueryContext(
+		ctx,
+		getExecutor(ctx, r.db),
+		teamBondsQueryCreate,
+		teamBond{
+			TeamID:        b.TeamID,
+			BondID:        b.BondID,
+			Count:         b.Count,
+			Price:         b.Price,
+			CouponPercent: b.CouponPercent,
+			PurchaseRound: b.PurchaseRound,
+			MaturityRound: b.MaturityRound,
+			Redeemed:      b.Redeemed,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var id int64
+	for rows.Next() {
+		if err = rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	return id, nil
+}
+
+const teamBondsQueryUpdate = `
+update backend.team_bond
+set redeemed = :redeemed
+where id = :id
+`
+
+// Update изменяет только признак погашения, условия пакета после покупки не меняются.
+func (r *TeamBondsRepo) Update(ctx context.Context, b *models.TeamBond) error {
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
+		ctx,
+		teamBondsQueryUpdate,
+		teamBond{
+			ID:       b.ID,
+			Redeemed: b.Redeemed,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const teamBondsQueryGetAllByTeamID = `
+select
+    id,
+    team_id,
+    bond_id,
+    count,
+    price,
+    coupon_percent,
+    purchase_round,
+    maturity_round,
+    redeemed
+from backend.team_bond
+where team_id = $1
+order by id
+`
+
+func (r *TeamBondsRepo) GetAllByTeamID(ctx context.Context, teamID int64) ([]models.TeamBond, error) {
+	var bonds []teamBond
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &bonds, teamBondsQueryGetAllByTeamID, teamID); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(bonds, func(item teamBond, _ int) models.TeamBond {
+		return item.toModel()
+	}), nil
+}
+
+const teamBondsQueryGetAllNotRedeemedByTeamIDs = `
+select
+    id,
+    team_id,
+    bond_id,
+    count,
+    price,
+    coupon_percent,
+    purchase_round,
+    maturity_round,
+    redeemed
+from backend.team_bond
+where team_id in (?) and not redeemed
+order by id
+`
+
+func (r *TeamBondsRepo) GetAllNotRedeemedByTeamIDs(ctx context.Context, teamIDs []int64) ([]models.TeamBond, error) {
+	if len(teamIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := sqlx.In(teamBondsQueryGetAllNotRedeemedByTeamIDs, teamIDs)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.In: %w", err)
+	}
+	query = r.db.Rebind(query)
+
+	var bonds []teamBond
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &bonds, query, args...); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(bonds, func(item teamBond, _ int) models.TeamBond {
+		return item.toModel()
+	}), nil
+}
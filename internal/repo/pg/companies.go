@@ -31,8 +31,9 @@ returning id
 `
 
 func (r *CompaniesRepo) Create(ctx context.Context, company *models.Company) (int64, error) {
-	rows, err := r.db.NamedQueryContext(
+	rows, err := sqlx.NamedQueryContext(
 		ctx,
+		getExecutor(ctx, r.db),
 		companiesRepoQueryCreate,
 		struct {
 			Name     string `db:"name"`
@@ -73,7 +74,7 @@ where id = :id
 `
 
 func (r *CompaniesRepo) Update(ctx context.Context, company *models.Company) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		companiesRepoQueryUpdate,
 		struct {
@@ -114,7 +115,7 @@ where id = $1
 
 func (r *CompaniesRepo) GetByID(ctx context.Context, id int64) (*models.Company, error) {
 	var c company
-	if err := r.db.GetContext(ctx, &c, companiesRepoQueryGetByID, id); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &c, companiesRepoQueryGetByID, id); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return &models.Company{
@@ -135,7 +136,7 @@ where not archived or archived isnull
 
 func (r *CompaniesRepo) GetAllNotArchived(ctx context.Context) ([]models.Company, error) {
 	var companies []company
-	if err := r.db.SelectContext(ctx, &companies, companiesRepoQueryGetAll); err != nil {
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &companies, companiesRepoQueryGetAll); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -24,26 +24,30 @@ type share struct {
 	CompanyID int64 `db:"company_id"`
 	Round     int   `db:"round"`
 	Price     int64 `db:"price"`
+	Dividend  int64 `db:"dividend"`
 }
 
 const companySharesQueryCreate = `
-insert into backend.company_share (company_id, round, price) 
-values (:company_id, :round, :price)
+insert into backend.company_share (company_id, round, price, dividend) 
+values (:company_id, :round, :price, :dividend)
 returning id
 `
 
 func (r *CompanySharesRepo) Create(ctx context.Context, share *models.CompanyShare) (int64, error) {
-	rows, err := r.db.NamedQueryContext(
+	rows, err := sqlx.NamedQueryContext(
 		ctx,
+		getExecutor(ctx, r.db),
 		companySharesQueryCreate,
 		struct {
 			CompanyID int64 `db:"company_id"`
 			Round     int   `db:"round"`
 			Price     int64 `db:"price"`
+			Dividend  int64 `db:"dividend"`
 		}{
 			CompanyID: share.CompanyID,
 			Round:     share.Round,
 			Price:     share.Price,
+			Dividend:  share.Dividend,
 		},
 	)
 	if err != nil {
@@ -70,7 +74,7 @@ where company_id = :company_id and round = :round
 `
 
 func (r *CompanySharesRepo) Update(ctx context.Context, share *models.CompanyShare) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		companySharesQueryUpdate,
 		struct {
@@ -100,12 +104,41 @@ func (r *CompanySharesRepo) Update(ctx context.Context, share *models.CompanySha
 	return nil
 }
 
+const companySharesQueryUpdateDividend = `
+update backend.company_share 
+set dividend = $3
+where company_id = $1 and round = $2
+`
+
+func (r *CompanySharesRepo) UpdateDividend(ctx context.Context, companyID int64, round int, dividend int64) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		companySharesQueryUpdateDividend,
+		companyID,
+		round,
+		dividend,
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
 const companySharesQueryGetAllActual = `
 select 
     cs.id, 
     cs.company_id, 
     cs.round, 
-    cs.price
+    cs.price,
+    cs.dividend
 from backend.company_share cs
 left join backend.company c on c.id = cs.company_id
 where not c.archived or c.archived isnull 
@@ -113,7 +146,7 @@ where not c.archived or c.archived isnull
 
 func (r *CompanySharesRepo) GetAllActual(ctx context.Context) ([]models.CompanyShare, error) {
 	var shares []share
-	if err := r.db.SelectContext(ctx, &shares, companySharesQueryGetAllActual); err != nil {
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &shares, companySharesQueryGetAllActual); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -124,6 +157,7 @@ func (r *CompanySharesRepo) GetAllActual(ctx context.Context) ([]models.CompanyS
 				CompanyID: item.CompanyID,
 				Round:     item.Round,
 				Price:     item.Price,
+				Dividend:  item.Dividend,
 			}
 		},
 	), nil
@@ -134,7 +168,8 @@ select
     cs.id, 
     cs.company_id, 
     cs.round, 
-    cs.price
+    cs.price,
+    cs.dividend
 from backend.company_share cs
 where cs.id in (?)
 `
@@ -147,7 +182,7 @@ func (r *CompanySharesRepo) GetListByIDs(ctx context.Context, ids []int64) ([]mo
 	query = r.db.Rebind(query)
 
 	var shares []share
-	if err = r.db.SelectContext(ctx, &shares, query, args...); err != nil {
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &shares, query, args...); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 
@@ -159,6 +194,7 @@ func (r *CompanySharesRepo) GetListByIDs(ctx context.Context, ids []int64) ([]mo
 				CompanyID: item.CompanyID,
 				Round:     item.Round,
 				Price:     item.Price,
+				Dividend:  item.Dividend,
 			}
 		},
 	), nil
@@ -169,14 +205,15 @@ select
     cs.id, 
     cs.company_id, 
     cs.round, 
-    cs.price
+    cs.price,
+    cs.dividend
 from backend.company_share cs
 where cs.company_id = $1
 `
 
 func (r *CompanySharesRepo) GetListByCompanyID(ctx context.Context, companyID int64) ([]models.CompanyShare, error) {
 	var shares []share
-	if err := r.db.SelectContext(ctx, &shares, companySharesQueryGetListByCompanyID, companyID); err != nil {
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &shares, companySharesQueryGetListByCompanyID, companyID); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -187,6 +224,7 @@ func (r *CompanySharesRepo) GetListByCompanyID(ctx context.Context, companyID in
 				CompanyID: item.CompanyID,
 				Round:     item.Round,
 				Price:     item.Price,
+				Dividend:  item.Dividend,
 			}
 		},
 	), nil
@@ -197,7 +235,8 @@ select
     cs.id, 
     cs.company_id, 
     cs.round, 
-    cs.price
+    cs.price,
+    cs.dividend
 from backend.company_share cs
 where cs.company_id in (?) and cs.round = ?
 `
@@ -214,7 +253,131 @@ func (r *CompanySharesRepo) GetListByCompanyIDsAndRound(
 	query = r.db.Rebind(query)
 
 	var shares []share
-	if err = r.db.SelectContext(ctx, &shares, query, args...); err != nil {
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &shares, query, args...); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		shares,
+		func(item share, _ int) models.CompanyShare {
+			return models.CompanyShare{
+				ID:        item.ID,
+				CompanyID: item.CompanyID,
+				Round:     item.Round,
+				Price:     item.Price,
+				Dividend:  item.Dividend,
+			}
+		},
+	), nil
+}
+
+const companySharesQueryUpsertSessionPrice = `
+insert into backend.session_share_price (session_id, game_id, company_id, round, price)
+values ($1, $2, $3, $4, $5)
+on conflict (session_id, game_id, company_id, round) do update set price = excluded.price
+`
+
+func (r *CompanySharesRepo) UpsertSessionPrice(ctx context.Context, price *models.SessionSharePrice) error {
+	if _, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		companySharesQueryUpsertSessionPrice,
+		price.SessionID,
+		price.GameID,
+		price.CompanyID,
+		price.Round,
+		price.Price,
+	); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
+}
+
+const companySharesQueryGetAllActualForGame = `
+select 
+    cs.id, 
+    cs.company_id, 
+    cs.round, 
+    coalesce(ssp.price, cs.price) as price,
+    cs.dividend
+from backend.company_share cs
+left join backend.company c on c.id = cs.company_id
+left join backend.session_share_price ssp 
+    on ssp.company_id = cs.company_id 
+    and ssp.round = cs.round 
+    and ssp.session_id = $1 
+    and ssp.game_id = $2
+where not c.archived or c.archived isnull 
+`
+
+// GetAllActualForGame возвращает акции неархивных компаний с ценами игры gameID сессии sessionID:
+// пересчитанная по спросу цена перекрывает цену администратора.
+func (r *CompanySharesRepo) GetAllActualForGame(
+	ctx context.Context,
+	sessionID int64,
+	gameID int64,
+) ([]models.CompanyShare, error) {
+	var shares []share
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&shares,
+		companySharesQueryGetAllActualForGame,
+		sessionID,
+		gameID,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		shares,
+		func(item share, _ int) models.CompanyShare {
+			return models.CompanyShare{
+				ID:        item.ID,
+				CompanyID: item.CompanyID,
+				Round:     item.Round,
+				Price:     item.Price,
+				Dividend:  item.Dividend,
+			}
+		},
+	), nil
+}
+
+const companySharesQueryGetListForGameByCompanyIDsAndRound = `
+select 
+    cs.id, 
+    cs.company_id, 
+    cs.round, 
+    coalesce(ssp.price, cs.price) as price,
+    cs.dividend
+from backend.company_share cs
+left join backend.session_share_price ssp 
+    on ssp.company_id = cs.company_id 
+    and ssp.round = cs.round 
+    and ssp.session_id = ? 
+    and ssp.game_id = ?
+where cs.company_id in (?) and cs.round = ?
+`
+
+// GetListForGameByCompanyIDsAndRound возвращает акции компаний раунда с ценами игры gameID сессии sessionID:
+// пересчитанная по спросу цена перекрывает цену администратора.
+func (r *CompanySharesRepo) GetListForGameByCompanyIDsAndRound(
+	ctx context.Context,
+	sessionID int64,
+	gameID int64,
+	companyIDs []int64,
+	round int,
+) ([]models.CompanyShare, error) {
+	query, args, err := sqlx.In(
+		companySharesQueryGetListForGameByCompanyIDsAndRound,
+		sessionID,
+		gameID,
+		companyIDs,
+		round,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.In: %w", err)
+	}
+	query = r.db.Rebind(query)
+
+	var shares []share
+	if err = getExecutor(ctx, r.db).SelectContext(ctx, &shares, query, args...); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return lo.Map(
@@ -225,6 +388,7 @@ func (r *CompanySharesRepo) GetListByCompanyIDsAndRound(
 				CompanyID: item.CompanyID,
 				Round:     item.Round,
 				Price:     item.Price,
+				Dividend:  item.Dividend,
 			}
 		},
 	), nil
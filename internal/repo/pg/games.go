@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
+	"time"
 )
 
 type GamesRepo struct {
@@ -19,42 +21,113 @@ func NewGamesRepo(db *sqlx.DB) *GamesRepo {
 }
 
 type game struct {
-	State        int8  `db:"state"`
-	CurrentRound int   `db:"current_round"`
-	TradeState   int8  `db:"trade_state"`
-	CurrentGame  int64 `db:"current_game"`
+	ID                int64      `db:"id"`
+	Name              string     `db:"name"`
+	State             int8       `db:"state"`
+	CurrentRound      int        `db:"current_round"`
+	TradeState        int8       `db:"trade_state"`
+	CurrentGame       int64      `db:"current_game"`
+	TradeStartedAt    *time.Time `db:"trade_started_at"`
+	TradeDeadline     *time.Time `db:"trade_deadline"`
+	TradeRemaining    *int64     `db:"trade_remaining"`
+	RoundStartPending bool       `db:"round_start_pending"`
+	AutopilotStatus   int8       `db:"autopilot_status"`
+}
+
+const gamesRepoCreateQuery = `
+insert into backend.game (name, state, current_round, trade_state, current_game)
+values (:name, :state, :current_round, :trade_state, :current_game)
+returning id
+`
+
+func (r *GamesRepo) Create(ctx context.Context, game *models.Game) (int64, error) {
+	rows, err := sqlx.NamedQueryContext(
+		ctx,
+		getExecutor(ctx, r.db),
+		gamesRepoCreateQuery,
+		struct {
+			Name         string `db:"name"`
+			State        int8   `db:"state"`
+			CurrentRound int    `db:"current_round"`
+			TradeState   int8   `db:"trade_state"`
+			CurrentGame  int64  `db:"current_game"`
+		}{
+			Name:         game.Name,
+			State:        int8(game.State),
+			CurrentRound: game.CurrentRound,
+			TradeState:   int8(game.TradeState),
+			CurrentGame:  game.CurrentGame,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var id int64
+	for rows.Next() {
+		if err = rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	return id, nil
 }
 
 const gamesRepoUpdateQuery = `
 update backend.game
 set (
+    name,
     state,
     current_round,
     trade_state,
-    current_game
+    current_game,
+    trade_started_at,
+    trade_deadline,
+    trade_remaining,
+    round_start_pending
 ) = (
+    :name,
     :state,
     :current_round,
     :trade_state,
-    :current_game
+    :current_game,
+    :trade_started_at,
+    :trade_deadline,
+    :trade_remaining,
+    :round_start_pending
 )
-where id = 1
+where id = :id
 `
 
 func (r *GamesRepo) Update(ctx context.Context, game *models.Game) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		gamesRepoUpdateQuery,
 		struct {
-			State        int8  `db:"state"`
-			CurrentRound int   `db:"current_round"`
-			TradeState   int8  `db:"trade_state"`
-			CurrentGame  int64 `db:"current_game"`
+			ID                int64      `db:"id"`
+			Name              string     `db:"name"`
+			State             int8       `db:"state"`
+			CurrentRound      int        `db:"current_round"`
+			TradeState        int8       `db:"trade_state"`
+			CurrentGame       int64      `db:"current_game"`
+			TradeStartedAt    *time.Time `db:"trade_started_at"`
+			TradeDeadline     *time.Time `db:"trade_deadline"`
+			TradeRemaining    *int64     `db:"trade_remaining"`
+			RoundStartPending bool       `db:"round_start_pending"`
 		}{
-			State:        int8(game.State),
-			CurrentRound: game.CurrentRound,
-			TradeState:   int8(game.TradeState),
-			CurrentGame:  game.CurrentGame,
+			ID:                game.ID,
+			Name:              game.Name,
+			State:             int8(game.State),
+			CurrentRound:      game.CurrentRound,
+			TradeState:        int8(game.TradeState),
+			CurrentGame:       game.CurrentGame,
+			TradeStartedAt:    game.TradeStartedAt,
+			TradeDeadline:     game.TradeDeadline,
+			TradeRemaining:    (*int64)(game.TradeRemaining),
+			RoundStartPending: game.RoundStartPending,
 		},
 	)
 	if err != nil {
@@ -71,28 +144,108 @@ func (r *GamesRepo) Update(ctx context.Context, game *models.Game) error {
 	return nil
 }
 
+const gamesRepoSetAutopilotStatusQuery = `
+update backend.game
+set autopilot_status = $2
+where id = $1
+`
+
+// SetAutopilotStatus сохраняет состояние автопилота. Update его не перезаписывает, чтобы обновления
+// игры, прочитанной раньше, не затёрли статус.
+func (r *GamesRepo) SetAutopilotStatus(ctx context.Context, id int64, status models.AutopilotStatus) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, gamesRepoSetAutopilotStatusQuery, id, int8(status))
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
 const gamesRepoGetQuery = `
-select 
+select
+    id,
+    name,
     state,
     current_round,
     trade_state,
-    current_game
+    current_game,
+    trade_started_at,
+    trade_deadline,
+    trade_remaining,
+    round_start_pending,
+    autopilot_status
 from backend.game
-where id = 1
+where id = $1
 `
 
-func (r *GamesRepo) Get(ctx context.Context) (*models.Game, error) {
+func (r *GamesRepo) Get(ctx context.Context, id int64) (*models.Game, error) {
 	var g game
-	if err := r.db.GetContext(ctx, &g, gamesRepoGetQuery); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &g, gamesRepoGetQuery, id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repo.ErrNotFound
 		}
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	return &models.Game{
-		State:        models.GameState(g.State),
-		CurrentRound: g.CurrentRound,
-		TradeState:   models.TradeState(g.TradeState),
-		CurrentGame:  g.CurrentGame,
+		ID:                g.ID,
+		Name:              g.Name,
+		State:             models.GameState(g.State),
+		CurrentRound:      g.CurrentRound,
+		TradeState:        models.TradeState(g.TradeState),
+		CurrentGame:       g.CurrentGame,
+		TradeStartedAt:    g.TradeStartedAt,
+		TradeDeadline:     g.TradeDeadline,
+		TradeRemaining:    (*time.Duration)(g.TradeRemaining),
+		RoundStartPending: g.RoundStartPending,
+		AutopilotStatus:   models.AutopilotStatus(g.AutopilotStatus),
 	}, nil
 }
+
+const gamesRepoGetAllQuery = `
+select
+    id,
+    name,
+    state,
+    current_round,
+    trade_state,
+    current_game,
+    trade_started_at,
+    trade_deadline,
+    trade_remaining,
+    round_start_pending,
+    autopilot_status
+from backend.game
+order by id
+`
+
+func (r *GamesRepo) GetAll(ctx context.Context) ([]models.Game, error) {
+	var games []game
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &games, gamesRepoGetAllQuery); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		games,
+		func(item game, _ int) models.Game {
+			return models.Game{
+				ID:                item.ID,
+				Name:              item.Name,
+				State:             models.GameState(item.State),
+				CurrentRound:      item.CurrentRound,
+				TradeState:        models.TradeState(item.TradeState),
+				CurrentGame:       item.CurrentGame,
+				TradeStartedAt:    item.TradeStartedAt,
+				TradeDeadline:     item.TradeDeadline,
+				TradeRemaining:    (*time.Duration)(item.TradeRemaining),
+				RoundStartPending: item.RoundStartPending,
+				AutopilotStatus:   models.AutopilotStatus(item.AutopilotStatus),
+			}
+		},
+	), nil
+}
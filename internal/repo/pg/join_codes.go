@@ -0,0 +1,159 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"time"
+)
+
+type JoinCodesRepo struct {
+	db *sqlx.DB
+}
+
+func NewJoinCodesRepo(db *sqlx.DB) *JoinCodesRepo {
+	return &JoinCodesRepo{db: db}
+}
+
+type joinCode struct {
+	ID        int64      `db:"id"`
+	CreatedAt time.Time  `db:"created_at"`
+	SessionID int64      `db:"session_id"`
+	GameID    int64      `db:"game_id"`
+	Code      string     `db:"code"`
+	ExpiresAt *time.Time `db:"expires_at"`
+	MaxUses   int        `db:"max_uses"`
+	Uses      int        `db:"uses"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+func (c joinCode) toModel() models.JoinCode {
+	return models.JoinCode{
+		ID:        c.ID,
+		CreatedAt: c.CreatedAt,
+		SessionID: c.SessionID,
+		GameID:    c.GameID,
+		Code:      c.Code,
+		ExpiresAt: c.ExpiresAt,
+		MaxUses:   c.MaxUses,
+		Uses:      c.Uses,
+		RevokedAt: c.RevokedAt,
+	}
+}
+
+const joinCodesQueryCreate = `
+insert into backend.join_code (session_id, game_id, code, expires_at, max_uses)
+values ($1, $2, $3, $4, $5)
+returning id
+`
+
+func (r *JoinCodesRepo) Create(ctx context.Context, code *models.JoinCode) (int64, error) {
+	var id int64
+	if err := getExecutor(ctx, r.db).GetContext(
+		ctx,
+		&id,
+		joinCodesQueryCreate,
+		code.SessionID,
+		code.GameID,
+		code.Code,
+		code.ExpiresAt,
+		code.MaxUses,
+	); err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	return id, nil
+}
+
+const joinCodesQueryGetByID = `
+select id, created_at, session_id, game_id, code, expires_at, max_uses, uses, revoked_at
+from backend.join_code
+where id = $1
+`
+
+func (r *JoinCodesRepo) GetByID(ctx context.Context, id int64) (*models.JoinCode, error) {
+	var c joinCode
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &c, joinCodesQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(c.toModel()), nil
+}
+
+const joinCodesQueryGetByCodeForUpdate = `
+select id, created_at, session_id, game_id, code, expires_at, max_uses, uses, revoked_at
+from backend.join_code
+where code = $1
+for update
+`
+
+// GetByCodeForUpdate блокирует код до конца транзакции, чтобы параллельные регистрации
+// не превысили лимит использований.
+func (r *JoinCodesRepo) GetByCodeForUpdate(ctx context.Context, code string) (*models.JoinCode, error) {
+	var c joinCode
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &c, joinCodesQueryGetByCodeForUpdate, code); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(c.toModel()), nil
+}
+
+const joinCodesQueryGetAllByGameID = `
+select id, created_at, session_id, game_id, code, expires_at, max_uses, uses, revoked_at
+from backend.join_code
+where session_id = $1 and game_id = $2
+order by id
+`
+
+func (r *JoinCodesRepo) GetAllByGameID(ctx context.Context, sessionID int64, gameID int64) ([]models.JoinCode, error) {
+	var codes []joinCode
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &codes, joinCodesQueryGetAllByGameID, sessionID, gameID); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(codes, func(item joinCode, _ int) models.JoinCode {
+		return item.toModel()
+	}), nil
+}
+
+const joinCodesQueryIncrementUses = `
+update backend.join_code
+set uses = uses + 1
+where id = $1
+`
+
+func (r *JoinCodesRepo) IncrementUses(ctx context.Context, id int64) error {
+	if _, err := getExecutor(ctx, r.db).ExecContext(ctx, joinCodesQueryIncrementUses, id); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
+}
+
+const joinCodesQueryRevoke = `
+update backend.join_code
+set revoked_at = now()
+where id = $1 and revoked_at isnull
+`
+
+func (r *JoinCodesRepo) Revoke(ctx context.Context, id int64) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, joinCodesQueryRevoke, id)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
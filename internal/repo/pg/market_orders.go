@@ -0,0 +1,307 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"time"
+)
+
+type MarketOrdersRepo struct {
+	db *sqlx.DB
+}
+
+func NewMarketOrdersRepo(db *sqlx.DB) *MarketOrdersRepo {
+	return &MarketOrdersRepo{db: db}
+}
+
+type marketOrder struct {
+	ID        int64     `db:"id"`
+	SessionID int64     `db:"session_id"`
+	TeamID    int64     `db:"team_id"`
+	MemberID  *int64    `db:"member_id"`
+	CompanyID int64     `db:"company_id"`
+	Side      int8      `db:"side"`
+	Round     int       `db:"round"`
+	Price     int64     `db:"price"`
+	Count     int64     `db:"count"`
+	Filled    int64     `db:"filled"`
+	Status    int8      `db:"status"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+const marketOrdersQueryCreate = `
+insert into backend.market_order (
+    session_id,
+    team_id,
+    member_id,
+    company_id,
+    side,
+    round,
+    price,
+    count,
+    filled,
+    status
+)
+values (
+    :session_id,
+    :team_id,
+    :member_id,
+    :company_id,
+    :side,
+    :round,
+    :price,
+    :count,
+    :filled,
+    :status
+)
+returning id, created_at
+`
+
+func (r *MarketOrdersRepo) Create(ctx context.Context, order *models.MarketOrder) (int64, error) {
+	rows, err := sqlx.NamedQueryContext(
+		ctx,
+		getExecutor(ctx, r.db),
+		marketOrdersQueryCreate,
+		struct {
+			SessionID int64  `db:"session_id"`
+			TeamID    int64  `db:"team_id"`
+			MemberID  *int64 `db:"member_id"`
+			CompanyID int64  `db:"company_id"`
+			Side      int8   `db:"side"`
+			Round     int    `db:"round"`
+			Price     int64  `db:"price"`
+			Count     int64  `db:"count"`
+			Filled    int64  `db:"filled"`
+			Status    int8   `db:"status"`
+		}{
+			SessionID: order.SessionID,
+			TeamID:    order.TeamID,
+			MemberID:  order.MemberID,
+			CompanyID: order.CompanyID,
+			Side:      int8(order.Side),
+			Round:     order.Round,
+			Price:     order.Price,
+			Count:     order.Count,
+			Filled:    order.Filled,
+			Status:    int8(order.Status),
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var id int64
+	for rows.Next() {
+		if err = rows.Scan(&id, &order.CreatedAt); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	return id, nil
+}
+
+const marketOrdersQueryUpdate = `
+update backend.market_order
+set filled = :filled,
+    status = :status
+where id = :id
+`
+
+func (r *MarketOrdersRepo) Update(ctx context.Context, order *models.MarketOrder) error {
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
+		ctx,
+		marketOrdersQueryUpdate,
+		struct {
+			ID     int64 `db:"id"`
+			Filled int64 `db:"filled"`
+			Status int8  `db:"status"`
+		}{
+			ID:     order.ID,
+			Filled: order.Filled,
+			Status: int8(order.Status),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const marketOrdersQueryCancelOpenBySessionID = `
+update backend.market_order
+set status = $1
+where session_id = $2 and status = $3
+`
+
+func (r *MarketOrdersRepo) CancelOpenBySessionID(ctx context.Context, sessionID int64) error {
+	if _, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		marketOrdersQueryCancelOpenBySessionID,
+		int8(models.OrderStatusCancelled),
+		sessionID,
+		int8(models.OrderStatusOpen),
+	); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	return nil
+}
+
+const marketOrdersQueryGetByID = `
+select
+    id,
+    session_id,
+    team_id,
+    member_id,
+    company_id,
+    side,
+    round,
+    price,
+    count,
+    filled,
+    status,
+    created_at
+from backend.market_order
+where id = $1
+`
+
+func (r *MarketOrdersRepo) GetByID(ctx context.Context, id int64) (*models.MarketOrder, error) {
+	var o marketOrder
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &o, marketOrdersQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return &models.MarketOrder{
+		ID:        o.ID,
+		SessionID: o.SessionID,
+		TeamID:    o.TeamID,
+		MemberID:  o.MemberID,
+		CompanyID: o.CompanyID,
+		Side:      models.OrderSide(o.Side),
+		Round:     o.Round,
+		Price:     o.Price,
+		Count:     o.Count,
+		Filled:    o.Filled,
+		Status:    models.OrderStatus(o.Status),
+		CreatedAt: o.CreatedAt,
+	}, nil
+}
+
+const marketOrdersQueryGetAllOpen = `
+select
+    id,
+    session_id,
+    team_id,
+    member_id,
+    company_id,
+    side,
+    round,
+    price,
+    count,
+    filled,
+    status,
+    created_at
+from backend.market_order
+where status = $1
+order by id
+`
+
+func (r *MarketOrdersRepo) GetAllOpen(ctx context.Context) ([]models.MarketOrder, error) {
+	var orders []marketOrder
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&orders,
+		marketOrdersQueryGetAllOpen,
+		int8(models.OrderStatusOpen),
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		orders,
+		func(item marketOrder, _ int) models.MarketOrder {
+			return models.MarketOrder{
+				ID:        item.ID,
+				SessionID: item.SessionID,
+				TeamID:    item.TeamID,
+				MemberID:  item.MemberID,
+				CompanyID: item.CompanyID,
+				Side:      models.OrderSide(item.Side),
+				Round:     item.Round,
+				Price:     item.Price,
+				Count:     item.Count,
+				Filled:    item.Filled,
+				Status:    models.OrderStatus(item.Status),
+				CreatedAt: item.CreatedAt,
+			}
+		},
+	), nil
+}
+
+const marketOrdersQueryGetByTeamIDAndRound = `
+select
+    id,
+    session_id,
+    team_id,
+    member_id,
+    company_id,
+    side,
+    round,
+    price,
+    count,
+    filled,
+    status,
+    created_at
+from backend.market_order
+where team_id = $1 and round = $2
+order by id
+`
+
+func (r *MarketOrdersRepo) GetByTeamIDAndRound(ctx context.Context, teamID int64, round int) ([]models.MarketOrder, error) {
+	var orders []marketOrder
+	if err := getExecutor(ctx, r.db).SelectContext(
+		ctx,
+		&orders,
+		marketOrdersQueryGetByTeamIDAndRound,
+		teamID,
+		round,
+	); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		orders,
+		func(item marketOrder, _ int) models.MarketOrder {
+			return models.MarketOrder{
+				ID:        item.ID,
+				SessionID: item.SessionID,
+				TeamID:    item.TeamID,
+				MemberID:  item.MemberID,
+				CompanyID: item.CompanyID,
+				Side:      models.OrderSide(item.Side),
+				Round:     item.Round,
+				Price:     item.Price,
+				Count:     item.Count,
+				Filled:    item.Filled,
+				Status:    models.OrderStatus(item.Status),
+				CreatedAt: item.CreatedAt,
+			}
+		},
+	), nil
+}
@@ -0,0 +1,15 @@
+alter table backend.random_event
+    add column if not exists description      text     not null default '',
+    add column if not exists effect_type      smallint not null default 1,
+    add column if not exists balance_delta    bigint   not null default 0,
+    add column if not exists company_id       bigint references backend.company (id),
+    add column if not exists price_multiplier double precision not null default 1,
+    add column if not exists probability      integer  not null default 0 check (probability between 0 and 100),
+    add column if not exists archived         boolean;
+
+update backend.random_event
+set name = ''
+where name isnull;
+
+alter table backend.random_event
+    alter column name set not null;
@@ -0,0 +1,17 @@
+alter table backend.game
+    add column if not exists name       text        not null default '',
+    add column if not exists created_at timestamptz not null default now();
+
+alter table backend.team
+    add column if not exists session_id bigint references backend.game (id);
+
+update backend.team
+set session_id = 1
+where session_id isnull;
+
+alter table backend.team
+    alter column session_id set not null,
+    drop constraint if exists team_credentials_game_id_key,
+    add constraint team_credentials_session_id_game_id_key unique (credentials, session_id, game_id);
+
+create index if not exists team_session_id_game_id_idx on backend.team (session_id, game_id);
@@ -0,0 +1,3 @@
+alter table backend.settings
+    add column if not exists briefing_duration bigint not null default 60000000000,
+    add column if not exists results_duration  bigint not null default 60000000000;
@@ -0,0 +1,4 @@
+alter table backend.game
+    add column if not exists trade_started_at timestamptz,
+    add column if not exists trade_deadline   timestamptz,
+    add column if not exists trade_remaining  bigint;
@@ -0,0 +1,5 @@
+alter table backend.balance
+    add column if not exists version bigint not null default 0;
+
+alter table backend.team
+    add column if not exists version bigint not null default 0;
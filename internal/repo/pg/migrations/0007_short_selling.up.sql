@@ -0,0 +1,20 @@
+alter table backend.settings
+    add column if not exists short_selling_limit  bigint not null default 0,
+    add column if not exists short_margin_percent bigint not null default 150;
+
+alter table backend.balance
+    add column if not exists collateral bigint not null default 0;
+
+alter table backend.balance_transaction
+    add column if not exists type smallint not null default 1;
+
+update backend.balance_transaction
+set type = 2
+where additional_info_id is not null;
+
+update backend.balance_transaction
+set type = 3
+where random_event_id is not null;
+
+create index if not exists balance_transaction_balance_id_round_type_idx
+    on backend.balance_transaction (balance_id, round, type);
@@ -0,0 +1,17 @@
+create table if not exists backend.market_order
+(
+    id         bigserial primary key,
+    session_id bigint      not null references backend.game (id),
+    team_id    bigint      not null references backend.team (id),
+    company_id bigint      not null references backend.company (id),
+    side       smallint    not null,
+    round      integer     not null,
+    price      bigint      not null check (price > 0),
+    count      bigint      not null check (count > 0),
+    filled     bigint      not null default 0,
+    status     smallint    not null,
+    created_at timestamptz not null default now()
+);
+
+create index if not exists market_order_session_id_status_idx on backend.market_order (session_id, status);
+create index if not exists market_order_team_id_round_idx on backend.market_order (team_id, round);
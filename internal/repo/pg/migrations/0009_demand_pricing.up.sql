@@ -0,0 +1,6 @@
+alter table backend.settings
+    add column if not exists enable_demand_pricing     boolean not null default false,
+    add column if not exists demand_elasticity_percent bigint  not null default 0;
+
+alter table backend.company_share
+    add column if not exists base_price bigint;
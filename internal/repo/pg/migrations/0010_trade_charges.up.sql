@@ -0,0 +1,9 @@
+alter table backend.settings
+    add column if not exists commission_flat           bigint not null default 0,
+    add column if not exists commission_percent        bigint not null default 0,
+    add column if not exists capital_gains_tax_percent bigint not null default 0;
+
+alter table backend.balance_transaction
+    add column if not exists parent_id bigint references backend.balance_transaction (id) on delete cascade;
+
+create index if not exists balance_transaction_parent_id_idx on backend.balance_transaction (parent_id);
@@ -0,0 +1,2 @@
+alter table backend.company_share
+    add column if not exists dividend bigint not null default 0;
@@ -0,0 +1,25 @@
+create table if not exists backend.bond
+(
+    id              bigserial primary key,
+    name            text     not null,
+    type            smallint not null,
+    price           bigint   not null check (price > 0),
+    coupon_percent  bigint   not null default 0 check (coupon_percent >= 0),
+    maturity_rounds integer  not null check (maturity_rounds > 0),
+    archived        boolean
+);
+
+create table if not exists backend.team_bond
+(
+    id             bigserial primary key,
+    team_id        bigint  not null references backend.team (id),
+    bond_id        bigint  not null references backend.bond (id),
+    count          bigint  not null check (count > 0),
+    price          bigint  not null,
+    coupon_percent bigint  not null,
+    purchase_round integer not null,
+    maturity_round integer not null,
+    redeemed       boolean not null default false
+);
+
+create index if not exists team_bond_team_id_idx on backend.team_bond (team_id);
@@ -0,0 +1,6 @@
+alter table backend.settings
+    add column if not exists credit_limit          bigint not null default 0,
+    add column if not exists loan_interest_percent bigint not null default 0;
+
+alter table backend.balance
+    add column if not exists loan bigint not null default 0 check (loan >= 0);
@@ -0,0 +1,6 @@
+alter table backend.team
+    add column if not exists password_hash text,
+    alter column credentials drop not null,
+    drop constraint if exists team_credentials_session_id_game_id_key;
+
+create index if not exists team_session_id_game_id_name_idx on backend.team (session_id, game_id, name);
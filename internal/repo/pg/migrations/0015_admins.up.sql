@@ -0,0 +1,24 @@
+create table if not exists backend.admin
+(
+    id            bigserial primary key,
+    created_at    timestamptz not null default now(),
+    username      text        not null unique,
+    password_hash text        not null
+);
+
+create table if not exists backend.admin_refresh_token
+(
+    admin_id bigint not null unique references backend.admin (id) on delete cascade,
+    token    text   not null
+);
+
+create table if not exists backend.admin_action
+(
+    id         bigserial primary key,
+    created_at timestamptz not null default now(),
+    admin_id   bigint references backend.admin (id) on delete set null,
+    session_id bigint      not null references backend.game (id),
+    action     text        not null
+);
+
+create index if not exists admin_action_session_id_created_at_idx on backend.admin_action (session_id, created_at);
@@ -0,0 +1,24 @@
+create table if not exists backend.auth_session
+(
+    id                bigserial primary key,
+    created_at        timestamptz not null default now(),
+    role              text        not null,
+    subject_id        bigint      not null,
+    user_agent        text        not null default '',
+    refresh_token     text        not null default '',
+    access_jti        text        not null default '',
+    access_expires_at timestamptz not null default now(),
+    revoked_at        timestamptz
+);
+
+create index if not exists auth_session_role_subject_id_idx on backend.auth_session (role, subject_id)
+    where revoked_at isnull;
+
+create table if not exists backend.revoked_access_token
+(
+    jti        text primary key,
+    expires_at timestamptz not null
+);
+
+drop table if exists backend.team_refresh_token;
+drop table if exists backend.admin_refresh_token;
@@ -0,0 +1,16 @@
+create table if not exists backend.team_member
+(
+    id            bigserial primary key,
+    created_at    timestamptz not null default now(),
+    team_id       bigint      not null references backend.team (id) on delete cascade,
+    name          text        not null,
+    password_hash text        not null,
+    is_captain    boolean     not null default false,
+    unique (team_id, name)
+);
+
+alter table backend.balance_transaction
+    add column if not exists member_id bigint references backend.team_member (id) on delete set null;
+
+alter table backend.auth_session
+    add column if not exists member_id bigint;
@@ -0,0 +1,14 @@
+create table if not exists backend.join_code
+(
+    id         bigserial primary key,
+    created_at timestamptz not null default now(),
+    session_id bigint      not null references backend.game (id),
+    game_id    bigint      not null,
+    code       text        not null unique,
+    expires_at timestamptz,
+    max_uses   integer     not null check (max_uses > 0),
+    uses       integer     not null default 0 check (uses >= 0),
+    revoked_at timestamptz
+);
+
+create index if not exists join_code_session_id_game_id_idx on backend.join_code (session_id, game_id);
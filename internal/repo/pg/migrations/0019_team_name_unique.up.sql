@@ -0,0 +1,14 @@
+-- вход выполняется по имени команды: дубликаты, созданные до ограничения, переименовываются
+update backend.team t
+set name = t.name || ' #' || t.id
+where exists (
+    select 1
+    from backend.team o
+    where o.session_id = t.session_id
+      and o.game_id = t.game_id
+      and o.name = t.name
+      and o.id < t.id
+);
+
+drop index if exists backend.team_session_id_game_id_name_idx;
+create unique index if not exists team_session_id_game_id_name_key on backend.team (session_id, game_id, name);
@@ -0,0 +1,2 @@
+alter table backend.market_order
+    add column if not exists member_id bigint references backend.team_member (id) on delete set null;
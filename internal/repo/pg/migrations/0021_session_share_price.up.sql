@@ -0,0 +1,17 @@
+create table if not exists backend.session_share_price
+(
+    session_id bigint  not null references backend.game (id),
+    game_id    bigint  not null,
+    company_id bigint  not null references backend.company (id),
+    round      integer not null,
+    price      bigint  not null check (price > 0),
+    primary key (session_id, game_id, company_id, round)
+);
+
+-- цены, пересчитанные по спросу, раньше перезаписывали общие цены администратора
+update backend.company_share
+set price = base_price
+where base_price is not null;
+
+alter table backend.company_share
+    drop column if exists base_price;
@@ -0,0 +1,3 @@
+-- у существующих команд остаётся null: средние цены восстанавливаются по истории сделок при следующей сделке
+alter table backend.team
+    add column if not exists average_prices jsonb;
@@ -0,0 +1,2 @@
+alter table backend.game
+    add column if not exists round_start_pending boolean not null default false;
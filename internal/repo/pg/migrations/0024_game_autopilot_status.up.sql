@@ -0,0 +1,2 @@
+alter table backend.game
+    add column if not exists autopilot_status smallint not null default 0;
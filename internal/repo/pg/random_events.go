@@ -1 +1,240 @@
 package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+)
+
+type RandomEventsRepo struct {
+	db *sqlx.DB
+}
+
+func NewRandomEventsRepo(db *sqlx.DB) *RandomEventsRepo {
+	return &RandomEventsRepo{db: db}
+}
+
+type randomEvent struct {
+	ID              int64   `db:"id"`
+	Name            string  `db:"name"`
+	Description     string  `db:"description"`
+	EffectType      int8    `db:"effect_type"`
+	BalanceDelta    int64   `db:"balance_delta"`
+	CompanyID       *int64  `db:"company_id"`
+	PriceMultiplier float64 `db:"price_multiplier"`
+	Probability     int     `db:"probability"`
+	Archived        *bool   `db:"archived"`
+}
+
+const randomEventsQueryCreate = `
+insert into backend.random_event (
+    name,
+    description,
+    effect_type,
+    balance_delta,
+    company_id,
+    price_multiplier,
+    probability,
+    archived
+)
+values (
+    :name,
+    :description,
+    :effect_type,
+    :balance_delta,
+    :company_id,
+    :price_multiplier,
+    :probability,
+    :archived
+)
+returning id
+`
+
+func (r *RandomEventsRepo) Create(ctx context.Context, event *models.RandomEvent) (int64, error) {
+	rows, err := sqlx.NamedQueryContext(
+		ctx,
+		getExecutor(ctx, r.db),
+		randomEventsQueryCreate,
+		struct {
+			Name            string  `db:"name"`
+			Description     string  `db:"description"`
+			EffectType      int8    `db:"effect_type"`
+			BalanceDelta    int64   `db:"balance_delta"`
+			CompanyID       *int64  `db:"company_id"`
+			PriceMultiplier float64 `db:"price_multiplier"`
+			Probability     int     `db:"probability"`
+			Archived        *bool   `db:"archived"`
+		}{
+			Name:            event.Name,
+			Description:     event.Description,
+			EffectType:      int8(event.EffectType),
+			BalanceDelta:    event.BalanceDelta,
+			CompanyID:       event.CompanyID,
+			PriceMultiplier: event.PriceMultiplier,
+			Probability:     event.Probability,
+			Archived:        event.Archived,
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var id int64
+	for rows.Next() {
+		if err = rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows error: %w", err)
+	}
+	return id, nil
+}
+
+const randomEventsQueryUpdate = `
+update backend.random_event
+set (
+    name,
+    description,
+    effect_type,
+    balance_delta,
+    company_id,
+    price_multiplier,
+    probability,
+    archived
+) = (
+    :name,
+    :description,
+    :effect_type,
+    :balance_delta,
+    :company_id,
+    :price_multiplier,
+    :probability,
+    :archived
+)
+where id = :id
+`
+
+func (r *RandomEventsRepo) Update(ctx context.Context, event *models.RandomEvent) error {
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
+		ctx,
+		randomEventsQueryUpdate,
+		struct {
+			ID              int64   `db:"id"`
+			Name            string  `db:"name"`
+			Description     string  `db:"description"`
+			EffectType      int8    `db:"effect_type"`
+			BalanceDelta    int64   `db:"balance_delta"`
+			CompanyID       *int64  `db:"company_id"`
+			PriceMultiplier float64 `db:"price_multiplier"`
+			Probability     int     `db:"probability"`
+			Archived        *bool   `db:"archived"`
+		}{
+			ID:              event.ID,
+			Name:            event.Name,
+			Description:     event.Description,
+			EffectType:      int8(event.EffectType),
+			BalanceDelta:    event.BalanceDelta,
+			CompanyID:       event.CompanyID,
+			PriceMultiplier: event.PriceMultiplier,
+			Probability:     event.Probability,
+			Archived:        event.Archived,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return repo.ErrNotFound
+		}
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const randomEventsQueryGetByID = `
+select
+    id,
+    name,
+    description,
+    effect_type,
+    balance_delta,
+    company_id,
+    price_multiplier,
+    probability,
+    archived
+from backend.random_event
+where id = $1
+`
+
+func (r *RandomEventsRepo) GetByID(ctx context.Context, id int64) (*models.RandomEvent, error) {
+	var e randomEvent
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &e, randomEventsQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return &models.RandomEvent{
+		ID:              e.ID,
+		Name:            e.Name,
+		Description:     e.Description,
+		EffectType:      models.RandomEventEffectType(e.EffectType),
+		BalanceDelta:    e.BalanceDelta,
+		CompanyID:       e.CompanyID,
+		PriceMultiplier: e.PriceMultiplier,
+		Probability:     e.Probability,
+		Archived:        e.Archived,
+	}, nil
+}
+
+const randomEventsQueryGetAllNotArchived = `
+select
+    id,
+    name,
+    description,
+    effect_type,
+    balance_delta,
+    company_id,
+    price_multiplier,
+    probability,
+    archived
+from backend.random_event
+where not archived or archived isnull
+order by id
+`
+
+func (r *RandomEventsRepo) GetAllNotArchived(ctx context.Context) ([]models.RandomEvent, error) {
+	var events []randomEvent
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &events, randomEventsQueryGetAllNotArchived); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(
+		events,
+		func(item randomEvent, _ int) models.RandomEvent {
+			return models.RandomEvent{
+				ID:              item.ID,
+				Name:            item.Name,
+				Description:     item.Description,
+				EffectType:      models.RandomEventEffectType(item.EffectType),
+				BalanceDelta:    item.BalanceDelta,
+				CompanyID:       item.CompanyID,
+				PriceMultiplier: item.PriceMultiplier,
+				Probability:     item.Probability,
+				Archived:        item.Archived,
+			}
+		},
+	), nil
+}
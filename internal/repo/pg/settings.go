@@ -22,10 +22,21 @@ func NewSettingsRepo(db *sqlx.DB) *SettingsRepo {
 type settings struct {
 	RoundsCount               int    `db:"rounds_count"`
 	RoundsDuration            int64  `db:"rounds_duration"`
+	BriefingDuration          int64  `db:"briefing_duration"`
+	ResultsDuration           int64  `db:"results_duration"`
 	LinkToPDF                 string `db:"link_to_pdf"`
 	EnableRandomEvents        bool   `db:"enable_random_events"`
 	DefaultBalanceAmount      int64  `db:"default_balance_amount"`
 	DefaultAdditionalInfoCost int64  `db:"default_additional_info_cost"`
+	ShortSellingLimit         int64  `db:"short_selling_limit"`
+	ShortMarginPercent        int64  `db:"short_margin_percent"`
+	EnableDemandPricing       bool   `db:"enable_demand_pricing"`
+	DemandElasticityPercent   int64  `db:"demand_elasticity_percent"`
+	CommissionFlat            int64  `db:"commission_flat"`
+	CommissionPercent         int64  `db:"commission_percent"`
+	CapitalGainsTaxPercent    int64  `db:"capital_gains_tax_percent"`
+	CreditLimit               int64  `db:"credit_limit"`
+	LoanInterestPercent       int64  `db:"loan_interest_percent"`
 }
 
 const settingsRepoUpdateQuery = `
@@ -33,39 +44,83 @@ update backend.settings
 set (
     rounds_count,
     rounds_duration,
+    briefing_duration,
+    results_duration,
     link_to_pdf,
     enable_random_events,
     default_balance_amount,
-    default_additional_info_cost
+    default_additional_info_cost,
+    short_selling_limit,
+    short_margin_percent,
+    enable_demand_pricing,
+    demand_elasticity_percent,
+    commission_flat,
+    commission_percent,
+    capital_gains_tax_percent,
+    credit_limit,
+    loan_interest_percent
 ) = (
     :rounds_count,
     :rounds_duration,
+    :briefing_duration,
+    :results_duration,
     :link_to_pdf,
     :enable_random_events,
     :default_balance_amount,
-    :default_additional_info_cost
+    :default_additional_info_cost,
+    :short_selling_limit,
+    :short_margin_percent,
+    :enable_demand_pricing,
+    :demand_elasticity_percent,
+    :commission_flat,
+    :commission_percent,
+    :capital_gains_tax_percent,
+    :credit_limit,
+    :loan_interest_percent
 )
 where id = 1
 `
 
 func (r *SettingsRepo) Update(ctx context.Context, settings *models.Settings) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		settingsRepoUpdateQuery,
 		struct {
 			RoundsCount               int    `db:"rounds_count"`
 			RoundsDuration            int64  `db:"rounds_duration"`
+			BriefingDuration          int64  `db:"briefing_duration"`
+			ResultsDuration           int64  `db:"results_duration"`
 			LinkToPDF                 string `db:"link_to_pdf"`
 			EnableRandomEvents        bool   `db:"enable_random_events"`
 			DefaultBalanceAmount      int64  `db:"default_balance_amount"`
 			DefaultAdditionalInfoCost int64  `db:"default_additional_info_cost"`
+			ShortSellingLimit         int64  `db:"short_selling_limit"`
+			ShortMarginPercent        int64  `db:"short_margin_percent"`
+			EnableDemandPricing       bool   `db:"enable_demand_pricing"`
+			DemandElasticityPercent   int64  `db:"demand_elasticity_percent"`
+			CommissionFlat            int64  `db:"commission_flat"`
+			CommissionPercent         int64  `db:"commission_percent"`
+			CapitalGainsTaxPercent    int64  `db:"capital_gains_tax_percent"`
+			CreditLimit               int64  `db:"credit_limit"`
+			LoanInterestPercent       int64  `db:"loan_interest_percent"`
 		}{
 			RoundsCount:               settings.RoundsCount,
 			RoundsDuration:            int64(settings.RoundsDuration),
+			BriefingDuration:          int64(settings.BriefingDuration),
+			ResultsDuration:           int64(settings.ResultsDuration),
 			LinkToPDF:                 settings.LinkToPDF,
 			EnableRandomEvents:        settings.EnableRandomEvents,
 			DefaultBalanceAmount:      settings.DefaultBalanceAmount,
 			DefaultAdditionalInfoCost: settings.DefaultAdditionalInfoCost,
+			ShortSellingLimit:         settings.ShortSellingLimit,
+			ShortMarginPercent:        settings.ShortMarginPercent,
+			EnableDemandPricing:       settings.EnableDemandPricing,
+			DemandElasticityPercent:   settings.DemandElasticityPercent,
+			CommissionFlat:            settings.CommissionFlat,
+			CommissionPercent:         settings.CommissionPercent,
+			CapitalGainsTaxPercent:    settings.CapitalGainsTaxPercent,
+			CreditLimit:               settings.CreditLimit,
+			LoanInterestPercent:       settings.LoanInterestPercent,
 		},
 	)
 	if err != nil {
@@ -86,17 +141,28 @@ const settingsRepoGetQuery = `
 select 
     rounds_count,
     rounds_duration,
+    briefing_duration,
+    results_duration,
     link_to_pdf,
     enable_random_events,
     default_balance_amount,
-    default_additional_info_cost
+    default_additional_info_cost,
+    short_selling_limit,
+    short_margin_percent,
+    enable_demand_pricing,
+    demand_elasticity_percent,
+    commission_flat,
+    commission_percent,
+    capital_gains_tax_percent,
+    credit_limit,
+    loan_interest_percent
 from backend.settings
 where id = 1
 `
 
 func (r *SettingsRepo) Get(ctx context.Context) (*models.Settings, error) {
 	var s settings
-	if err := r.db.GetContext(ctx, &s, settingsRepoGetQuery); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &s, settingsRepoGetQuery); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, repo.ErrNotFound
 		}
@@ -105,9 +171,20 @@ func (r *SettingsRepo) Get(ctx context.Context) (*models.Settings, error) {
 	return &models.Settings{
 		RoundsCount:               s.RoundsCount,
 		RoundsDuration:            time.Duration(s.RoundsDuration),
+		BriefingDuration:          time.Duration(s.BriefingDuration),
+		ResultsDuration:           time.Duration(s.ResultsDuration),
 		LinkToPDF:                 s.LinkToPDF,
 		EnableRandomEvents:        s.EnableRandomEvents,
 		DefaultBalanceAmount:      s.DefaultBalanceAmount,
 		DefaultAdditionalInfoCost: s.DefaultAdditionalInfoCost,
+		ShortSellingLimit:         s.ShortSellingLimit,
+		ShortMarginPercent:        s.ShortMarginPercent,
+		EnableDemandPricing:       s.EnableDemandPricing,
+		DemandElasticityPercent:   s.DemandElasticityPercent,
+		CommissionFlat:            s.CommissionFlat,
+		CommissionPercent:         s.CommissionPercent,
+		CapitalGainsTaxPercent:    s.CapitalGainsTaxPercent,
+		CreditLimit:               s.CreditLimit,
+		LoanInterestPercent:       s.LoanInterestPercent,
 	}, nil
 }
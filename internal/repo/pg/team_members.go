@@ -0,0 +1,167 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"time"
+)
+
+type TeamMembersRepo struct {
+	db *sqlx.DB
+}
+
+func NewTeamMembersRepo(db *sqlx.DB) *TeamMembersRepo {
+	return &TeamMembersRepo{db: db}
+}
+
+type teamMember struct {
+	ID           int64     `db:"id"`
+	CreatedAt    time.Time `db:"created_at"`
+	TeamID       int64     `db:"team_id"`
+	Name         string    `db:"name"`
+	PasswordHash string    `db:"password_hash"`
+	IsCaptain    bool      `db:"is_captain"`
+}
+
+func (m teamMember) toModel() models.TeamMember {
+	return models.TeamMember{
+		ID:           m.ID,
+		CreatedAt:    m.CreatedAt,
+		TeamID:       m.TeamID,
+		Name:         m.Name,
+		PasswordHash: m.PasswordHash,
+		IsCaptain:    m.IsCaptain,
+	}
+}
+
+const teamMembersQueryCreate = `
+insert into backend.team_member (team_id, name, password_hash, is_captain)
+values ($1, $2, $3, $4)
+returning id
+`
+
+func (r *TeamMembersRepo) Create(ctx context.Context, member *models.TeamMember) (int64, error) {
+	var id int64
+	if err := getExecutor(ctx, r.db).GetContext(
+		ctx,
+		&id,
+		teamMembersQueryCreate,
+		member.TeamID,
+		member.Name,
+		member.PasswordHash,
+		member.IsCaptain,
+	); err != nil {
+		if isUniqueViolation(err) {
+			return 0, repo.ErrAlreadyExists
+		}
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+	return id, nil
+}
+
+const teamMembersQueryUpdate = `
+update backend.team_member
+set name = $1, password_hash = $2, is_captain = $3
+where id = $4
+`
+
+func (r *TeamMembersRepo) Update(ctx context.Context, member *models.TeamMember) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(
+		ctx,
+		teamMembersQueryUpdate,
+		member.Name,
+		member.PasswordHash,
+		member.IsCaptain,
+		member.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
+const teamMembersQueryDelete = `
+delete from backend.team_member
+where id = $1
+`
+
+func (r *TeamMembersRepo) Delete(ctx context.Context, id int64) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, teamMembersQueryDelete, id)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNotFound
+	}
+	return nil
+}
+
+const teamMembersQueryGetByID = `
+select id, created_at, team_id, name, password_hash, is_captain
+from backend.team_member
+where id = $1
+`
+
+func (r *TeamMembersRepo) GetByID(ctx context.Context, id int64) (*models.TeamMember, error) {
+	var m teamMember
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &m, teamMembersQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(m.toModel()), nil
+}
+
+const teamMembersQueryGetByName = `
+select id, created_at, team_id, name, password_hash, is_captain
+from backend.team_member
+where team_id = $1 and name = $2
+`
+
+func (r *TeamMembersRepo) GetByName(ctx context.Context, teamID int64, name string) (*models.TeamMember, error) {
+	var m teamMember
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &m, teamMembersQueryGetByName, teamID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.ToPtr(m.toModel()), nil
+}
+
+const teamMembersQueryGetAllByTeamID = `
+select id, created_at, team_id, name, password_hash, is_captain
+from backend.team_member
+where team_id = $1
+order by id
+`
+
+func (r *TeamMembersRepo) GetAllByTeamID(ctx context.Context, teamID int64) ([]models.TeamMember, error) {
+	var members []teamMember
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &members, teamMembersQueryGetAllByTeamID, teamID); err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return lo.Map(members, func(item teamMember, _ int) models.TeamMember {
+		return item.toModel()
+	}), nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/lib/pq"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
 	"time"
@@ -27,12 +28,16 @@ type team struct {
 	UpdatedAt       *time.Time     `db:"updated_at"`
 	Name            string         `db:"name"`
 	Members         pq.StringArray `db:"members"`
-	Credentials     string         `db:"credentials"`
+	Credentials     *string        `db:"credentials"`
+	PasswordHash    *string        `db:"password_hash"`
 	BalanceID       int64          `db:"balance_id"`
 	Shares          []byte         `db:"shares"`
 	AdditionalInfos []byte         `db:"additional_info_ids"`
 	RandomEventID   *int64         `db:"random_event_id"`
+	SessionID       int64          `db:"session_id"`
 	GameID          int64          `db:"game_id"`
+	Version         int64          `db:"version"`
+	AveragePrices   []byte         `db:"average_prices"`
 }
 
 const teamsRepoQueryCreate = `
@@ -42,12 +47,14 @@ insert into backend.team
      updated_at, 
      name, 
      members, 
-     credentials, 
+     password_hash, 
      balance_id, 
      shares, 
      additional_info_ids, 
      random_event_id,
-     game_id
+     session_id,
+     game_id,
+     average_prices
     ) 
 values 
     (
@@ -55,40 +62,50 @@ values
      null, 
      :name, 
      :members, 
-     :credentials, 
+     :password_hash, 
      :balance_id, 
      :shares, 
      :additional_info_ids, 
      :random_event_id,
-     :game_id
+     :session_id,
+     :game_id,
+     :average_prices
     )
 returning id
 `
 
 func (r *TeamsRepo) Create(ctx context.Context, team *models.Team) (int64, error) {
-	rows, err := r.db.NamedQueryContext(
+	rows, err := sqlx.NamedQueryContext(
 		ctx,
+		getExecutor(ctx, r.db),
 		teamsRepoQueryCreate,
 		struct {
 			Name            string         `db:"name"`
 			Members         pq.StringArray `db:"members"`
-			Credentials     string         `db:"credentials"`
+			PasswordHash    string         `db:"password_hash"`
 			BalanceID       int64          `db:"balance_id"`
 			Shares          any            `db:"shares"`
 			AdditionalInfos any            `db:"additional_info_ids"`
 			RandomEventID   *int64         `db:"random_event_id"`
+			SessionID       int64          `db:"session_id"`
 			GameID          int64          `db:"game_id"`
+			AveragePrices   any            `db:"average_prices"`
 		}{
 			Name:            team.Name,
 			Members:         team.Members,
-			Credentials:     team.Credentials,
+			PasswordHash:    team.PasswordHash,
 			BalanceID:       team.BalanceID,
 			Shares:          team.Shares,
 			AdditionalInfos: team.AdditionalInfos,
 			RandomEventID:   team.RandomEventID,
+			SessionID:       team.SessionID,
 			GameID:          team.GameID,
+			AveragePrices:   team.AveragePrices,
 		},
 	)
+	if isUniqueViolation(err) {
+		return 0, repo.ErrAlreadyExists
+	}
 	if err != nil {
 		return 0, fmt.Errorf("exec err: %w", err)
 	}
@@ -100,7 +117,10 @@ func (r *TeamsRepo) Create(ctx context.Context, team *models.Team) (int64, error
 			return 0, fmt.Errorf("scan error: %w", err)
 		}
 	}
-	if err = rows.Err(); err != nil {
+	if err = rows.Err(); isUniqueViolation(err) {
+		return 0, repo.ErrAlreadyExists
+	}
+	if err != nil {
 		return 0, fmt.Errorf("rows error: %w", err)
 	}
 	return id, nil
@@ -114,20 +134,24 @@ set (
     members,
     shares,
     additional_info_ids,
-    random_event_id
+    random_event_id,
+    average_prices,
+    version
 ) = (
     now(),
     :name,
     :members,
     :shares,
     :additional_info_ids,
-    :random_event_id
+    :random_event_id,
+    :average_prices,
+    version + 1
 )
-where id = :id
+where id = :id and version = :version
 `
 
 func (r *TeamsRepo) Update(ctx context.Context, team *models.Team) error {
-	result, err := r.db.NamedExecContext(
+	result, err := getExecutor(ctx, r.db).NamedExecContext(
 		ctx,
 		teamsRepoQueryUpdate,
 		struct {
@@ -137,6 +161,8 @@ func (r *TeamsRepo) Update(ctx context.Context, team *models.Team) error {
 			Shares          any            `db:"shares"`
 			AdditionalInfos any            `db:"additional_info_ids"`
 			RandomEventID   *int64         `db:"random_event_id"`
+			AveragePrices   any            `db:"average_prices"`
+			Version         int64          `db:"version"`
 		}{
 			ID:              team.ID,
 			Name:            team.Name,
@@ -144,6 +170,8 @@ func (r *TeamsRepo) Update(ctx context.Context, team *models.Team) error {
 			Shares:          team.Shares,
 			AdditionalInfos: team.AdditionalInfos,
 			RandomEventID:   team.RandomEventID,
+			AveragePrices:   team.AveragePrices,
+			Version:         team.Version,
 		},
 	)
 	if err != nil {
@@ -158,11 +186,16 @@ func (r *TeamsRepo) Update(ctx context.Context, team *models.Team) error {
 		return fmt.Errorf("get affected rows: %w", err)
 	}
 	if affected == 0 {
-		return repo.ErrNothingUpdated
+		return versionConflictOrNotFound(ctx, r.db, teamsRepoQueryExists, team.ID)
 	}
+	team.Version++
 	return nil
 }
 
+const teamsRepoQueryExists = `
+select exists(select 1 from backend.team where id = $1)
+`
+
 const teamsRepoQueryDeleteBulk = `
 delete from backend.team
 where id in(?)
@@ -177,13 +210,13 @@ func (r *TeamsRepo) DeleteBulk(ctx context.Context, ids []int64) error {
 		return fmt.Errorf("build query: %w", err)
 	}
 	query = r.db.Rebind(query)
-	if _, err = r.db.ExecContext(ctx, query, args...); err != nil {
+	if _, err = getExecutor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
 		return fmt.Errorf("query error: %w", err)
 	}
 	return nil
 }
 
-const teamsRepoQueryGetByCredentials = `
+const teamsRepoQueryGetByName = `
 select 
     id, 
     created_at,
@@ -191,18 +224,30 @@ select
     name, 
     members,
     credentials,
+    password_hash,
     balance_id,
     shares, 
     additional_info_ids, 
     random_event_id,
-    game_id
+    session_id,
+    game_id,
+    version,
+    average_prices
 from backend.team
-where credentials = $1 and game_id = $2
+where name = $1 and session_id = $2 and game_id = $3
 `
 
-func (r *TeamsRepo) GetByCredentials(ctx context.Context, credentials string, gameID int64) (*models.Team, error) {
+func (r *TeamsRepo) GetByName(
+	ctx context.Context,
+	name string,
+	sessionID int64,
+	gameID int64,
+) (*models.Team, error) {
 	var t team
-	if err := r.db.GetContext(ctx, &t, teamsRepoQueryGetByCredentials, credentials, gameID); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &t, teamsRepoQueryGetByName, name, sessionID, gameID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	model := &models.Team{
@@ -212,11 +257,14 @@ func (r *TeamsRepo) GetByCredentials(ctx context.Context, credentials string, ga
 		Name:            t.Name,
 		Members:         t.Members,
 		Credentials:     t.Credentials,
+		PasswordHash:    lo.FromPtr(t.PasswordHash),
 		BalanceID:       t.BalanceID,
 		Shares:          nil,
 		AdditionalInfos: nil,
 		RandomEventID:   t.RandomEventID,
+		SessionID:       t.SessionID,
 		GameID:          t.GameID,
+		Version:         t.Version,
 	}
 	if len(t.Shares) != 0 {
 		if err := jsoniter.Unmarshal(t.Shares, &model.Shares); err != nil {
@@ -228,9 +276,37 @@ func (r *TeamsRepo) GetByCredentials(ctx context.Context, credentials string, ga
 			return nil, fmt.Errorf("unmarshal json: %T:%w", model.AdditionalInfos, err)
 		}
 	}
+	if len(t.AveragePrices) != 0 {
+		if err := jsoniter.Unmarshal(t.AveragePrices, &model.AveragePrices); err != nil {
+			return nil, fmt.Errorf("unmarshal json: %T:%w", model.AveragePrices, err)
+		}
+	}
 	return model, nil
 }
 
+const teamsRepoQueryUpdatePasswordHash = `
+update backend.team
+set password_hash = $1, credentials = null, updated_at = now()
+where id = $2
+`
+
+// UpdatePasswordHash сохраняет хэш пароля и удаляет устаревшие учётные данные в открытом виде.
+func (r *TeamsRepo) UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error {
+	result, err := getExecutor(ctx, r.db).ExecContext(ctx, teamsRepoQueryUpdatePasswordHash, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repo.ErrNothingUpdated
+	}
+	return nil
+}
+
 const teamsRepoQueryGetByID = `
 select 
     id, 
@@ -239,18 +315,25 @@ select
     name, 
     members,
     credentials,
+    password_hash,
     balance_id,
     shares, 
     additional_info_ids, 
     random_event_id,
-    game_id
+    session_id,
+    game_id,
+    version,
+    average_prices
 from backend.team
 where id = $1
 `
 
 func (r *TeamsRepo) GetByID(ctx context.Context, id int64) (*models.Team, error) {
 	var t team
-	if err := r.db.GetContext(ctx, &t, teamsRepoQueryGetByID, id); err != nil {
+	if err := getExecutor(ctx, r.db).GetContext(ctx, &t, teamsRepoQueryGetByID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repo.ErrNotFound
+		}
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	model := &models.Team{
@@ -260,11 +343,14 @@ func (r *TeamsRepo) GetByID(ctx context.Context, id int64) (*models.Team, error)
 		Name:            t.Name,
 		Members:         t.Members,
 		Credentials:     t.Credentials,
+		PasswordHash:    lo.FromPtr(t.PasswordHash),
 		BalanceID:       t.BalanceID,
 		Shares:          nil,
 		AdditionalInfos: nil,
 		RandomEventID:   t.RandomEventID,
+		SessionID:       t.SessionID,
 		GameID:          t.GameID,
+		Version:         t.Version,
 	}
 	if len(t.Shares) != 0 {
 		if err := jsoniter.Unmarshal(t.Shares, &model.Shares); err != nil {
@@ -276,6 +362,11 @@ func (r *TeamsRepo) GetByID(ctx context.Context, id int64) (*models.Team, error)
 			return nil, fmt.Errorf("unmarshal json: %T:%w", model.AdditionalInfos, err)
 		}
 	}
+	if len(t.AveragePrices) != 0 {
+		if err := jsoniter.Unmarshal(t.AveragePrices, &model.AveragePrices); err != nil {
+			return nil, fmt.Errorf("unmarshal json: %T:%w", model.AveragePrices, err)
+		}
+	}
 	return model, nil
 }
 
@@ -287,18 +378,22 @@ select
     name, 
     members,
     credentials,
+    password_hash,
     balance_id,
     shares, 
     additional_info_ids, 
     random_event_id,
-    game_id
+    session_id,
+    game_id,
+    version,
+    average_prices
 from backend.team
-where game_id = $1
+where session_id = $1 and game_id = $2
 `
 
-func (r *TeamsRepo) GetAllByGameID(ctx context.Context, gameID int64) ([]models.Team, error) {
+func (r *TeamsRepo) GetAllByGameID(ctx context.Context, sessionID int64, gameID int64) ([]models.Team, error) {
 	var teams []team
-	if err := r.db.SelectContext(ctx, &teams, teamsRepoQueryGetAllByGameID, gameID); err != nil {
+	if err := getExecutor(ctx, r.db).SelectContext(ctx, &teams, teamsRepoQueryGetAllByGameID, sessionID, gameID); err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
 	result := make([]models.Team, 0, len(teams))
@@ -310,11 +405,14 @@ func (r *TeamsRepo) GetAllByGameID(ctx context.Context, gameID int64) ([]models.
 			Name:            t.Name,
 			Members:         t.Members,
 			Credentials:     t.Credentials,
+			PasswordHash:    lo.FromPtr(t.PasswordHash),
 			BalanceID:       t.BalanceID,
 			Shares:          nil,
 			AdditionalInfos: nil,
 			RandomEventID:   t.RandomEventID,
+			SessionID:       t.SessionID,
 			GameID:          t.GameID,
+			Version:         t.Version,
 		}
 		if len(t.Shares) != 0 {
 			if err := jsoniter.Unmarshal(t.Shares, &model.Shares); err != nil {
@@ -326,6 +424,11 @@ func (r *TeamsRepo) GetAllByGameID(ctx context.Context, gameID int64) ([]models.
 				return nil, fmt.Errorf("unmarshal json: %T:%w", model.AdditionalInfos, err)
 			}
 		}
+		if len(t.AveragePrices) != 0 {
+			if err := jsoniter.Unmarshal(t.AveragePrices, &model.AveragePrices); err != nil {
+				return nil, fmt.Errorf("unmarshal json: %T:%w", model.AveragePrices, err)
+			}
+		}
 		result = append(result, model)
 	}
 	return result, nil
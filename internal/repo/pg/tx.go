@@ -0,0 +1,88 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"investment-game-backend/internal/repo"
+)
+
+// pgUniqueViolation — код ошибки Postgres при нарушении ограничения уникальности.
+const pgUniqueViolation = "23505"
+
+type txKey struct{}
+
+type TxManager struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(db *sqlx.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTx выполняет fn в одной транзакции: репозитории, вызванные с ctx из fn, работают в ней же.
+// Вложенный вызов переиспользует уже открытую транзакцию.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("rollback tx: %w: %w", rollbackErr, err)
+		}
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+type executor interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
+}
+
+// getExecutor возвращает транзакцию из ctx, если она открыта через TxManager, иначе само подключение.
+func getExecutor(ctx context.Context, db *sqlx.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// isUniqueViolation сообщает, что запрос отклонён ограничением уникальности.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// versionConflictOrNotFound объясняет, почему версионированное обновление не затронуло строку:
+// repo.ErrNotFound, если строки с id нет, и repo.ErrVersionConflict, если её версия уже изменилась.
+func versionConflictOrNotFound(ctx context.Context, db *sqlx.DB, existsQuery string, id int64) error {
+	var exists bool
+	if err := getExecutor(ctx, db).GetContext(ctx, &exists, existsQuery, id); err != nil {
+		return fmt.Errorf("query error: %w", err)
+	}
+	if !exists {
+		return repo.ErrNotFound
+	}
+	return repo.ErrVersionConflict
+}
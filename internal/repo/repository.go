@@ -5,14 +5,22 @@ import (
 	"investment-game-backend/internal/models"
 )
 
+// TxManager выполняет fn в одной транзакции, репозитории берут её из переданного ctx.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type SettingsRepo interface {
 	Update(ctx context.Context, settings *models.Settings) error
 	Get(ctx context.Context) (*models.Settings, error)
 }
 
 type GamesRepo interface {
-	Update(ctx context.Context, settings *models.Game) error
-	Get(ctx context.Context) (*models.Game, error)
+	Create(ctx context.Context, game *models.Game) (int64, error)
+	Update(ctx context.Context, game *models.Game) error
+	SetAutopilotStatus(ctx context.Context, id int64, status models.AutopilotStatus) error
+	Get(ctx context.Context, id int64) (*models.Game, error)
+	GetAll(ctx context.Context) ([]models.Game, error)
 }
 
 type CompaniesRepo interface {
@@ -25,10 +33,20 @@ type CompaniesRepo interface {
 type CompanySharesRepo interface {
 	Create(ctx context.Context, share *models.CompanyShare) (int64, error)
 	Update(ctx context.Context, share *models.CompanyShare) error
+	UpdateDividend(ctx context.Context, companyID int64, round int, dividend int64) error
 	GetAllActual(ctx context.Context) ([]models.CompanyShare, error)
 	GetListByIDs(ctx context.Context, ids []int64) ([]models.CompanyShare, error)
 	GetListByCompanyID(ctx context.Context, companyID int64) ([]models.CompanyShare, error)
 	GetListByCompanyIDsAndRound(ctx context.Context, companyIDs []int64, round int) ([]models.CompanyShare, error)
+	UpsertSessionPrice(ctx context.Context, price *models.SessionSharePrice) error
+	GetAllActualForGame(ctx context.Context, sessionID int64, gameID int64) ([]models.CompanyShare, error)
+	GetListForGameByCompanyIDsAndRound(
+		ctx context.Context,
+		sessionID int64,
+		gameID int64,
+		companyIDs []int64,
+		round int,
+	) ([]models.CompanyShare, error)
 }
 
 type AdditionalInfosRepo interface {
@@ -44,25 +62,105 @@ type BalancesRepo interface {
 	Create(ctx context.Context, balance *models.Balance) (int64, error)
 	Update(ctx context.Context, balance *models.Balance) error
 	GetByID(ctx context.Context, id int64) (*models.Balance, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*models.Balance, error)
 }
 
 type TeamsRepo interface {
 	Create(ctx context.Context, team *models.Team) (int64, error)
 	Update(ctx context.Context, team *models.Team) error
 	DeleteBulk(ctx context.Context, ids []int64) error
-	GetByCredentials(ctx context.Context, credentials string, gameID int64) (*models.Team, error)
+	// GetByName ищет команду по имени среди команд текущей игры сессии.
+	GetByName(ctx context.Context, name string, sessionID int64, gameID int64) (*models.Team, error)
+	UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error
 	GetByID(ctx context.Context, id int64) (*models.Team, error)
-	GetAllByGameID(ctx context.Context, gameID int64) ([]models.Team, error)
+	GetAllByGameID(ctx context.Context, sessionID int64, gameID int64) ([]models.Team, error)
 }
 
 type BalanceTransactionsRepo interface {
 	Create(ctx context.Context, tr *models.BalanceTransaction) (int64, error)
-	Update(ctx context.Context, tr *models.BalanceTransaction) error
-	Delete(ctx context.Context, balanceID int64, round int) error
-	Get(ctx context.Context, balanceID int64, round int) (*models.BalanceTransaction, error)
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*models.BalanceTransaction, error)
+	GetAllByBalanceIDAndRound(ctx context.Context, balanceID int64, round int) ([]models.BalanceTransaction, error)
+	GetAllByGameIDAndRound(
+		ctx context.Context,
+		sessionID int64,
+		gameID int64,
+		round int,
+		trType models.BalanceTransactionType,
+	) ([]models.BalanceTransaction, error)
+	GetAllByBalanceID(ctx context.Context, balanceID int64) ([]models.BalanceTransaction, error)
+	GetList(ctx context.Context, filter models.BalanceTransactionFilter) ([]models.BalanceTransaction, error)
+}
+
+type RandomEventsRepo interface {
+	Create(ctx context.Context, event *models.RandomEvent) (int64, error)
+	Update(ctx context.Context, event *models.RandomEvent) error
+	GetByID(ctx context.Context, id int64) (*models.RandomEvent, error)
+	GetAllNotArchived(ctx context.Context) ([]models.RandomEvent, error)
+}
+
+type BondsRepo interface {
+	Create(ctx context.Context, bond *models.Bond) (int64, error)
+	Update(ctx context.Context, bond *models.Bond) error
+	GetByID(ctx context.Context, id int64) (*models.Bond, error)
+	GetAllNotArchived(ctx context.Context) ([]models.Bond, error)
+}
+
+type TeamBondsRepo interface {
+	Create(ctx context.Context, bond *models.TeamBond) (int64, error)
+	Update(ctx context.Context, bond *models.TeamBond) error
+	GetAllByTeamID(ctx context.Context, teamID int64) ([]models.TeamBond, error)
+	GetAllNotRedeemedByTeamIDs(ctx context.Context, teamIDs []int64) ([]models.TeamBond, error)
 }
 
 type AuthRepo interface {
-	SetRefreshToken(ctx context.Context, teamID int64, token string) error
-	VerifyRefreshToken(ctx context.Context, userID int64, token string) (bool, error)
+	CreateSession(ctx context.Context, session *models.AuthSession) (int64, error)
+	UpdateSessionTokens(ctx context.Context, session *models.AuthSession, previousRefreshToken string) error
+	GetSessionByID(ctx context.Context, id int64) (*models.AuthSession, error)
+	GetActiveSessionsBySubject(ctx context.Context, role models.Role, subjectID int64) ([]models.AuthSession, error)
+	RevokeSessions(ctx context.Context, ids []int64) error
+	RevokeAccessTokens(ctx context.Context, tokens []models.RevokedAccessToken) error
+	GetActiveRevokedAccessTokens(ctx context.Context) ([]models.RevokedAccessToken, error)
+	DeleteExpiredRevokedAccessTokens(ctx context.Context) error
+}
+
+type AdminsRepo interface {
+	Create(ctx context.Context, admin *models.Admin) (int64, error)
+	Update(ctx context.Context, admin *models.Admin) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*models.Admin, error)
+	GetByUsername(ctx context.Context, username string) (*models.Admin, error)
+	GetAll(ctx context.Context) ([]models.Admin, error)
+}
+
+type AdminActionsRepo interface {
+	Create(ctx context.Context, action *models.AdminAction) (int64, error)
+	GetAllBySessionID(ctx context.Context, sessionID int64) ([]models.AdminAction, error)
+}
+
+type TeamMembersRepo interface {
+	Create(ctx context.Context, member *models.TeamMember) (int64, error)
+	Update(ctx context.Context, member *models.TeamMember) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*models.TeamMember, error)
+	GetByName(ctx context.Context, teamID int64, name string) (*models.TeamMember, error)
+	GetAllByTeamID(ctx context.Context, teamID int64) ([]models.TeamMember, error)
+}
+
+type JoinCodesRepo interface {
+	Create(ctx context.Context, code *models.JoinCode) (int64, error)
+	GetByID(ctx context.Context, id int64) (*models.JoinCode, error)
+	GetByCodeForUpdate(ctx context.Context, code string) (*models.JoinCode, error)
+	GetAllByGameID(ctx context.Context, sessionID int64, gameID int64) ([]models.JoinCode, error)
+	IncrementUses(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, id int64) error
+}
+
+type MarketOrdersRepo interface {
+	Create(ctx context.Context, order *models.MarketOrder) (int64, error)
+	Update(ctx context.Context, order *models.MarketOrder) error
+	CancelOpenBySessionID(ctx context.Context, sessionID int64) error
+	GetByID(ctx context.Context, id int64) (*models.MarketOrder, error)
+	GetAllOpen(ctx context.Context) ([]models.MarketOrder, error)
+	GetByTeamIDAndRound(ctx context.Context, teamID int64, round int) ([]models.MarketOrder, error)
 }
@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+	"errors"
+)
+
+// VersionConflictRetries — количество попыток транзакции в WithinTxRetry.
+const VersionConflictRetries = 3
+
+// WithinTxRetry выполняет fn в транзакции и повторяет её, если баланс или команду успели изменить
+// параллельно (ErrVersionConflict). После последней неудачной попытки возвращает ErrVersionConflict.
+func WithinTxRetry(ctx context.Context, txManager TxManager, fn func(ctx context.Context) error) error {
+	var err error
+	for range VersionConflictRetries {
+		err = txManager.WithinTx(ctx, fn)
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
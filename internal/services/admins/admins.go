@@ -0,0 +1,188 @@
+package admins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"investment-game-backend/pkg/password"
+)
+
+type Service struct {
+	repo        repo.AdminsRepo
+	actionsRepo repo.AdminActionsRepo
+	log         *zerolog.Logger
+}
+
+func New(repo repo.AdminsRepo, actionsRepo repo.AdminActionsRepo, log *zerolog.Logger) *Service {
+	return &Service{
+		repo:        repo,
+		actionsRepo: actionsRepo,
+		log:         log,
+	}
+}
+
+var (
+	ErrInvalidParams    = errors.New("invalid admin params")
+	ErrUsernameTaken    = errors.New("admin username is already taken")
+	ErrCannotDeleteSelf = errors.New("admin cannot delete himself")
+	ErrLastAdmin        = errors.New("cannot delete the last admin")
+)
+
+// Bootstrap создаёт первого администратора из конфигурации, если в базе ещё нет ни одного.
+// Последующие администраторы заводятся через API, конфигурация на них не влияет.
+func (s *Service) Bootstrap(ctx context.Context, username string, pass string) error {
+	admins, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetAll: %w", err)
+	}
+	if len(admins) != 0 {
+		return nil
+	}
+
+	if _, err = s.Create(ctx, CreateParams{Username: username, Password: pass}); err != nil {
+		return fmt.Errorf("s.Create: %w", err)
+	}
+	s.log.Info().Str("username", username).Msg("bootstrap admin created")
+	return nil
+}
+
+type CreateParams struct {
+	Username string
+	Password string
+}
+
+func (params CreateParams) Validate() error {
+	if params.Username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidParams)
+	}
+	if params.Password == "" {
+		return fmt.Errorf("%w: password is required", ErrInvalidParams)
+	}
+	return nil
+}
+
+func (s *Service) Create(ctx context.Context, params CreateParams) (*models.Admin, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+	if err := s.checkUsernameFree(ctx, params.Username, 0); err != nil {
+		return nil, fmt.Errorf("s.checkUsernameFree: %w", err)
+	}
+
+	passwordHash, err := password.Hash(params.Password)
+	if err != nil {
+		return nil, fmt.Errorf("password.Hash: %w", err)
+	}
+
+	admin := &models.Admin{
+		Username:     params.Username,
+		PasswordHash: passwordHash,
+	}
+	if admin.ID, err = s.repo.Create(ctx, admin); err != nil {
+		return nil, fmt.Errorf("s.repo.Create: %w", err)
+	}
+	return admin, nil
+}
+
+// UpdateParams: пустой Password оставляет текущий пароль.
+type UpdateParams struct {
+	ID       int64
+	Username string
+	Password string
+}
+
+func (s *Service) Update(ctx context.Context, params UpdateParams) error {
+	if params.Username == "" {
+		return fmt.Errorf("%w: username is required", ErrInvalidParams)
+	}
+
+	admin, err := s.repo.GetByID(ctx, params.ID)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+	if err = s.checkUsernameFree(ctx, params.Username, admin.ID); err != nil {
+		return fmt.Errorf("s.checkUsernameFree: %w", err)
+	}
+
+	admin.Username = params.Username
+	if params.Password != "" {
+		if admin.PasswordHash, err = password.Hash(params.Password); err != nil {
+			return fmt.Errorf("password.Hash: %w", err)
+		}
+	}
+
+	if err = s.repo.Update(ctx, admin); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет администратора id по запросу администратора actorID. Записи аудита
+// удалённого администратора сохраняются без ссылки на него.
+func (s *Service) Delete(ctx context.Context, actorID int64, id int64) error {
+	if actorID == id {
+		return ErrCannotDeleteSelf
+	}
+
+	admins, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetAll: %w", err)
+	}
+	if len(admins) <= 1 {
+		return ErrLastAdmin
+	}
+
+	if err = s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("s.repo.Delete: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]models.Admin, error) {
+	admins, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAll: %w", err)
+	}
+	return admins, nil
+}
+
+func (s *Service) checkUsernameFree(ctx context.Context, username string, exceptID int64) error {
+	admin, err := s.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("s.repo.GetByUsername: %w", err)
+	}
+	if admin.ID != exceptID {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// RecordAction сохраняет в аудит действие администратора над игрой сессии.
+func (s *Service) RecordAction(ctx context.Context, adminID int64, sessionID int64, action string) error {
+	if _, err := s.actionsRepo.Create(
+		ctx,
+		&models.AdminAction{
+			AdminID:   lo.ToPtr(adminID),
+			SessionID: sessionID,
+			Action:    action,
+		},
+	); err != nil {
+		return fmt.Errorf("s.actionsRepo.Create: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetActions(ctx context.Context, sessionID int64) ([]models.AdminAction, error) {
+	actions, err := s.actionsRepo.GetAllBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.actionsRepo.GetAllBySessionID: %w", err)
+	}
+	return actions, nil
+}
@@ -2,84 +2,309 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
+	"investment-game-backend/pkg/password"
+	"sync"
 	"time"
 )
 
-const administratorTeamID int64 = -1
-
 type Service struct {
-	teamsRepo        repo.TeamsRepo
-	authRepo         repo.AuthRepo
-	gamesRepo        repo.GamesRepo
-	jwtConfig        JWTConfig
-	adminCredentials string
-	log              *zerolog.Logger
+	teamsRepo   repo.TeamsRepo
+	membersRepo repo.TeamMembersRepo
+	adminsRepo  repo.AdminsRepo
+	authRepo    repo.AuthRepo
+	gamesRepo   repo.GamesRepo
+	jwtConfig   JWTConfig
+	// onSessionsRevoked закрывает websocket-соединения завершённых сессий входа
+	onSessionsRevoked func(authSessionIDs []int64)
+	log               *zerolog.Logger
+
+	// revokedAccessTokens — jti отозванных access-токенов и время их истечения. Проверяется
+	// на каждом запросе, поэтому держится в памяти, а в базе хранится копия для рестарта.
+	revokedMu           sync.RWMutex
+	revokedAccessTokens map[string]time.Time
 }
 
 func New(
 	teamsRepo repo.TeamsRepo,
+	membersRepo repo.TeamMembersRepo,
+	adminsRepo repo.AdminsRepo,
 	authRepo repo.AuthRepo,
 	gamesRepo repo.GamesRepo,
 	jwtConfig JWTConfig,
-	adminCredentials AdminCredentials,
+	onSessionsRevoked func(authSessionIDs []int64),
 	log *zerolog.Logger,
 ) *Service {
 	return &Service{
-		teamsRepo:        teamsRepo,
-		authRepo:         authRepo,
-		gamesRepo:        gamesRepo,
-		jwtConfig:        jwtConfig,
-		adminCredentials: adminCredentials.String(),
-		log:              log,
+		teamsRepo:           teamsRepo,
+		membersRepo:         membersRepo,
+		adminsRepo:          adminsRepo,
+		authRepo:            authRepo,
+		gamesRepo:           gamesRepo,
+		jwtConfig:           jwtConfig,
+		onSessionsRevoked:   onSessionsRevoked,
+		log:                 log,
+		revokedAccessTokens: make(map[string]time.Time),
 	}
 }
 
-func (s *Service) Login(ctx context.Context, credentials string, isAdmin bool) (models.JWTPair, error) {
-	if isAdmin {
-		if s.adminCredentials != credentials {
-			return models.JWTPair{}, errors.New("unsuccessful login")
+var (
+	errUnsuccessfulLogin = errors.New("unsuccessful login")
+
+	ErrSessionRevoked = errors.New("auth session is revoked")
+	ErrTeamNotFound   = errors.New("team not found")
+)
+
+// Login открывает новую сессию для устройства. Прежние сессии того же пользователя
+// остаются активными, поэтому команда может работать с нескольких устройств.
+func (s *Service) Login(ctx context.Context, params LoginParams) (models.JWTPair, error) {
+	game, err := s.gamesRepo.Get(ctx, params.SessionID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return models.JWTPair{}, errUnsuccessfulLogin
+		}
+		return models.JWTPair{}, fmt.Errorf("failed to get game: %w", err)
+	}
+
+	if params.IsAdmin {
+		admin, err := s.authenticateAdmin(ctx, params.Name, params.Password)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.authenticateAdmin: %w", err)
+		}
+		jwtPair, err := s.startSession(
+			ctx,
+			&models.AuthSession{
+				Role:      models.RoleAdmin,
+				SubjectID: admin.ID,
+				UserAgent: params.UserAgent,
+			},
+			s.getClaimsForAdmin(ctx, admin, game.ID),
+		)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.startSession: %w", err)
+		}
+		return jwtPair, nil
+	}
+
+	if params.MemberName != "" {
+		team, member, err := s.authenticateMember(ctx, game, params.Name, params.MemberName, params.Password)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.authenticateMember: %w", err)
+		}
+		jwtPair, err := s.startSession(
+			ctx,
+			&models.AuthSession{
+				Role:      models.RoleTeam,
+				SubjectID: team.ID,
+				MemberID:  &member.ID,
+				UserAgent: params.UserAgent,
+			},
+			s.getClaimsForMember(ctx, team, member),
+		)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.startSession: %w", err)
 		}
+		return jwtPair, nil
 	}
 
-	game, err := s.gamesRepo.Get(ctx)
+	team, err := s.authenticateTeam(ctx, game, params.Name, params.Password)
 	if err != nil {
-		return models.JWTPair{}, fmt.Errorf("failed to get game: %w", err)
+		return models.JWTPair{}, fmt.Errorf("s.authenticateTeam: %w", err)
 	}
-	var (
-		additionalClaims map[string]any
-		teamID           int64
+	claims, err := s.getClaimsForTeamLogin(ctx, team)
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.getClaimsForTeamLogin: %w", err)
+	}
+	jwtPair, err := s.startSession(
+		ctx,
+		&models.AuthSession{
+			Role:      models.RoleTeam,
+			SubjectID: team.ID,
+			UserAgent: params.UserAgent,
+		},
+		claims,
 	)
-	if s.adminCredentials != credentials {
-		team, err := s.teamsRepo.GetByCredentials(ctx, credentials, game.CurrentGame)
-		if err != nil {
-			if errors.Is(err, repo.ErrNotFound) {
-				return models.JWTPair{}, errors.New("unsuccessful login")
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.startSession: %w", err)
+	}
+	return jwtPair, nil
+}
+
+// IssueSpectatorTokens открывает сессию наблюдателя игры sessionID по запросу администратора adminID.
+// Наблюдатель видит состояние игры и получает общие события, но не может ничего изменять.
+func (s *Service) IssueSpectatorTokens(
+	ctx context.Context,
+	adminID int64,
+	sessionID int64,
+	userAgent string,
+) (models.JWTPair, error) {
+	if _, err := s.gamesRepo.Get(ctx, sessionID); err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	jwtPair, err := s.startSession(
+		ctx,
+		&models.AuthSession{
+			Role:      models.RoleSpectator,
+			SubjectID: adminID,
+			UserAgent: userAgent,
+		},
+		s.getClaimsForSpectator(ctx, sessionID),
+	)
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.startSession: %w", err)
+	}
+	return jwtPair, nil
+}
+
+func (s *Service) startSession(
+	ctx context.Context,
+	session *models.AuthSession,
+	additionalClaims map[string]any,
+) (models.JWTPair, error) {
+	id, err := s.authRepo.CreateSession(ctx, session)
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.authRepo.CreateSession: %w", err)
+	}
+	session.ID = id
+
+	jwtPair, err := s.issueTokens(ctx, session, additionalClaims)
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.issueTokens: %w", err)
+	}
+	return jwtPair, nil
+}
+
+// issueTokens выпускает пару токенов для сессии и сохраняет в ней новый refresh-токен и jti
+// access-токена. Предыдущий refresh-токен сессии после этого перестаёт приниматься: из двух
+// одновременных обновлений одним токеном успешно только первое, второе получает ErrSessionRevoked.
+func (s *Service) issueTokens(
+	ctx context.Context,
+	session *models.AuthSession,
+	additionalClaims map[string]any,
+) (models.JWTPair, error) {
+	previousRefreshToken := session.RefreshToken
+	additionalClaims["aid"] = session.ID
+	jwtPair, err := s.getJWTPair(ctx, session, additionalClaims)
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("s.getJWTPair: %w", err)
+	}
+	if err = s.authRepo.UpdateSessionTokens(ctx, session, previousRefreshToken); err != nil {
+		if errors.Is(err, repo.ErrNothingUpdated) {
+			return models.JWTPair{}, ErrSessionRevoked
+		}
+		return models.JWTPair{}, fmt.Errorf("s.authRepo.UpdateSessionTokens: %w", err)
+	}
+	return jwtPair, nil
+}
+
+// authenticateAdmin проверяет пароль администратора. Неизвестное имя проверяется по фиксированному
+// хэшу, чтобы время ответа не выдавало существующих администраторов.
+func (s *Service) authenticateAdmin(ctx context.Context, username string, pass string) (*models.Admin, error) {
+	admin, err := s.adminsRepo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			_ = password.CompareDummy(pass)
+			return nil, errUnsuccessfulLogin
+		}
+		return nil, fmt.Errorf("s.adminsRepo.GetByUsername: %w", err)
+	}
+	if err = password.Compare(admin.PasswordHash, pass); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return nil, errUnsuccessfulLogin
+		}
+		return nil, fmt.Errorf("password.Compare: %w", err)
+	}
+	return admin, nil
+}
+
+// authenticateTeam проверяет пароль команды. Команды со старыми учётными данными в открытом виде
+// сверяются за постоянное время и при успешном входе получают хэш вместо открытого пароля.
+// Неизвестная команда и неверный старый пароль проверяются по фиксированному хэшу, чтобы время
+// ответа не отличалось от неверного пароля существующей команды.
+func (s *Service) authenticateTeam(
+	ctx context.Context,
+	game *models.Game,
+	name string,
+	pass string,
+) (*models.Team, error) {
+	team, err := s.teamsRepo.GetByName(ctx, name, game.ID, game.CurrentGame)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			_ = password.CompareDummy(pass)
+			return nil, errUnsuccessfulLogin
+		}
+		return nil, fmt.Errorf("s.teamsRepo.GetByName: %w", err)
+	}
+
+	if team.PasswordHash != "" {
+		if err = password.Compare(team.PasswordHash, pass); err != nil {
+			if errors.Is(err, password.ErrMismatch) {
+				return nil, errUnsuccessfulLogin
 			}
-			return models.JWTPair{}, fmt.Errorf("s.teamsRepo.GetByCredentials: %w", err)
+			return nil, fmt.Errorf("password.Compare: %w", err)
 		}
-		additionalClaims = s.getClaimsForTeam(ctx, team)
-		teamID = team.ID
-	} else {
-		additionalClaims = s.getClaimsForAdmin(ctx)
-		teamID = administratorTeamID
+		return team, nil
 	}
 
-	jwtPair, err := s.getJWTPair(ctx, additionalClaims)
+	if team.Credentials == nil || !constantTimeEqual(*team.Credentials, name+":"+pass) {
+		_ = password.CompareDummy(pass)
+		return nil, errUnsuccessfulLogin
+	}
+	passwordHash, err := password.Hash(pass)
 	if err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.getJWTPair: %w", err)
+		return nil, fmt.Errorf("password.Hash: %w", err)
+	}
+	if err = s.teamsRepo.UpdatePasswordHash(ctx, team.ID, passwordHash); err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.UpdatePasswordHash: %w", err)
 	}
+	s.log.Debug().Int64("team_id", team.ID).Msg("legacy team credentials rehashed")
+	return team, nil
+}
 
-	if err = s.authRepo.SetRefreshToken(ctx, teamID, jwtPair.RefreshToken); err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.repo.SetRefreshToken: %w", err)
+// authenticateMember проверяет пароль участника команды teamName.
+func (s *Service) authenticateMember(
+	ctx context.Context,
+	game *models.Game,
+	teamName string,
+	memberName string,
+	pass string,
+) (*models.Team, *models.TeamMember, error) {
+	team, err := s.teamsRepo.GetByName(ctx, teamName, game.ID, game.CurrentGame)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			_ = password.CompareDummy(pass)
+			return nil, nil, errUnsuccessfulLogin
+		}
+		return nil, nil, fmt.Errorf("s.teamsRepo.GetByName: %w", err)
+	}
+	member, err := s.membersRepo.GetByName(ctx, team.ID, memberName)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			_ = password.CompareDummy(pass)
+			return nil, nil, errUnsuccessfulLogin
+		}
+		return nil, nil, fmt.Errorf("s.membersRepo.GetByName: %w", err)
+	}
+	if err = password.Compare(member.PasswordHash, pass); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return nil, nil, errUnsuccessfulLogin
+		}
+		return nil, nil, fmt.Errorf("password.Compare: %w", err)
 	}
+	return team, member, nil
+}
 
-	return jwtPair, nil
+func constantTimeEqual(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
 func (s *Service) Refresh(ctx context.Context, refreshToken string) (models.JWTPair, error) {
@@ -91,49 +316,210 @@ func (s *Service) Refresh(ctx context.Context, refreshToken string) (models.JWTP
 	}
 	claims := token.Claims.(jwt.MapClaims)
 
-	teamIDFromClaims, ok := claims["sub"]
+	authSessionID, ok := claims["aid"].(float64)
 	if !ok {
-		return models.JWTPair{}, errors.New("not found sub claim in jwt")
+		return models.JWTPair{}, errors.New("aid is not integer")
 	}
-	teamID, ok := teamIDFromClaims.(float64)
+	sessionID, ok := claims["sid"].(float64)
 	if !ok {
-		return models.JWTPair{}, errors.New("sub is not integer")
+		return models.JWTPair{}, errors.New("sid is not integer")
 	}
 
-	ok, err = s.authRepo.VerifyRefreshToken(ctx, int64(teamID), refreshToken)
+	session, err := s.authRepo.GetSessionByID(ctx, int64(authSessionID))
 	if err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.repo.VerifyRefreshToken: %w", err)
+		if errors.Is(err, repo.ErrNotFound) {
+			return models.JWTPair{}, ErrSessionRevoked
+		}
+		return models.JWTPair{}, fmt.Errorf("s.authRepo.GetSessionByID: %w", err)
 	}
-	if !ok {
+	if session.IsRevoked() {
+		return models.JWTPair{}, ErrSessionRevoked
+	}
+	// refresh-токен одноразовый: после обновления в сессии хранится только последний выданный
+	if !constantTimeEqual(session.RefreshToken, refreshToken) {
 		return models.JWTPair{}, errors.New("such refresh token not exist")
 	}
 
+	// claims собираются заново из базы, чтобы удалённые команды и администраторы не могли продлить доступ
 	var additionalClaims map[string]any
-	if int64(teamID) == administratorTeamID {
-		additionalClaims = s.getClaimsForAdmin(ctx)
-	} else {
-		additionalClaims = s.getClaimsForTeam(
-			ctx,
-			&models.Team{
-				ID:   int64(teamID),
-				Name: claims["name"].(string),
-			},
-		)
+	switch session.Role {
+	case models.RoleAdmin:
+		admin, err := s.adminsRepo.GetByID(ctx, session.SubjectID)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.adminsRepo.GetByID: %w", err)
+		}
+		additionalClaims = s.getClaimsForAdmin(ctx, admin, int64(sessionID))
+	case models.RoleSpectator:
+		additionalClaims = s.getClaimsForSpectator(ctx, int64(sessionID))
+	case models.RoleTeam:
+		team, err := s.teamsRepo.GetByID(ctx, session.SubjectID)
+		if err != nil {
+			return models.JWTPair{}, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+		}
+		if session.MemberID == nil {
+			if additionalClaims, err = s.getClaimsForTeamLogin(ctx, team); err != nil {
+				return models.JWTPair{}, fmt.Errorf("s.getClaimsForTeamLogin: %w", err)
+			}
+		} else {
+			// права участника перечитываются, чтобы смена капитана вступала в силу при обновлении токена
+			member, err := s.membersRepo.GetByID(ctx, *session.MemberID)
+			if err != nil {
+				if errors.Is(err, repo.ErrNotFound) {
+					return models.JWTPair{}, ErrSessionRevoked
+				}
+				return models.JWTPair{}, fmt.Errorf("s.membersRepo.GetByID: %w", err)
+			}
+			additionalClaims = s.getClaimsForMember(ctx, team, member)
+		}
+	default:
+		return models.JWTPair{}, fmt.Errorf("unknown session role %q", session.Role)
 	}
 
-	jwtPair, err := s.getJWTPair(ctx, additionalClaims)
+	jwtPair, err := s.issueTokens(ctx, session, additionalClaims)
 	if err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.getJWTPair: %w", err)
+		return models.JWTPair{}, fmt.Errorf("s.issueTokens: %w", err)
 	}
+	return jwtPair, nil
+}
+
+// Logout завершает сессию устройства и отзывает её последний access-токен.
+func (s *Service) Logout(ctx context.Context, authSessionID int64) error {
+	session, err := s.authRepo.GetSessionByID(ctx, authSessionID)
 	if err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.getJWTPair: %w", err)
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("s.authRepo.GetSessionByID: %w", err)
+	}
+	if session.IsRevoked() {
+		return nil
 	}
 
-	if err = s.authRepo.SetRefreshToken(ctx, int64(teamID), jwtPair.RefreshToken); err != nil {
-		return models.JWTPair{}, fmt.Errorf("s.repo.SetRefreshToken: %w", err)
+	if err = s.revokeSessions(ctx, []models.AuthSession{*session}); err != nil {
+		return fmt.Errorf("s.revokeSessions: %w", err)
 	}
+	return nil
+}
 
-	return jwtPair, nil
+// KickTeam завершает все сессии команды на всех устройствах.
+func (s *Service) KickTeam(ctx context.Context, teamID int64) error {
+	if _, err := s.teamsRepo.GetByID(ctx, teamID); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return ErrTeamNotFound
+		}
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	sessions, err := s.authRepo.GetActiveSessionsBySubject(ctx, models.RoleTeam, teamID)
+	if err != nil {
+		return fmt.Errorf("s.authRepo.GetActiveSessionsBySubject: %w", err)
+	}
+	if err = s.revokeSessions(ctx, sessions); err != nil {
+		return fmt.Errorf("s.revokeSessions: %w", err)
+	}
+	s.log.Info().Int64("team_id", teamID).Int("sessions", len(sessions)).Msg("team sessions revoked")
+	return nil
+}
+
+// KickTeamMember завершает сессии участника команды, например после его удаления из команды
+// или снятия с него прав капитана.
+func (s *Service) KickTeamMember(ctx context.Context, teamID int64, memberID int64) error {
+	sessions, err := s.authRepo.GetActiveSessionsBySubject(ctx, models.RoleTeam, teamID)
+	if err != nil {
+		return fmt.Errorf("s.authRepo.GetActiveSessionsBySubject: %w", err)
+	}
+	sessions = lo.Filter(sessions, func(item models.AuthSession, _ int) bool {
+		return item.MemberID != nil && *item.MemberID == memberID
+	})
+	if err = s.revokeSessions(ctx, sessions); err != nil {
+		return fmt.Errorf("s.revokeSessions: %w", err)
+	}
+	return nil
+}
+
+// KickTeamSharedSessions завершает сессии, открытые общим паролем команды. Вызывается, когда
+// в команде появляется первый участник и общий вход теряет право торговать.
+func (s *Service) KickTeamSharedSessions(ctx context.Context, teamID int64) error {
+	sessions, err := s.authRepo.GetActiveSessionsBySubject(ctx, models.RoleTeam, teamID)
+	if err != nil {
+		return fmt.Errorf("s.authRepo.GetActiveSessionsBySubject: %w", err)
+	}
+	sessions = lo.Filter(sessions, func(item models.AuthSession, _ int) bool {
+		return item.MemberID == nil
+	})
+	if err = s.revokeSessions(ctx, sessions); err != nil {
+		return fmt.Errorf("s.revokeSessions: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) revokeSessions(ctx context.Context, sessions []models.AuthSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	tokens := make([]models.RevokedAccessToken, 0, len(sessions))
+	for _, session := range sessions {
+		if session.AccessJTI == "" || !session.AccessExpiresAt.After(now) {
+			continue
+		}
+		tokens = append(tokens, models.RevokedAccessToken{
+			JTI:       session.AccessJTI,
+			ExpiresAt: session.AccessExpiresAt,
+		})
+	}
+
+	ids := lo.Map(sessions, func(item models.AuthSession, _ int) int64 {
+		return item.ID
+	})
+	if err := s.authRepo.RevokeSessions(ctx, ids); err != nil {
+		return fmt.Errorf("s.authRepo.RevokeSessions: %w", err)
+	}
+	if err := s.authRepo.RevokeAccessTokens(ctx, tokens); err != nil {
+		return fmt.Errorf("s.authRepo.RevokeAccessTokens: %w", err)
+	}
+	s.addRevokedAccessTokens(tokens)
+	s.onSessionsRevoked(ids)
+	return nil
+}
+
+// RestoreRevokedAccessTokens загружает из базы ещё не истёкшие отозванные access-токены.
+// Вызывается при старте, до приёма запросов.
+func (s *Service) RestoreRevokedAccessTokens(ctx context.Context) error {
+	if err := s.authRepo.DeleteExpiredRevokedAccessTokens(ctx); err != nil {
+		return fmt.Errorf("s.authRepo.DeleteExpiredRevokedAccessTokens: %w", err)
+	}
+	tokens, err := s.authRepo.GetActiveRevokedAccessTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("s.authRepo.GetActiveRevokedAccessTokens: %w", err)
+	}
+	s.addRevokedAccessTokens(tokens)
+	return nil
+}
+
+func (s *Service) addRevokedAccessTokens(tokens []models.RevokedAccessToken) {
+	s.revokedMu.Lock()
+	defer s.revokedMu.Unlock()
+
+	// список короткий: записи живут не дольше access-токена, поэтому истёкшие чистятся при каждой вставке
+	now := time.Now()
+	for jti, expiresAt := range s.revokedAccessTokens {
+		if !expiresAt.After(now) {
+			delete(s.revokedAccessTokens, jti)
+		}
+	}
+	for _, token := range tokens {
+		s.revokedAccessTokens[token.JTI] = token.ExpiresAt
+	}
+}
+
+func (s *Service) IsAccessTokenRevoked(jti string) bool {
+	s.revokedMu.RLock()
+	defer s.revokedMu.RUnlock()
+
+	expiresAt, ok := s.revokedAccessTokens[jti]
+	return ok && expiresAt.After(time.Now())
 }
 
 func (s *Service) RefreshTokenExpTime() time.Duration {
@@ -144,21 +530,68 @@ func (s *Service) getClaimsForTeam(_ context.Context, team *models.Team) map[str
 	claims := map[string]any{
 		"sub":  team.ID,
 		"name": team.Name,
-		"role": "team",
+		"role": models.RoleTeam,
+		"sid":  team.SessionID,
 	}
 	return claims
 }
 
-func (s *Service) getClaimsForAdmin(_ context.Context) map[string]any {
+// getClaimsForTeamLogin возвращает claims входа общим паролем команды. Торговать с ним можно,
+// только пока в команде нет участников: после их появления сделки совершает капитан,
+// а общий вход остаётся для просмотра.
+func (s *Service) getClaimsForTeamLogin(ctx context.Context, team *models.Team) (map[string]any, error) {
+	members, err := s.membersRepo.GetAllByTeamID(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("s.membersRepo.GetAllByTeamID: %w", err)
+	}
+	claims := s.getClaimsForTeam(ctx, team)
+	claims["cap"] = len(members) == 0
+	return claims, nil
+}
+
+// getClaimsForMember дополняет claims команды участником: mid — ID участника, cap — право торговать.
+func (s *Service) getClaimsForMember(ctx context.Context, team *models.Team, member *models.TeamMember) map[string]any {
+	claims := s.getClaimsForTeam(ctx, team)
+	claims["mid"] = member.ID
+	claims["cap"] = member.CanTrade()
+	return claims
+}
+
+func (s *Service) getClaimsForAdmin(_ context.Context, admin *models.Admin, sessionID int64) map[string]any {
+	claims := map[string]any{
+		"sub":  admin.ID,
+		"name": admin.Username,
+		"role": models.RoleAdmin,
+		"sid":  sessionID,
+	}
+	return claims
+}
+
+// getClaimsForSpectator возвращает claims наблюдателя: в sub для него нет ни команды, ни администратора.
+func (s *Service) getClaimsForSpectator(_ context.Context, sessionID int64) map[string]any {
 	claims := map[string]any{
-		"role": "admin",
+		"sub":  0,
+		"role": models.RoleSpectator,
+		"sid":  sessionID,
 	}
 	return claims
 }
 
-func (s *Service) getJWTPair(_ context.Context, additionalClaims map[string]any) (models.JWTPair, error) {
-	atClaims := make(jwt.MapClaims, len(additionalClaims)+1)
-	atClaims["exp"] = time.Now().Add(s.jwtConfig.JWTAccessExpirationTime).Unix()
+// getJWTPair подписывает пару токенов с уникальными jti и запоминает в session refresh-токен
+// и параметры access-токена, необходимые для его отзыва.
+func (s *Service) getJWTPair(
+	_ context.Context,
+	session *models.AuthSession,
+	additionalClaims map[string]any,
+) (models.JWTPair, error) {
+	accessJTI, err := newJTI()
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("newJTI: %w", err)
+	}
+	accessExpiresAt := time.Now().Add(s.jwtConfig.JWTAccessExpirationTime)
+	atClaims := make(jwt.MapClaims, len(additionalClaims)+2)
+	atClaims["exp"] = accessExpiresAt.Unix()
+	atClaims["jti"] = accessJTI
 	for key, value := range additionalClaims {
 		atClaims[key] = value
 	}
@@ -168,8 +601,13 @@ func (s *Service) getJWTPair(_ context.Context, additionalClaims map[string]any)
 		return models.JWTPair{}, fmt.Errorf("token.SignedString: %w", err)
 	}
 
-	rtClaims := make(jwt.MapClaims, len(additionalClaims)+1)
+	refreshJTI, err := newJTI()
+	if err != nil {
+		return models.JWTPair{}, fmt.Errorf("newJTI: %w", err)
+	}
+	rtClaims := make(jwt.MapClaims, len(additionalClaims)+2)
 	rtClaims["exp"] = time.Now().Add(s.jwtConfig.JWTRefreshExpirationTime).Unix()
+	rtClaims["jti"] = refreshJTI
 	for key, value := range additionalClaims {
 		rtClaims[key] = value
 	}
@@ -179,8 +617,21 @@ func (s *Service) getJWTPair(_ context.Context, additionalClaims map[string]any)
 		return models.JWTPair{}, fmt.Errorf("token.SignedString: %w", err)
 	}
 
+	session.RefreshToken = refreshToken
+	session.AccessJTI = accessJTI
+	// exp в токене хранится с точностью до секунды, отзыв должен действовать не меньше
+	session.AccessExpiresAt = accessExpiresAt.Truncate(time.Second).Add(time.Second)
+
 	return models.JWTPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("rand.Read: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
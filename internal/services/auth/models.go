@@ -9,11 +9,13 @@ type JWTConfig struct {
 	JWTRefreshSecretKey      string
 }
 
-type AdminCredentials struct {
-	Username string
-	Password string
-}
-
-func (c AdminCredentials) String() string {
-	return c.Username + ":" + c.Password
+type LoginParams struct {
+	SessionID int64
+	Name      string
+	// MemberName задаётся при входе участника команды, тогда Password — пароль участника.
+	MemberName string
+	Password   string
+	IsAdmin    bool
+	// UserAgent сохраняется в сессии, чтобы администратор мог отличить устройства команды.
+	UserAgent string
 }
@@ -0,0 +1,137 @@
+package bonds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+)
+
+type Service struct {
+	repo repo.BondsRepo
+	log  *zerolog.Logger
+}
+
+func New(repo repo.BondsRepo, log *zerolog.Logger) *Service {
+	return &Service{
+		repo: repo,
+		log:  log,
+	}
+}
+
+var ErrInvalidParams = errors.New("invalid bond params")
+
+type CreateParams struct {
+	Name           string
+	Type           models.BondType
+	Price          int64
+	CouponPercent  int64
+	MaturityRounds int
+}
+
+func (params CreateParams) Validate() error {
+	return validateTerms(params.Type, params.Price, params.CouponPercent, params.MaturityRounds)
+}
+
+func (s *Service) Create(ctx context.Context, params CreateParams) (*models.Bond, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	bond := &models.Bond{
+		Name:           params.Name,
+		Type:           params.Type,
+		Price:          params.Price,
+		CouponPercent:  params.CouponPercent,
+		MaturityRounds: params.MaturityRounds,
+	}
+	id, err := s.repo.Create(ctx, bond)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.Create: %w", err)
+	}
+	bond.ID = id
+	return bond, nil
+}
+
+type UpdateParams struct {
+	ID             int64
+	Name           string
+	Type           models.BondType
+	Price          int64
+	CouponPercent  int64
+	MaturityRounds int
+}
+
+func (params UpdateParams) Validate() error {
+	return validateTerms(params.Type, params.Price, params.CouponPercent, params.MaturityRounds)
+}
+
+// Update изменяет условия инструмента для новых покупок, уже купленные пакеты сохраняют свои условия.
+func (s *Service) Update(ctx context.Context, params UpdateParams) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("params.Validate: %w", err)
+	}
+
+	bond, err := s.repo.GetByID(ctx, params.ID)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+
+	if bond.IsArchived() {
+		return errors.New("cannot update archived bond")
+	}
+
+	bond.Name = params.Name
+	bond.Type = params.Type
+	bond.Price = params.Price
+	bond.CouponPercent = params.CouponPercent
+	bond.MaturityRounds = params.MaturityRounds
+
+	if err = s.repo.Update(ctx, bond); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Archive(ctx context.Context, id int64) error {
+	bond, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+
+	bond.Archived = lo.ToPtr(true)
+
+	if err = s.repo.Update(ctx, bond); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]models.Bond, error) {
+	bonds, err := s.repo.GetAllNotArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAllNotArchived: %w", err)
+	}
+	return bonds, nil
+}
+
+func validateTerms(bondType models.BondType, price int64, couponPercent int64, maturityRounds int) error {
+	switch bondType {
+	case models.BondTypeBond, models.BondTypeDeposit:
+	default:
+		return fmt.Errorf("%w: unknown type %d", ErrInvalidParams, bondType)
+	}
+	if price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidParams)
+	}
+	if couponPercent < 0 {
+		return fmt.Errorf("%w: coupon percent cannot be negative", ErrInvalidParams)
+	}
+	if maturityRounds <= 0 {
+		return fmt.Errorf("%w: maturity must be at least one round", ErrInvalidParams)
+	}
+	return nil
+}
@@ -11,32 +11,50 @@ import (
 )
 
 type Service struct {
-	repo       repo.CompaniesRepo
-	sharesRepo repo.CompanySharesRepo
-	gameRepo   repo.GamesRepo
-	log        *zerolog.Logger
+	repo                    repo.CompaniesRepo
+	sharesRepo              repo.CompanySharesRepo
+	gameRepo                repo.GamesRepo
+	settingsRepo            repo.SettingsRepo
+	balanceTransactionsRepo repo.BalanceTransactionsRepo
+	additionalInfosRepo     repo.AdditionalInfosRepo
+	txManager               repo.TxManager
+	log                     *zerolog.Logger
 }
 
 func New(
 	repo repo.CompaniesRepo,
 	sharesRepo repo.CompanySharesRepo,
 	gameRepo repo.GamesRepo,
+	settingsRepo repo.SettingsRepo,
+	balanceTransactionsRepo repo.BalanceTransactionsRepo,
+	additionalInfosRepo repo.AdditionalInfosRepo,
+	txManager repo.TxManager,
 	log *zerolog.Logger,
 ) *Service {
 	return &Service{
-		repo:       repo,
-		sharesRepo: sharesRepo,
-		gameRepo:   gameRepo,
-		log:        log,
+		repo:                    repo,
+		sharesRepo:              sharesRepo,
+		gameRepo:                gameRepo,
+		settingsRepo:            settingsRepo,
+		balanceTransactionsRepo: balanceTransactionsRepo,
+		additionalInfosRepo:     additionalInfosRepo,
+		txManager:               txManager,
+		log:                     log,
 	}
 }
 
 type CreateWithSharesParams struct {
 	Name   string
 	Shares map[int]int64
+	// Dividends: ключ - раунд, значение - дивиденд на одну акцию.
+	Dividends map[int]int64
 }
 
 func (s *Service) CreateWithShares(ctx context.Context, params CreateWithSharesParams) (int64, error) {
+	if err := validateDividends(params.Shares, params.Dividends); err != nil {
+		return 0, fmt.Errorf("validateDividends: %w", err)
+	}
+
 	company := &models.Company{
 		Name: params.Name,
 	}
@@ -52,6 +70,7 @@ func (s *Service) CreateWithShares(ctx context.Context, params CreateWithSharesP
 				CompanyID: createdID,
 				Round:     round,
 				Price:     sharePrice,
+				Dividend:  params.Dividends[round],
 			},
 		); err != nil {
 			return 0, fmt.Errorf("s.sharesRepo.Create: %w", err)
@@ -65,6 +84,8 @@ type UpdateParams struct {
 	ID     int64
 	Name   string
 	Shares map[int]int64
+	// Dividends: ключ - раунд, значение - дивиденд на одну акцию. Не указанные раунды не изменяются.
+	Dividends map[int]int64
 }
 
 func (s *Service) Update(ctx context.Context, params UpdateParams) error {
@@ -79,11 +100,56 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 
 	company.Name = params.Name
 
-	if err = s.repo.Update(ctx, company); err != nil {
-		return fmt.Errorf("s.repo.Update: %w", err)
+	for round, dividend := range params.Dividends {
+		if dividend < 0 {
+			return fmt.Errorf("%w: round %d", ErrInvalidDividend, round)
+		}
+	}
+
+	// название, цены и дивиденды сохраняются вместе, чтобы ошибка не оставила цены без дивидендов
+	if err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, company); err != nil {
+			return fmt.Errorf("s.repo.Update: %w", err)
+		}
+
+		if err := s.upsertShares(ctx, company.ID, params.Shares); err != nil {
+			return fmt.Errorf("s.upsertShares: %w", err)
+		}
+
+		for round, dividend := range params.Dividends {
+			if err := s.sharesRepo.UpdateDividend(ctx, company.ID, round, dividend); err != nil {
+				if errors.Is(err, repo.ErrNothingUpdated) {
+					return fmt.Errorf("%w: no share price for round %d", ErrInvalidDividend, round)
+				}
+				return fmt.Errorf("s.sharesRepo.UpdateDividend: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("s.txManager.WithinTx: %w", err)
 	}
 
-	shares, err := s.sharesRepo.GetListByCompanyID(ctx, company.ID)
+	return nil
+}
+
+var ErrInvalidDividend = errors.New("invalid dividend")
+
+// validateDividends проверяет, что дивиденды неотрицательны и заданы только для раундов с ценой акции.
+func validateDividends(prices map[int]int64, dividends map[int]int64) error {
+	for round, dividend := range dividends {
+		if dividend < 0 {
+			return fmt.Errorf("%w: round %d", ErrInvalidDividend, round)
+		}
+		if _, ok := prices[round]; !ok {
+			return fmt.Errorf("%w: no share price for round %d", ErrInvalidDividend, round)
+		}
+	}
+	return nil
+}
+
+// upsertShares сохраняет цены акций компании по раундам: существующие обновляются, недостающие создаются.
+func (s *Service) upsertShares(ctx context.Context, companyID int64, prices map[int]int64) error {
+	shares, err := s.sharesRepo.GetListByCompanyID(ctx, companyID)
 	if err != nil {
 		return fmt.Errorf("s.sharesRepo.GetListByCompanyID: %w", err)
 	}
@@ -94,12 +160,12 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 		},
 	)
 
-	for round, price := range params.Shares {
+	for round, price := range prices {
 		if _, ok := existedRounds[round]; ok {
 			if err = s.sharesRepo.Update(
 				ctx,
 				&models.CompanyShare{
-					CompanyID: company.ID,
+					CompanyID: companyID,
 					Round:     round,
 					Price:     price,
 				},
@@ -112,7 +178,7 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 		if _, err = s.sharesRepo.Create(
 			ctx,
 			&models.CompanyShare{
-				CompanyID: company.ID,
+				CompanyID: companyID,
 				Round:     round,
 				Price:     price,
 			},
@@ -138,29 +204,43 @@ func (s *Service) Archive(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (s *Service) GetAllWithShares(ctx context.Context, onlyCurrentRound bool) ([]models.CompanyWithShares, error) {
+func (s *Service) GetAllWithShares(
+	ctx context.Context,
+	sessionID int64,
+	onlyCurrentRound bool,
+) ([]models.CompanyWithShares, error) {
 	companies, err := s.repo.GetAllNotArchived(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("s.repo.GetAllNotArchived: %w", err)
 	}
 
-	game, err := s.gameRepo.Get(ctx)
+	game, err := s.gameRepo.Get(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("s.gameRepo.Get: %w", err)
 	}
 
-	shares, err := s.sharesRepo.GetAllActual(ctx)
+	shares, err := s.sharesRepo.GetAllActualForGame(ctx, game.ID, game.CurrentGame)
 	if err != nil {
-		return nil, fmt.Errorf("s.sharesRepo.GetAllActual: %w", err)
+		return nil, fmt.Errorf("s.sharesRepo.GetAllActualForGame: %w", err)
 	}
 
 	sharesByCompanyID := make(map[int64]map[int]int64, len(companies))
+	dividendsByCompanyID := make(map[int64]map[int]int64, len(companies))
 
 	for _, share := range shares {
 		if onlyCurrentRound && share.Round != game.CurrentRound {
 			continue
 		}
 
+		if share.Dividend != 0 {
+			dividendByRound, ok := dividendsByCompanyID[share.CompanyID]
+			if !ok {
+				dividendByRound = make(map[int]int64)
+				dividendsByCompanyID[share.CompanyID] = dividendByRound
+			}
+			dividendByRound[share.Round] = share.Dividend
+		}
+
 		priceByRound, ok := sharesByCompanyID[share.CompanyID]
 		if !ok {
 			priceByRound = map[int]int64{share.Round: share.Price}
@@ -177,11 +257,152 @@ func (s *Service) GetAllWithShares(ctx context.Context, onlyCurrentRound bool) (
 		result = append(
 			result,
 			models.CompanyWithShares{
-				Company: company,
-				Shares:  companyShares,
+				Company:   company,
+				Shares:    companyShares,
+				Dividends: dividendsByCompanyID[company.ID],
 			},
 		)
 	}
 
 	return result, nil
 }
+
+// PreviewPriceAdjustment показывает цены следующего раунда сессии, пересчитанные по спросу,
+// без сохранения. Используется администратором перед открытием раунда.
+func (s *Service) PreviewPriceAdjustment(ctx context.Context, sessionID int64) ([]models.SharePriceAdjustment, error) {
+	game, err := s.gameRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gameRepo.Get: %w", err)
+	}
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+
+	adjustments, err := s.getPriceAdjustments(ctx, game, game.CurrentRound+1, settings.DemandElasticityPercent)
+	if err != nil {
+		return nil, fmt.Errorf("s.getPriceAdjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+// AdjustPricesByDemand пересчитывает цены раунда round по спросу команд сессии в прошлом раунде.
+// Цены администратора общие для всех сессий и не меняются: пересчитанные цены сохраняются только для
+// текущей игры сессии, пересчёт всегда идёт от цены администратора.
+func (s *Service) AdjustPricesByDemand(
+	ctx context.Context,
+	sessionID int64,
+	round int,
+) ([]models.SharePriceAdjustment, error) {
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if !settings.EnableDemandPricing {
+		return nil, nil
+	}
+	game, err := s.gameRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gameRepo.Get: %w", err)
+	}
+
+	adjustments, err := s.getPriceAdjustments(ctx, game, round, settings.DemandElasticityPercent)
+	if err != nil {
+		return nil, fmt.Errorf("s.getPriceAdjustments: %w", err)
+	}
+	for _, adjustment := range adjustments {
+		if err = s.sharesRepo.UpsertSessionPrice(
+			ctx,
+			&models.SessionSharePrice{
+				SessionID: game.ID,
+				GameID:    game.CurrentGame,
+				CompanyID: adjustment.CompanyID,
+				Round:     adjustment.Round,
+				Price:     adjustment.Price,
+			},
+		); err != nil {
+			return nil, fmt.Errorf("s.sharesRepo.UpsertSessionPrice: %w", err)
+		}
+	}
+
+	s.log.Trace().
+		Int64("session_id", sessionID).
+		Int("round", round).
+		Int("companies_count", len(adjustments)).
+		Msg("share prices adjusted by demand")
+
+	return adjustments, nil
+}
+
+func (s *Service) getPriceAdjustments(
+	ctx context.Context,
+	game *models.Game,
+	round int,
+	elasticityPercent int64,
+) ([]models.SharePriceAdjustment, error) {
+	companies, err := s.repo.GetAllNotArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAllNotArchived: %w", err)
+	}
+	if len(companies) == 0 {
+		return []models.SharePriceAdjustment{}, nil
+	}
+
+	shares, err := s.sharesRepo.GetListByCompanyIDsAndRound(
+		ctx,
+		lo.Map(companies, func(item models.Company, _ int) int64 {
+			return item.ID
+		}),
+		round,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.sharesRepo.GetListByCompanyIDsAndRound: %w", err)
+	}
+
+	// сделки между командами по стакану не меняют общий спрос, поэтому учитываются только сделки с биржей
+	transactions, err := s.balanceTransactionsRepo.GetAllByGameIDAndRound(
+		ctx,
+		game.ID,
+		game.CurrentGame,
+		round-1,
+		models.BalanceTransactionTypeShares,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByGameIDAndRound: %w", err)
+	}
+	netDemand := make(map[int64]int64)
+	volume := make(map[int64]int64)
+	for _, tr := range transactions {
+		for companyID, count := range tr.Details {
+			netDemand[companyID] += count
+			volume[companyID] += max(count, -count)
+		}
+	}
+
+	result := make([]models.SharePriceAdjustment, 0, len(shares))
+	for _, share := range shares {
+		result = append(result, models.SharePriceAdjustment{
+			CompanyID: share.CompanyID,
+			Round:     round,
+			BasePrice: share.Price,
+			NetDemand: netDemand[share.CompanyID],
+			Volume:    volume[share.CompanyID],
+			Price: adjustPrice(
+				share.Price,
+				netDemand[share.CompanyID],
+				volume[share.CompanyID],
+				elasticityPercent,
+			),
+		})
+	}
+	return result, nil
+}
+
+// adjustPrice сдвигает цену на долю elasticityPercent, равную перевесу покупок над продажами в объёме сделок.
+// Цена не опускается ниже 1.
+func adjustPrice(basePrice int64, netDemand int64, volume int64, elasticityPercent int64) int64 {
+	if volume == 0 {
+		return basePrice
+	}
+	return max(basePrice+basePrice*elasticityPercent*netDemand/(100*volume), 1)
+}
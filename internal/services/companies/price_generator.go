@@ -0,0 +1,149 @@
+package companies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"math"
+	"math/rand"
+)
+
+var ErrInvalidGenerateParams = errors.New("invalid price generation params")
+
+// GeneratePricesParams задаёт параметры геометрического броуновского движения цены акции компании.
+// Drift и Volatility указываются за один раунд. Если задан JumpVolatility или JumpMean, в раунды,
+// на которые запланирована дополнительная информация о компании, цена дополнительно
+// умножается на логнормальный скачок exp(N(JumpMean, JumpVolatility)).
+type GeneratePricesParams struct {
+	CompanyID      int64
+	InitialPrice   int64
+	Drift          float64
+	Volatility     float64
+	Seed           int64
+	JumpMean       float64
+	JumpVolatility float64
+}
+
+func (params GeneratePricesParams) Validate() error {
+	if params.InitialPrice <= 0 {
+		return fmt.Errorf("%w: initial price must be positive", ErrInvalidGenerateParams)
+	}
+	if params.Volatility < 0 || params.JumpVolatility < 0 {
+		return fmt.Errorf("%w: volatility cannot be negative", ErrInvalidGenerateParams)
+	}
+	return nil
+}
+
+func (params GeneratePricesParams) withJumps() bool {
+	return params.JumpMean != 0 || params.JumpVolatility != 0
+}
+
+// GeneratedPrices — сгенерированные цены акций компании: ключ - раунд, значение - цена.
+type GeneratedPrices struct {
+	CompanyID int64
+	Prices    map[int]int64
+}
+
+// GeneratePrices генерирует цены акций компаний на все раунды игры без сохранения, а также
+// на раунд после последнего, по ценам которого подводятся итоги игры. При одинаковом Seed результат повторяется, поэтому предпросмотр совпадает с сохранёнными ценами.
+func (s *Service) GeneratePrices(ctx context.Context, params []GeneratePricesParams) ([]GeneratedPrices, error) {
+	for _, item := range params {
+		if err := item.Validate(); err != nil {
+			return nil, fmt.Errorf("item.Validate: %w", err)
+		}
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	jumpRounds, err := s.getJumpRounds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.getJumpRounds: %w", err)
+	}
+
+	result := make([]GeneratedPrices, 0, len(params))
+	for _, item := range params {
+		company, err := s.repo.GetByID(ctx, item.CompanyID)
+		if err != nil {
+			return nil, fmt.Errorf("s.repo.GetByID: %w", err)
+		}
+		if company.IsArchived() {
+			return nil, fmt.Errorf("%w: company %d is archived", ErrInvalidGenerateParams, company.ID)
+		}
+
+		var companyJumpRounds map[int]struct{}
+		if item.withJumps() {
+			companyJumpRounds = jumpRounds[company.ID]
+		}
+		result = append(result, GeneratedPrices{
+			CompanyID: company.ID,
+			Prices:    generatePrices(item, settings.RoundsCount+1, companyJumpRounds),
+		})
+	}
+	return result, nil
+}
+
+// CommitGeneratedPrices генерирует цены так же, как GeneratePrices, и сохраняет их в одной транзакции.
+func (s *Service) CommitGeneratedPrices(ctx context.Context, params []GeneratePricesParams) ([]GeneratedPrices, error) {
+	var result []GeneratedPrices
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if result, err = s.GeneratePrices(ctx, params); err != nil {
+			return fmt.Errorf("s.GeneratePrices: %w", err)
+		}
+		for _, item := range result {
+			if err = s.upsertShares(ctx, item.CompanyID, item.Prices); err != nil {
+				return fmt.Errorf("s.upsertShares: %w", err)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.txManager.WithinTx: %w", err)
+	}
+	return result, nil
+}
+
+// getJumpRounds возвращает раунды, на которые запланирована информация о компаниях:
+// ключ - ID компании, значение - множество раундов.
+func (s *Service) getJumpRounds(ctx context.Context) (map[int64]map[int]struct{}, error) {
+	infos, err := s.additionalInfosRepo.GetAllActualWithType(ctx, models.AdditionalInfoTypeCompanyInfo)
+	if err != nil {
+		return nil, fmt.Errorf("s.additionalInfosRepo.GetAllActualWithType: %w", err)
+	}
+
+	result := make(map[int64]map[int]struct{})
+	for _, info := range infos {
+		if info.CompanyID == nil {
+			continue
+		}
+		rounds, ok := result[*info.CompanyID]
+		if !ok {
+			rounds = make(map[int]struct{})
+			result[*info.CompanyID] = rounds
+		}
+		rounds[info.Round] = struct{}{}
+	}
+	return result, nil
+}
+
+// generatePrices строит траекторию цены по раундам 1..roundsCount, цена первого раунда равна InitialPrice.
+func generatePrices(params GeneratePricesParams, roundsCount int, jumpRounds map[int]struct{}) map[int]int64 {
+	rnd := rand.New(rand.NewSource(params.Seed))
+
+	prices := make(map[int]int64, roundsCount)
+	price := float64(params.InitialPrice)
+	for round := 1; round <= roundsCount; round++ {
+		if round > 1 {
+			price *= math.Exp(params.Drift - params.Volatility*params.Volatility/2 + params.Volatility*rnd.NormFloat64())
+			// скачок берётся из генератора в каждом раунде, чтобы расписание информации не сдвигало остальную траекторию
+			jump := params.JumpMean + params.JumpVolatility*rnd.NormFloat64()
+			if _, ok := jumpRounds[round]; ok {
+				price *= math.Exp(jump)
+			}
+		}
+		prices[round] = max(int64(math.Round(price)), 1)
+	}
+	return prices
+}
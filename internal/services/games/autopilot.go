@@ -0,0 +1,380 @@
+package games
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAutopilotAlreadyRunning = errors.New("autopilot is already running")
+	ErrAutopilotNotRunning     = errors.New("autopilot is not running")
+	// ErrAutopilotRunning — ручное управление раундами и торгами недоступно, пока сессию ведёт автопилот.
+	ErrAutopilotRunning = errors.New("manual control is disabled while autopilot is running")
+)
+
+type autopilot struct {
+	mx    sync.Mutex
+	phase models.AutopilotPhase
+	round int
+	// phaseTimer равен nil в фазе торгов: отсчётом управляет TradeController сессии.
+	phaseTimer *countdown
+	stopped    bool
+	// done закрывается, когда горутина автопилота завершилась и сессия вернулась в ручной режим.
+	done chan struct{}
+}
+
+// beginPhase переключает фазу, если автопилот не остановлен. Проверка и переключение выполняются
+// под одной блокировкой, чтобы StopAutopilot не попал между ними и не остался незамеченным.
+func (a *autopilot) beginPhase(phase models.AutopilotPhase, round int, phaseTimer *countdown) bool {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.stopped {
+		return false
+	}
+	a.phase = phase
+	a.round = round
+	a.phaseTimer = phaseTimer
+	return true
+}
+
+func (a *autopilot) isStopped() bool {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	return a.stopped
+}
+
+// StartAutopilot запускает автоматическое проведение игры сессии: раунды из Settings.RoundsCount
+// с фазами вводной, торгов и итогов, после последнего раунда игра завершается через StopGame.
+func (s *Service) StartAutopilot(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("start autopilot")
+
+	s.autopilotsMx.Lock()
+	if _, ok := s.autopilots[sessionID]; ok {
+		s.autopilotsMx.Unlock()
+		return ErrAutopilotAlreadyRunning
+	}
+	a := &autopilot{mx: sync.Mutex{}, done: make(chan struct{})}
+	s.autopilots[sessionID] = a
+	s.autopilotsMx.Unlock()
+
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		s.removeAutopilot(sessionID, a)
+		close(a.done)
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.State != models.GameStateStarted {
+		if err = s.StartGame(ctx, sessionID); err != nil {
+			s.removeAutopilot(sessionID, a)
+			close(a.done)
+			return fmt.Errorf("s.StartGame: %w", err)
+		}
+	}
+	if err = s.repo.SetAutopilotStatus(ctx, sessionID, models.AutopilotStatusRunning); err != nil {
+		s.removeAutopilot(sessionID, a)
+		close(a.done)
+		return fmt.Errorf("s.repo.SetAutopilotStatus: %w", err)
+	}
+
+	go s.runAutopilot(sessionID, a)
+	return nil
+}
+
+// StopAutopilot возвращает сессию в ручной режим, текущая фаза завершается досрочно.
+// Возвращается после завершения горутины автопилота, чтобы следующая команда не пересеклась
+// с раундом, который автопилот ещё доводит до конца.
+func (s *Service) StopAutopilot(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("stop autopilot")
+
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return ErrAutopilotNotRunning
+	}
+
+	a.mx.Lock()
+	a.stopped = true
+	a.mx.Unlock()
+	s.skipPhase(sessionID, a)
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Service) PauseAutopilot(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("pause autopilot")
+
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return ErrAutopilotNotRunning
+	}
+
+	a.mx.Lock()
+	if a.phaseTimer != nil {
+		a.phaseTimer.Pause()
+	} else {
+		s.controllers.Trade(sessionID).Pause()
+	}
+	a.mx.Unlock()
+
+	if err := s.saveTradeTimer(ctx, sessionID); err != nil {
+		return fmt.Errorf("s.saveTradeTimer: %w", err)
+	}
+
+	s.notifyAutopilotState(sessionID, a)
+	return nil
+}
+
+func (s *Service) ResumeAutopilot(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("resume autopilot")
+
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return ErrAutopilotNotRunning
+	}
+
+	a.mx.Lock()
+	if a.phaseTimer != nil {
+		a.phaseTimer.Resume()
+	} else {
+		s.controllers.Trade(sessionID).Resume()
+	}
+	a.mx.Unlock()
+
+	if err := s.saveTradeTimer(ctx, sessionID); err != nil {
+		return fmt.Errorf("s.saveTradeTimer: %w", err)
+	}
+
+	s.notifyAutopilotState(sessionID, a)
+	return nil
+}
+
+// SkipAutopilotPhase досрочно завершает текущую фазу, автопилот переходит к следующей.
+func (s *Service) SkipAutopilotPhase(_ context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("skip autopilot phase")
+
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return ErrAutopilotNotRunning
+	}
+	s.skipPhase(sessionID, a)
+	return nil
+}
+
+func (s *Service) ExtendAutopilotPhase(ctx context.Context, sessionID int64, d time.Duration) error {
+	s.log.Trace().Int64("session_id", sessionID).Dur("duration", d).Msg("extend autopilot phase")
+
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return ErrAutopilotNotRunning
+	}
+
+	a.mx.Lock()
+	if a.phaseTimer != nil {
+		a.phaseTimer.Extend(d)
+	} else {
+		s.controllers.Trade(sessionID).Extend(d)
+	}
+	a.mx.Unlock()
+
+	if err := s.saveTradeTimer(ctx, sessionID); err != nil {
+		return fmt.Errorf("s.saveTradeTimer: %w", err)
+	}
+
+	s.notifyAutopilotState(sessionID, a)
+	return nil
+}
+
+func (s *Service) GetAutopilotState(_ context.Context, sessionID int64) (models.AutopilotState, error) {
+	a, ok := s.getAutopilot(sessionID)
+	if !ok {
+		return models.AutopilotState{}, ErrAutopilotNotRunning
+	}
+	return s.getAutopilotState(sessionID, a), nil
+}
+
+func (s *Service) runAutopilot(sessionID int64, a *autopilot) {
+	defer close(a.done)
+	defer s.removeAutopilot(sessionID, a)
+	defer func() {
+		if err := s.repo.SetAutopilotStatus(context.Background(), sessionID, models.AutopilotStatusOff); err != nil {
+			s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.repo.SetAutopilotStatus")
+		}
+	}()
+
+	ctx := context.Background()
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.settingsRepo.Get")
+		return
+	}
+
+	for {
+		game, err := s.repo.Get(ctx, sessionID)
+		if err != nil {
+			s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.repo.Get")
+			return
+		}
+		if game.CurrentRound >= settings.RoundsCount {
+			break
+		}
+		round := game.CurrentRound + 1
+
+		if a.isStopped() {
+			return
+		}
+		if err = s.startRound(ctx, sessionID); err != nil {
+			s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.startRound")
+			return
+		}
+		if !s.runAutopilotPhase(sessionID, a, models.AutopilotPhaseBriefing, round, settings.BriefingDuration) {
+			return
+		}
+
+		if err = s.setTradeState(ctx, sessionID, models.TradeStateStarted); err != nil {
+			s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.setTradeState")
+			return
+		}
+		waitTrade, ok := s.beginTradingPhase(sessionID, a, round)
+		closeTrade := true
+		if ok {
+			s.notifyAutopilotState(sessionID, a)
+			closeTrade = waitTrade()
+		}
+		if closeTrade {
+			if err = s.closeTradeState(ctx, sessionID); err != nil {
+				s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.closeTradeState")
+			}
+		}
+		if a.isStopped() {
+			return
+		}
+
+		if err = s.stopRound(ctx, sessionID); err != nil {
+			s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.stopRound")
+			return
+		}
+		if !s.runAutopilotPhase(sessionID, a, models.AutopilotPhaseResults, round, settings.ResultsDuration) {
+			return
+		}
+	}
+
+	s.removeAutopilot(sessionID, a)
+	if err = s.StopGame(ctx, sessionID); err != nil {
+		s.log.Error().Err(err).Int64("session_id", sessionID).Msg("autopilot: s.StopGame")
+	}
+}
+
+// interruptAutopilot переводит в ручной режим сессию, автопилот которой прервал перезапуск сервера,
+// и сообщает об этом администраторам. Прерванная фаза не продолжается: администратор доводит раунд
+// вручную или запускает автопилот заново.
+func (s *Service) interruptAutopilot(ctx context.Context, game models.Game) error {
+	if err := s.repo.SetAutopilotStatus(ctx, game.ID, models.AutopilotStatusInterrupted); err != nil {
+		return fmt.Errorf("s.repo.SetAutopilotStatus: %w", err)
+	}
+	s.log.Warn().
+		Int64("session_id", game.ID).
+		Int("round", game.CurrentRound).
+		Msg("autopilot interrupted by restart, session switched to manual control")
+	s.notifier.NotifyAutopilotInterrupted(game.ID, game.CurrentRound)
+	return nil
+}
+
+// runAutopilotPhase ждёт окончания фазы и возвращает false, если автопилот был остановлен.
+func (s *Service) runAutopilotPhase(
+	sessionID int64,
+	a *autopilot,
+	phase models.AutopilotPhase,
+	round int,
+	d time.Duration,
+) bool {
+	phaseTimer := newCountdown(d)
+	if !a.beginPhase(phase, round, phaseTimer) {
+		phaseTimer.Stop()
+		return false
+	}
+	s.notifyAutopilotState(sessionID, a)
+
+	<-phaseTimer.Done()
+	return !a.isStopped()
+}
+
+// beginTradingPhase переводит автопилот в фазу торгов и запускает отсчёт под блокировкой
+// автопилота, чтобы skipPhase не попал между сменой фазы и стартом торгов. Возвращает
+// функцию ожидания окончания торгов или false, если автопилот уже остановлен.
+func (s *Service) beginTradingPhase(sessionID int64, a *autopilot, round int) (func() bool, bool) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.stopped {
+		return nil, false
+	}
+	a.phase = models.AutopilotPhaseTrading
+	a.round = round
+	a.phaseTimer = nil
+	return s.controllers.Trade(sessionID).BeginTradePeriod(), true
+}
+
+// skipPhase завершает текущую фазу. Выполняется под блокировкой автопилота, поэтому фаза
+// не может смениться между выбором таймера и его остановкой.
+func (s *Service) skipPhase(sessionID int64, a *autopilot) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	if a.phaseTimer != nil {
+		a.phaseTimer.Stop()
+		return
+	}
+	s.controllers.Trade(sessionID).StopTradePeriod()
+}
+
+// checkManualControl запрещает ручные команды раундов и торгов, пока сессию ведёт автопилот.
+func (s *Service) checkManualControl(sessionID int64) error {
+	if _, ok := s.getAutopilot(sessionID); ok {
+		return ErrAutopilotRunning
+	}
+	return nil
+}
+
+func (s *Service) getAutopilotState(sessionID int64, a *autopilot) models.AutopilotState {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	state := models.AutopilotState{
+		Phase: a.phase,
+		Round: a.round,
+	}
+	if a.phaseTimer != nil {
+		state.Remaining = a.phaseTimer.Remaining()
+		state.IsPaused = a.phaseTimer.IsPaused()
+	} else {
+		state.Remaining = s.controllers.Trade(sessionID).Remaining()
+		state.IsPaused = s.controllers.Trade(sessionID).IsPaused()
+	}
+	return state
+}
+
+func (s *Service) notifyAutopilotState(sessionID int64, a *autopilot) {
+	s.notifier.NotifyAutopilotStateChanged(sessionID, s.getAutopilotState(sessionID, a))
+}
+
+func (s *Service) getAutopilot(sessionID int64) (*autopilot, bool) {
+	s.autopilotsMx.Lock()
+	defer s.autopilotsMx.Unlock()
+	a, ok := s.autopilots[sessionID]
+	return a, ok
+}
+
+func (s *Service) removeAutopilot(sessionID int64, a *autopilot) {
+	s.autopilotsMx.Lock()
+	defer s.autopilotsMx.Unlock()
+	if s.autopilots[sessionID] == a {
+		delete(s.autopilots, sessionID)
+	}
+}
@@ -0,0 +1,90 @@
+package games
+
+import (
+	"sync"
+	"time"
+)
+
+// Controllers хранит отдельные TradeController и GameController для каждой игровой сессии,
+// чтобы старт торгов в одной комнате не открывал торги в другой.
+type Controllers struct {
+	tradePeriod time.Duration
+	tradeNotify []func(sessionID int64, isTrade bool)
+	gameNotify  []func(sessionID int64, isRegistration bool)
+	trade       map[int64]*TradeController
+	game        map[int64]*GameController
+	mx          sync.Mutex
+}
+
+func NewControllers(tradePeriod time.Duration) *Controllers {
+	return &Controllers{
+		tradePeriod: tradePeriod,
+		trade:       make(map[int64]*TradeController),
+		game:        make(map[int64]*GameController),
+		mx:          sync.Mutex{},
+	}
+}
+
+// RegisterTradeNotify должен вызываться до первого обращения к контроллерам сессий.
+func (c *Controllers) RegisterTradeNotify(f func(sessionID int64, isTrade bool)) {
+	c.tradeNotify = append(c.tradeNotify, f)
+}
+
+// RegisterGameNotify должен вызываться до первого обращения к контроллерам сессий.
+func (c *Controllers) RegisterGameNotify(f func(sessionID int64, isRegistration bool)) {
+	c.gameNotify = append(c.gameNotify, f)
+}
+
+func (c *Controllers) Trade(sessionID int64) *TradeController {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	ctrl, ok := c.trade[sessionID]
+	if ok {
+		return ctrl
+	}
+
+	ctrl = NewTradeController(c.tradePeriod)
+	for _, fn := range c.tradeNotify {
+		ctrl.RegisterNotify(func(isTrade bool) {
+			fn(sessionID, isTrade)
+		})
+	}
+	c.trade[sessionID] = ctrl
+	return ctrl
+}
+
+func (c *Controllers) Game(sessionID int64) *GameController {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	ctrl, ok := c.game[sessionID]
+	if ok {
+		return ctrl
+	}
+
+	ctrl = &GameController{}
+	for _, fn := range c.gameNotify {
+		ctrl.RegisterNotify(func(isRegistration bool) {
+			fn(sessionID, isRegistration)
+		})
+	}
+	c.game[sessionID] = ctrl
+	return ctrl
+}
+
+func (c *Controllers) SetTradePeriod(period time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.tradePeriod = period
+	for _, ctrl := range c.trade {
+		ctrl.SetPeriod(period)
+	}
+}
+
+func (c *Controllers) TradePeriod() time.Duration {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.tradePeriod
+}
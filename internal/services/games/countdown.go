@@ -0,0 +1,131 @@
+package games
+
+import (
+	"sync"
+	"time"
+)
+
+// countdown — таймер фазы, который можно приостановить, продлить или завершить досрочно.
+type countdown struct {
+	mx        sync.Mutex
+	timer     *time.Timer
+	deadline  time.Time
+	remaining time.Duration
+	paused    bool
+	finished  bool
+	// gen увеличивается при каждом перезапуске timer, чтобы устаревший таймер не завершил отсчёт.
+	gen  int
+	done chan struct{}
+}
+
+func newCountdown(d time.Duration) *countdown {
+	c := &countdown{
+		mx:   sync.Mutex{},
+		done: make(chan struct{}),
+	}
+	c.mx.Lock()
+	c.start(d)
+	c.mx.Unlock()
+	return c
+}
+
+// start должен вызываться под c.mx.
+func (c *countdown) start(d time.Duration) {
+	c.gen++
+	gen := c.gen
+	c.deadline = time.Now().Add(d)
+	c.timer = time.AfterFunc(d, func() {
+		c.mx.Lock()
+		defer c.mx.Unlock()
+		if c.gen == gen && !c.paused {
+			c.finish()
+		}
+	})
+}
+
+// finish должен вызываться под c.mx.
+func (c *countdown) finish() {
+	if c.finished {
+		return
+	}
+	c.finished = true
+	c.timer.Stop()
+	close(c.done)
+}
+
+func (c *countdown) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *countdown) Pause() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.paused || c.finished {
+		return false
+	}
+	c.timer.Stop()
+	c.gen++
+	c.remaining = max(time.Until(c.deadline), 0)
+	c.paused = true
+	return true
+}
+
+func (c *countdown) Resume() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if !c.paused || c.finished {
+		return false
+	}
+	c.paused = false
+	c.start(c.remaining)
+	return true
+}
+
+func (c *countdown) Extend(d time.Duration) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.finished {
+		return
+	}
+	if c.paused {
+		c.remaining += d
+		return
+	}
+	c.timer.Stop()
+	c.start(max(time.Until(c.deadline)+d, 0))
+}
+
+func (c *countdown) Stop() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	c.finish()
+}
+
+func (c *countdown) Remaining() time.Duration {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	switch {
+	case c.finished:
+		return 0
+	case c.paused:
+		return c.remaining
+	default:
+		return max(time.Until(c.deadline), 0)
+	}
+}
+
+func (c *countdown) IsPaused() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.paused
+}
+
+func (c *countdown) IsFinished() bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.finished
+}
@@ -2,108 +2,175 @@ package games
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
+	"sync"
 	"time"
 )
 
 type Service struct {
-	repo            repo.GamesRepo
-	tradeController *TradeController
-	gameController  *GameController
-	notifier        *TeamsNotifier
-	log             *zerolog.Logger
+	repo             repo.GamesRepo
+	settingsRepo     repo.SettingsRepo
+	controllers      *Controllers
+	notifier         *TeamsNotifier
+	rollRandomEvents func(ctx context.Context, sessionID int64, round int) ([]models.TeamRandomEvent, error)
+	// markShortsToMarket пересчитывает залог под короткие позиции и возвращает принудительно закрытые.
+	markShortsToMarket func(ctx context.Context, sessionID int64, round int) ([]models.MarginCall, error)
+	// adjustPrices пересчитывает цены раунда по спросу, если это включено в настройках.
+	adjustPrices func(ctx context.Context, sessionID int64, round int) ([]models.SharePriceAdjustment, error)
+	// payDividends начисляет командам дивиденды за раунд и возвращает выплаты.
+	payDividends func(ctx context.Context, sessionID int64, round int) ([]models.DividendPayout, error)
+	// payBondCoupons выплачивает купоны и погашает облигации в начале раунда.
+	payBondCoupons func(ctx context.Context, sessionID int64, round int) ([]models.BondPayment, error)
+	// chargeLoanInterest начисляет проценты по долгам команд в начале раунда.
+	chargeLoanInterest func(ctx context.Context, sessionID int64, round int) ([]models.LoanInterest, error)
+	autopilots         map[int64]*autopilot
+	autopilotsMx       sync.Mutex
+	// tradeMx сериализует запуск периода торгов и сохранение его окончания, чтобы закрытие
+	// завершившегося периода не перезаписало состояние только что начатого.
+	tradeMx sync.Mutex
+	log     *zerolog.Logger
 }
 
 func New(
 	repo repo.GamesRepo,
-	tradeController *TradeController,
-	gameController *GameController,
+	settingsRepo repo.SettingsRepo,
+	controllers *Controllers,
 	notifier *TeamsNotifier,
+	rollRandomEvents func(ctx context.Context, sessionID int64, round int) ([]models.TeamRandomEvent, error),
+	markShortsToMarket func(ctx context.Context, sessionID int64, round int) ([]models.MarginCall, error),
+	adjustPrices func(ctx context.Context, sessionID int64, round int) ([]models.SharePriceAdjustment, error),
+	payDividends func(ctx context.Context, sessionID int64, round int) ([]models.DividendPayout, error),
+	payBondCoupons func(ctx context.Context, sessionID int64, round int) ([]models.BondPayment, error),
+	chargeLoanInterest func(ctx context.Context, sessionID int64, round int) ([]models.LoanInterest, error),
 	log *zerolog.Logger,
 ) *Service {
 	return &Service{
-		repo:            repo,
-		tradeController: tradeController,
-		gameController:  gameController,
-		notifier:        notifier,
-		log:             log,
+		repo:               repo,
+		settingsRepo:       settingsRepo,
+		controllers:        controllers,
+		notifier:           notifier,
+		rollRandomEvents:   rollRandomEvents,
+		markShortsToMarket: markShortsToMarket,
+		adjustPrices:       adjustPrices,
+		payDividends:       payDividends,
+		payBondCoupons:     payBondCoupons,
+		chargeLoanInterest: chargeLoanInterest,
+		autopilots:         make(map[int64]*autopilot),
+		autopilotsMx:       sync.Mutex{},
+		tradeMx:            sync.Mutex{},
+		log:                log,
 	}
 }
 
-func (s *Service) Get(ctx context.Context) (*models.Game, error) {
-	game, err := s.repo.Get(ctx)
+func (s *Service) Get(ctx context.Context, sessionID int64) (*models.Game, error) {
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("s.repo.Get: %w", err)
 	}
 	return game, nil
 }
 
+func (s *Service) GetSessions(ctx context.Context) ([]models.Game, error) {
+	games, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAll: %w", err)
+	}
+	return games, nil
+}
+
+func (s *Service) CreateSession(ctx context.Context, name string) (*models.Game, error) {
+	s.log.Trace().Str("name", name).Msg("create session")
+
+	game := &models.Game{
+		Name:         name,
+		State:        models.GameStateStopGenerally,
+		CurrentRound: 0,
+		TradeState:   models.TradeStateNotStarted,
+		CurrentGame:  1,
+	}
+	id, err := s.repo.Create(ctx, game)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.Create: %w", err)
+	}
+	game.ID = id
+	return game, nil
+}
+
 type UpdateParams struct {
 	State        models.GameState
 	CurrentRound int
 	TradeState   models.TradeState
 }
 
-func (s *Service) Update(ctx context.Context, params UpdateParams) error {
-	game, err := s.repo.Get(ctx)
+func (s *Service) Update(ctx context.Context, sessionID int64, params UpdateParams) error {
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 
 	game.CurrentRound = params.CurrentRound
 
+	// смена состояния торгов сохраняется через setTradeState вместе со сроком периода
 	tradeStateChanged := game.TradeState != params.TradeState
-	game.TradeState = params.TradeState
 
 	gameStateChanged := game.State != params.State
 	game.State = params.State
 	if gameStateChanged && game.State == models.GameStateStarted {
 		game.CurrentRound = 1
 	}
+	// раунд задан администратором, незавершённое начало прошлого раунда не повторяется
+	game.RoundStartPending = false
 
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
 	}
 
 	if gameStateChanged {
-		s.onGameStateChange(game.State)
+		s.onGameStateChange(game.ID, game.State)
 	}
 
-	if tradeStateChanged && game.TradeState == models.TradeStateStarted {
-		go func() {
-			s.log.Trace().Msg("start trade period")
-			s.tradeController.StartTradePeriod()
-			s.log.Trace().Msg("stopped trade period")
-
-			game.TradeState = models.TradeStateNotStarted
-			if err = s.repo.Update(ctx, game); err != nil {
-				s.log.Error().Err(err).Msg("s.repo.Update")
+	if tradeStateChanged {
+		if params.TradeState == models.TradeStateStarted {
+			if err = s.startTradePeriod(ctx, game.ID); err != nil {
+				return fmt.Errorf("s.startTradePeriod: %w", err)
+			}
+		} else {
+			if err = s.setTradeState(ctx, game.ID, params.TradeState); err != nil {
+				return fmt.Errorf("s.setTradeState: %w", err)
 			}
-		}()
+			s.controllers.Trade(game.ID).StopTradePeriod()
+		}
 	}
 
 	return nil
 }
 
-func (s *Service) CreateNewGame(ctx context.Context) error {
-	s.log.Trace().Msg("create new game")
+func (s *Service) CreateNewGame(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("create new game")
 
-	game, err := s.repo.Get(ctx)
+	if err := s.StopAutopilot(ctx, sessionID); err != nil && !errors.Is(err, ErrAutopilotNotRunning) {
+		return fmt.Errorf("s.StopAutopilot: %w", err)
+	}
+
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 	game.CurrentGame++
 	// Registration Closed (-1)
 	game.State = models.GameStateClosed
-	s.onGameStateChange(models.GameStateClosed)
+	s.onGameStateChange(game.ID, models.GameStateClosed)
 
 	// default
 	game.CurrentRound = 0
+	game.RoundStartPending = false
 	game.TradeState = models.TradeStateNotStarted
-	s.tradeController.StopTradePeriod()
+	s.controllers.Trade(game.ID).StopTradePeriod()
 
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
@@ -111,20 +178,21 @@ func (s *Service) CreateNewGame(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) StartGame(ctx context.Context) error {
-	s.log.Trace().Msg("start game")
+func (s *Service) StartGame(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("start game")
 
-	game, err := s.repo.Get(ctx)
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 
 	game.State = models.GameStateStarted
-	s.notifier.NotifyGameStateChanged(game.State)
-	s.onGameStateChange(models.GameStateStarted)
+	s.notifier.NotifyGameStateChanged(game.ID, game.State)
+	s.onGameStateChange(game.ID, models.GameStateStarted)
 	game.CurrentRound = 0
+	game.RoundStartPending = false
 	game.TradeState = models.TradeStateNotStarted
-	s.tradeController.StopTradePeriod()
+	s.controllers.Trade(game.ID).StopTradePeriod()
 
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
@@ -132,37 +200,109 @@ func (s *Service) StartGame(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) StopGame(ctx context.Context) error {
-	s.log.Trace().Msg("stop game")
+func (s *Service) StopGame(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("stop game")
 
-	game, err := s.repo.Get(ctx)
+	if err := s.StopAutopilot(ctx, sessionID); err != nil && !errors.Is(err, ErrAutopilotNotRunning) {
+		return fmt.Errorf("s.StopAutopilot: %w", err)
+	}
+
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 
 	game.State = models.GameStateStopGenerally
-	s.notifier.NotifyGameStateChanged(game.State)
-	s.onGameStateChange(models.GameStateStopGenerally)
+	s.notifier.NotifyGameStateChanged(game.ID, game.State)
+	s.onGameStateChange(game.ID, models.GameStateStopGenerally)
 	game.CurrentRound = 0
+	game.RoundStartPending = false
 	game.TradeState = models.TradeStateNotStarted
-	s.tradeController.StopTradePeriod()
+	s.controllers.Trade(game.ID).StopTradePeriod()
+
+	if err = s.repo.Update(ctx, game); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+var (
+	ErrGameNotStarted = errors.New("game is not started")
+	ErrGameNotPaused  = errors.New("game is not paused")
+	ErrGamePaused     = errors.New("game is paused")
+	// ErrTradeAlreadyStarted — период торгов сессии уже идёт.
+	ErrTradeAlreadyStarted = errors.New("trade period is already running")
+)
+
+// PauseGame замораживает игру сессии: отсчёт торгов и фазы автопилота останавливаются
+// с сохранением оставшегося времени, покупки отклоняются до ResumeGame.
+func (s *Service) PauseGame(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("pause game")
+
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.State != models.GameStateStarted {
+		return ErrGameNotStarted
+	}
+
+	game.State = models.GameStatePaused
+	if s.controllers.Trade(game.ID).Pause() {
+		game.TradeRemaining = lo.ToPtr(s.controllers.Trade(game.ID).Remaining())
+	}
+	if err = s.repo.Update(ctx, game); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+
+	if err = s.PauseAutopilot(ctx, game.ID); err != nil && !errors.Is(err, ErrAutopilotNotRunning) {
+		return fmt.Errorf("s.PauseAutopilot: %w", err)
+	}
+
+	s.notifier.NotifyGameStateChanged(game.ID, game.State)
+	s.notifier.NotifyGamePauseChanged(game.ID, true, s.controllers.Trade(game.ID).Remaining())
+	return nil
+}
+
+func (s *Service) ResumeGame(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("resume game")
 
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.State != models.GameStatePaused {
+		return ErrGameNotPaused
+	}
+
+	game.State = models.GameStateStarted
+	if s.controllers.Trade(game.ID).Resume() {
+		game.TradeDeadline = lo.ToPtr(time.Now().Add(s.controllers.Trade(game.ID).Remaining()))
+		game.TradeRemaining = nil
+	}
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
 	}
+
+	if err = s.ResumeAutopilot(ctx, game.ID); err != nil && !errors.Is(err, ErrAutopilotNotRunning) {
+		return fmt.Errorf("s.ResumeAutopilot: %w", err)
+	}
+
+	s.notifier.NotifyGameStateChanged(game.ID, game.State)
+	s.notifier.NotifyGamePauseChanged(game.ID, false, s.controllers.Trade(game.ID).Remaining())
 	return nil
 }
 
-func (s *Service) StartRegistration(ctx context.Context) error {
-	s.log.Trace().Msg("start registration")
+func (s *Service) StartRegistration(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("start registration")
 
-	game, err := s.repo.Get(ctx)
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 
 	game.State = models.GameStateOpened
-	s.onGameStateChange(models.GameStateOpened)
+	s.onGameStateChange(game.ID, models.GameStateOpened)
 
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
@@ -170,16 +310,16 @@ func (s *Service) StartRegistration(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) StopRegistration(ctx context.Context) error {
-	s.log.Trace().Msg("stop registration")
+func (s *Service) StopRegistration(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("stop registration")
 
-	game, err := s.repo.Get(ctx)
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
 
 	game.State = models.GameStateClosed
-	s.onGameStateChange(models.GameStateClosed)
+	s.onGameStateChange(game.ID, models.GameStateClosed)
 
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
@@ -187,75 +327,318 @@ func (s *Service) StopRegistration(ctx context.Context) error {
 	return nil
 }
 
-func (s *Service) StartRound(ctx context.Context) error {
-	s.log.Trace().Msg("start round")
+func (s *Service) StartRound(ctx context.Context, sessionID int64) error {
+	if err := s.checkManualControl(sessionID); err != nil {
+		return err
+	}
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return ErrGamePaused
+	}
+	return s.startRound(ctx, sessionID)
+}
+
+func (s *Service) startRound(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("start round")
 
-	game, err := s.repo.Get(ctx)
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
-	game.CurrentRound++
-	s.notifier.NotifyRoundPeriodChanged(true)
+	// если прошлый запуск раунда прервался, его действия повторяются без перехода к следующему раунду:
+	// каждое из них пропускает уже обработанные в этом раунде команды
+	if !game.RoundStartPending {
+		game.CurrentRound++
+		game.RoundStartPending = true
+	}
+	s.notifier.NotifyRoundPeriodChanged(game.ID, true)
+	if err = s.repo.Update(ctx, game); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+
+	if err = s.runRoundStartActions(ctx, game); err != nil {
+		return fmt.Errorf("s.runRoundStartActions: %w", err)
+	}
+
+	if game, err = s.repo.Get(ctx, sessionID); err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	game.RoundStartPending = false
 	if err = s.repo.Update(ctx, game); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
 	}
 	return nil
 }
 
-func (s *Service) StopRound(_ context.Context) error {
-	s.log.Trace().Msg("stop round")
-	s.notifier.NotifyRoundPeriodChanged(false)
+// runRoundStartActions выполняет действия начала раунда: пересчёт цен, выплаты по облигациям, проценты
+// по долгам, случайные события и маржин-коллы.
+func (s *Service) runRoundStartActions(ctx context.Context, game *models.Game) error {
+	// цены пересчитываются до случайных событий и маржин-коллов, которые от них зависят
+	if _, err := s.adjustPrices(ctx, game.ID, game.CurrentRound); err != nil {
+		return fmt.Errorf("s.adjustPrices: %w", err)
+	}
+
+	bondPayments, err := s.payBondCoupons(ctx, game.ID, game.CurrentRound)
+	if err != nil {
+		return fmt.Errorf("s.payBondCoupons: %w", err)
+	}
+	if len(bondPayments) != 0 {
+		s.notifier.NotifyBondPayments(game.ID, game.CurrentRound, bondPayments)
+	}
+
+	// проценты списываются после купонов, чтобы команды могли оплатить их из выплат
+	loanInterests, err := s.chargeLoanInterest(ctx, game.ID, game.CurrentRound)
+	if err != nil {
+		return fmt.Errorf("s.chargeLoanInterest: %w", err)
+	}
+	if len(loanInterests) != 0 {
+		s.notifier.NotifyLoanInterestCharged(game.ID, game.CurrentRound, loanInterests)
+	}
+
+	if err = s.applyRandomEvents(ctx, game.ID, game.CurrentRound); err != nil {
+		return fmt.Errorf("s.applyRandomEvents: %w", err)
+	}
+
+	marginCalls, err := s.markShortsToMarket(ctx, game.ID, game.CurrentRound)
+	if err != nil {
+		return fmt.Errorf("s.markShortsToMarket: %w", err)
+	}
+	for _, marginCall := range marginCalls {
+		s.notifier.NotifyMarginCall(game.ID, marginCall)
+	}
 	return nil
 }
 
-func (s *Service) StartTrade(ctx context.Context) error {
-	game, err := s.repo.Get(ctx)
+func (s *Service) applyRandomEvents(ctx context.Context, sessionID int64, round int) error {
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if !settings.EnableRandomEvents {
+		return nil
+	}
+
+	events, err := s.rollRandomEvents(ctx, sessionID, round)
+	if err != nil {
+		return fmt.Errorf("s.rollRandomEvents: %w", err)
+	}
+	for _, event := range events {
+		s.notifier.NotifyRandomEvent(sessionID, event)
+	}
+	return nil
+}
+
+func (s *Service) StopRound(ctx context.Context, sessionID int64) error {
+	if err := s.checkManualControl(sessionID); err != nil {
+		return err
+	}
+	return s.stopRound(ctx, sessionID)
+}
+
+func (s *Service) stopRound(ctx context.Context, sessionID int64) error {
+	s.log.Trace().Int64("session_id", sessionID).Msg("stop round")
+	s.notifier.NotifyRoundPeriodChanged(sessionID, false)
+
+	game, err := s.repo.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("s.repo.Get: %w", err)
 	}
-	game.TradeState = models.TradeStateStarted
+	if game.CurrentRound == 0 {
+		return nil
+	}
 
-	if err = s.repo.Update(ctx, game); err != nil {
-		return fmt.Errorf("s.repo.Update: %w", err)
+	payouts, err := s.payDividends(ctx, game.ID, game.CurrentRound)
+	if err != nil {
+		return fmt.Errorf("s.payDividends: %w", err)
+	}
+	if len(payouts) != 0 {
+		s.notifier.NotifyDividendsPaid(game.ID, game.CurrentRound, payouts)
 	}
+	return nil
+}
 
-	go func() {
-		s.log.Trace().Msg("start trade period")
-		s.tradeController.StartTradePeriod()
-		s.log.Trace().Msg("stop trade period")
-		game.TradeState = models.TradeStateNotStarted
-		if err = s.repo.Update(context.Background(), game); err != nil {
-			s.log.Error().Err(err).Msg("s.repo.Update")
-		}
-	}()
+func (s *Service) StartTrade(ctx context.Context, sessionID int64) error {
+	if err := s.checkManualControl(sessionID); err != nil {
+		return err
+	}
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return ErrGamePaused
+	}
 
+	if err = s.startTradePeriod(ctx, sessionID); err != nil {
+		return fmt.Errorf("s.startTradePeriod: %w", err)
+	}
 	return nil
 }
 
-func (s *Service) StopTrade(_ context.Context) {
-	s.log.Trace().Msg("called stop trade")
-	s.tradeController.StopTradePeriod()
+// startTradePeriod сохраняет начало торгов и запускает отсчёт. Пока идёт период торгов,
+// повторный запуск отклоняется, иначе прежний отсчёт досрочно закрыл бы новый период.
+func (s *Service) startTradePeriod(ctx context.Context, sessionID int64) error {
+	s.tradeMx.Lock()
+	defer s.tradeMx.Unlock()
+
+	ctrl := s.controllers.Trade(sessionID)
+	if ctrl.IsActive() {
+		return ErrTradeAlreadyStarted
+	}
+	if err := s.setTradeState(ctx, sessionID, models.TradeStateStarted); err != nil {
+		return fmt.Errorf("s.setTradeState: %w", err)
+	}
+
+	s.log.Trace().Int64("session_id", sessionID).Msg("start trade period")
+	go s.finishTradePeriod(sessionID, ctrl.BeginTradePeriod())
+	return nil
+}
+
+// finishTradePeriod ждёт окончания периода торгов и сохраняет его, если период не был заменён следующим.
+func (s *Service) finishTradePeriod(sessionID int64, wait func() bool) {
+	if !wait() {
+		s.log.Trace().Int64("session_id", sessionID).Msg("trade period replaced")
+		return
+	}
+	s.log.Trace().Int64("session_id", sessionID).Msg("stop trade period")
+
+	if err := s.closeTradeState(context.Background(), sessionID); err != nil {
+		s.log.Error().Err(err).Msg("s.closeTradeState")
+	}
+}
+
+// closeTradeState сохраняет окончание торгов, если к этому моменту не начался новый период.
+func (s *Service) closeTradeState(ctx context.Context, sessionID int64) error {
+	s.tradeMx.Lock()
+	defer s.tradeMx.Unlock()
+
+	if s.controllers.Trade(sessionID).IsActive() {
+		return nil
+	}
+	if err := s.setTradeState(ctx, sessionID, models.TradeStateNotStarted); err != nil {
+		return fmt.Errorf("s.setTradeState: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) setTradeState(ctx context.Context, sessionID int64, state models.TradeState) error {
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	game.TradeState = state
+	game.TradeRemaining = nil
+	if state == models.TradeStateStarted {
+		now := time.Now()
+		game.TradeStartedAt = lo.ToPtr(now)
+		game.TradeDeadline = lo.ToPtr(now.Add(s.controllers.TradePeriod()))
+	} else {
+		game.TradeStartedAt = nil
+		game.TradeDeadline = nil
+	}
+
+	if err = s.repo.Update(ctx, game); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) StopTrade(_ context.Context, sessionID int64) error {
+	if err := s.checkManualControl(sessionID); err != nil {
+		return err
+	}
+	s.log.Trace().Int64("session_id", sessionID).Msg("called stop trade")
+	s.controllers.Trade(sessionID).StopTradePeriod()
+	return nil
 }
 
 func (s *Service) UpdateTradePeriod(period time.Duration) {
 	s.log.Trace().Msg("trade period updated")
-	s.tradeController.SetPeriod(period)
+	s.controllers.SetTradePeriod(period)
 }
 
-func (s *Service) onGameStateChange(state models.GameState) {
+func (s *Service) onGameStateChange(sessionID int64, state models.GameState) {
 	switch state {
 	case models.GameStateOpened:
-		s.log.Trace().Msg("start registration period")
-		s.gameController.StartRegistrationPeriod()
+		s.log.Trace().Int64("session_id", sessionID).Msg("start registration period")
+		s.controllers.Game(sessionID).StartRegistrationPeriod()
 	case models.GameStateClosed:
-		s.log.Trace().Msg("stop registration period")
-		s.gameController.StopRegistrationPeriod()
+		s.log.Trace().Int64("session_id", sessionID).Msg("stop registration period")
+		s.controllers.Game(sessionID).StopRegistrationPeriod()
 	case models.GameStateStarted:
-		s.log.Trace().Msg("stop registration period")
-		s.gameController.StopRegistrationPeriod()
+		s.log.Trace().Int64("session_id", sessionID).Msg("stop registration period")
+		s.controllers.Game(sessionID).StopRegistrationPeriod()
 	case models.GameStateStopGenerally:
-		s.log.Trace().Msg("stop registration period")
-		s.gameController.StopRegistrationPeriod()
+		s.log.Trace().Int64("session_id", sessionID).Msg("stop registration period")
+		s.controllers.Game(sessionID).StopRegistrationPeriod()
 	default:
 	}
 }
+
+// saveTradeTimer сохраняет текущий отсчёт торгов сессии после паузы, продления или возобновления.
+func (s *Service) saveTradeTimer(ctx context.Context, sessionID int64) error {
+	game, err := s.repo.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("s.repo.Get: %w", err)
+	}
+	if game.TradeState != models.TradeStateStarted {
+		return nil
+	}
+
+	ctrl := s.controllers.Trade(sessionID)
+	if ctrl.IsPaused() {
+		game.TradeRemaining = lo.ToPtr(ctrl.Remaining())
+	} else {
+		game.TradeDeadline = lo.ToPtr(time.Now().Add(ctrl.Remaining()))
+		game.TradeRemaining = nil
+	}
+
+	if err = s.repo.Update(ctx, game); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+// RestoreState восстанавливает состояние контроллеров сессий после перезапуска сервера:
+// открытую регистрацию и период торгов, который либо продолжается с оставшимся временем,
+// либо закрывается, если срок уже истёк. Сессии, которые вёл автопилот, переводятся в ручной режим.
+func (s *Service) RestoreState(ctx context.Context) error {
+	games, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetAll: %w", err)
+	}
+
+	for _, game := range games {
+		s.onGameStateChange(game.ID, game.State)
+
+		if game.AutopilotStatus == models.AutopilotStatusRunning {
+			if err = s.interruptAutopilot(ctx, game); err != nil {
+				return fmt.Errorf("s.interruptAutopilot: %w", err)
+			}
+		}
+
+		if game.TradeState != models.TradeStateStarted {
+			continue
+		}
+
+		switch {
+		case game.TradeRemaining != nil:
+			s.log.Info().Int64("session_id", game.ID).Dur("remaining", *game.TradeRemaining).Msg("restore paused trade period")
+			go s.finishTradePeriod(game.ID, s.controllers.Trade(game.ID).RestoreTradePeriod(*game.TradeRemaining, true))
+		case game.TradeDeadline != nil && time.Now().Before(*game.TradeDeadline):
+			remaining := time.Until(*game.TradeDeadline)
+			s.log.Info().Int64("session_id", game.ID).Dur("remaining", remaining).Msg("restore trade period")
+			go s.finishTradePeriod(game.ID, s.controllers.Trade(game.ID).RestoreTradePeriod(remaining, false))
+		default:
+			s.log.Info().Int64("session_id", game.ID).Msg("close expired trade period")
+			s.controllers.Trade(game.ID).StopTradePeriod()
+			if err = s.setTradeState(ctx, game.ID, models.TradeStateNotStarted); err != nil {
+				return fmt.Errorf("s.setTradeState: %w", err)
+			}
+		}
+	}
+	return nil
+}
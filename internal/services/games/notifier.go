@@ -4,21 +4,171 @@ import (
 	"github.com/gorilla/websocket"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/rs/zerolog"
+	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
+	"slices"
+	"strconv"
 	"sync"
+	"time"
 )
 
+// Subscriber — владелец websocket-соединения по данным access-токена. По нему решается,
+// какие события получает соединение: общие события игры приходят всем, события команды —
+// только ей и администраторам. Соединение закрывается при отзыве сессии входа AuthSessionID
+// и по истечении токена в ExpiresAt.
+type Subscriber struct {
+	Role          models.Role
+	TeamID        int64
+	AuthSessionID int64
+	ExpiresAt     time.Time
+}
+
+// envelopeVersion — версия формата конверта событий. Увеличивается при несовместимых изменениях.
+const envelopeVersion = 1
+
+// wsWriteTimeout ограничивает запись в одно соединение: клиент, переставший читать, отключается.
+const wsWriteTimeout = 5 * time.Second
+
+// connSendQueueSize — размер очереди исходящих сообщений соединения. Вмещает весь буфер событий
+// сессии, который может понадобиться отправить переподключившемуся клиенту.
+const connSendQueueSize = 2 * eventsBufferSize
+
+// eventsBufferSize — сколько последних событий сессии хранится для повторной отправки
+// переподключившимся клиентам.
+const eventsBufferSize = 1024
+
+type eventType string
+
+const (
+	eventTypeTradePeriodChanged      eventType = "tradePeriodChanged"
+	eventTypeRoundPeriodChanged      eventType = "roundPeriodChanged"
+	eventTypeGameStateChanged        eventType = "gameStateChanged"
+	eventTypeRandomEvent             eventType = "randomEvent"
+	eventTypeMarginCall              eventType = "marginCall"
+	eventTypeDividendsPaid           eventType = "dividendsPaid"
+	eventTypeBondPayments            eventType = "bondPayments"
+	eventTypeLoanInterestCharged     eventType = "loanInterestCharged"
+	eventTypeBalanceChanged          eventType = "balanceChanged"
+	eventTypeAdditionalInfoPurchased eventType = "additionalInfoPurchased"
+	eventTypeAutopilotStateChanged   eventType = "autopilotStateChanged"
+	eventTypeAutopilotInterrupted    eventType = "autopilotInterrupted"
+	eventTypeGamePauseChanged        eventType = "gamePauseChanged"
+	eventTypeOrderBookChanged        eventType = "orderBookChanged"
+	// eventTypeResyncRequired отправляется вместо пропущенных событий, если их уже нет в буфере
+	// или сервер перезапускался. Клиент должен перечитать состояние игры через API
+	// и продолжить с epoch и seq из этого сообщения.
+	eventTypeResyncRequired eventType = "resyncRequired"
+)
+
+// envelope — формат всех сообщений websocket. Seq монотонно растёт в пределах сессии и запуска
+// сервера, по паре Epoch и Seq клиент при переподключении запрашивает пропущенные события.
+type envelope struct {
+	Version int       `json:"v"`
+	Epoch   string    `json:"epoch"`
+	Type    eventType `json:"type"`
+	Seq     int64     `json:"seq"`
+	TS      time.Time `json:"ts"`
+	Payload any       `json:"payload"`
+}
+
+// recipients описывает полезную нагрузку события для разных подписчиков. Если задан all,
+// событие получают все, иначе администраторы получают admins, а команды — свой элемент teams.
+type recipients struct {
+	all    any
+	admins any
+	teams  map[int64]any
+}
+
+func toAll(payload any) recipients {
+	return recipients{all: payload}
+}
+
+// toTeam адресует событие команде teamID и администраторам.
+func toTeam(teamID int64, payload any) recipients {
+	return recipients{
+		admins: payload,
+		teams:  map[int64]any{teamID: payload},
+	}
+}
+
+// toEachTeam отправляет администраторам весь список, а каждой команде — только её элементы.
+func toEachTeam[T any](items []T, teamIDOf func(item T) int64, payloadOf func(items []T) any) recipients {
+	return recipients{
+		admins: payloadOf(items),
+		teams: lo.MapValues(lo.GroupBy(items, teamIDOf), func(teamItems []T, _ int64) any {
+			return payloadOf(teamItems)
+		}),
+	}
+}
+
+// event — событие сессии с готовыми сообщениями для каждого вида получателей.
+type event struct {
+	seq    int64
+	all    []byte
+	admins []byte
+	teams  map[int64][]byte
+}
+
+// messageFor возвращает сообщение для подписчика или nil, если событие ему не предназначено.
+func (e event) messageFor(sub Subscriber) []byte {
+	if e.all != nil {
+		return e.all
+	}
+	switch sub.Role {
+	case models.RoleAdmin:
+		return e.admins
+	case models.RoleTeam:
+		return e.teams[sub.TeamID]
+	default:
+		return nil
+	}
+}
+
+// sessionEvents — счётчик событий сессии и кольцевой буфер последних eventsBufferSize событий.
+// Событие с номером seq лежит в buffer[seq % eventsBufferSize].
+type sessionEvents struct {
+	seq    int64
+	buffer [eventsBufferSize]event
+}
+
+// since возвращает события после lastSeq или false, если часть из них уже вытеснена из буфера
+// либо lastSeq больше последнего seq. Совпадение эпохи проверяется до вызова.
+func (e *sessionEvents) since(lastSeq int64) ([]event, bool) {
+	if lastSeq < 0 || lastSeq > e.seq || e.seq-lastSeq > eventsBufferSize {
+		return nil, false
+	}
+	events := make([]event, 0, e.seq-lastSeq)
+	for seq := lastSeq + 1; seq <= e.seq; seq++ {
+		events = append(events, e.buffer[seq%eventsBufferSize])
+	}
+	return events, true
+}
+
+// ResumePoint — последнее полученное клиентом событие: эпоха запуска сервера и seq.
+type ResumePoint struct {
+	Epoch   string
+	LastSeq int64
+}
+
 type TeamsNotifier struct {
-	conns map[*websocket.Conn]struct{}
-	mx    sync.Mutex
-	log   *zerolog.Logger
+	// epoch отличает запуски сервера: после перезапуска seq начинаются заново, и seq клиента
+	// из прошлой эпохи не указывает на события текущей.
+	epoch string
+	// conns: ключ - ID сессии, значение - соединения сессии и их владельцы
+	conns map[int64]map[*websocket.Conn]*connection
+	// events: ключ - ID сессии
+	events map[int64]*sessionEvents
+	mx     sync.Mutex
+	log    *zerolog.Logger
 }
 
 func NewTeamsNotifier(log *zerolog.Logger) *TeamsNotifier {
 	return &TeamsNotifier{
-		log:   log,
-		conns: make(map[*websocket.Conn]struct{}),
-		mx:    sync.Mutex{},
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		log:    log,
+		conns:  make(map[int64]map[*websocket.Conn]*connection),
+		events: make(map[int64]*sessionEvents),
+		mx:     sync.Mutex{},
 	}
 }
 
@@ -26,79 +176,650 @@ type tradePeriodChangedMessage struct {
 	IsTradeStage bool `json:"isTradeStage"`
 }
 
-func (n *TeamsNotifier) NotifyTradePeriodChanged(isTrade bool) {
-	msg, _ := jsoniter.Marshal(tradePeriodChangedMessage{IsTradeStage: isTrade})
-
+func (n *TeamsNotifier) NotifyTradePeriodChanged(sessionID int64, isTrade bool) {
 	n.mx.Lock()
 	defer n.mx.Unlock()
 
 	n.log.Trace().
+		Int64("session_id", sessionID).
 		Bool("is_trade_period", isTrade).
-		Int("conns_count", len(n.conns)).
+		Int("conns_count", len(n.conns[sessionID])).
 		Msg("notify: trade period changed")
 
-	for conn := range n.conns {
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			n.RemoveConnection(conn)
-			_ = conn.Close()
-		}
-	}
+	n.publish(sessionID, eventTypeTradePeriodChanged, toAll(tradePeriodChangedMessage{IsTradeStage: isTrade}))
 }
 
 type roundPeriodChangedMessage struct {
 	IsRoundStage bool `json:"isRoundStage"`
 }
 
-func (n *TeamsNotifier) NotifyRoundPeriodChanged(isRound bool) {
-	msg, _ := jsoniter.Marshal(roundPeriodChangedMessage{IsRoundStage: isRound})
-
+func (n *TeamsNotifier) NotifyRoundPeriodChanged(sessionID int64, isRound bool) {
 	n.mx.Lock()
 	defer n.mx.Unlock()
 
 	n.log.Trace().
+		Int64("session_id", sessionID).
 		Bool("is_round_period", isRound).
-		Int("conns_count", len(n.conns)).
+		Int("conns_count", len(n.conns[sessionID])).
 		Msg("notify: round period changed")
 
-	for conn := range n.conns {
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			n.RemoveConnection(conn)
-			_ = conn.Close()
-		}
-	}
+	n.publish(sessionID, eventTypeRoundPeriodChanged, toAll(roundPeriodChangedMessage{IsRoundStage: isRound}))
 }
 
 type gameStateChangedMessage struct {
 	GameState models.GameState `json:"gameState"`
 }
 
-func (n *TeamsNotifier) NotifyGameStateChanged(state models.GameState) {
-	msg, _ := jsoniter.Marshal(gameStateChangedMessage{GameState: state})
-
+func (n *TeamsNotifier) NotifyGameStateChanged(sessionID int64, state models.GameState) {
 	n.mx.Lock()
 	defer n.mx.Unlock()
 
 	n.log.Trace().
+		Int64("session_id", sessionID).
 		Int("game_state", int(state)).
-		Int("conns_count", len(n.conns)).
+		Int("conns_count", len(n.conns[sessionID])).
 		Msg("notify: game state changed")
 
-	for conn := range n.conns {
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			n.RemoveConnection(conn)
-			_ = conn.Close()
+	n.publish(sessionID, eventTypeGameStateChanged, toAll(gameStateChangedMessage{GameState: state}))
+}
+
+type randomEventMessage struct {
+	TeamID      int64                        `json:"teamId"`
+	Round       int                          `json:"round"`
+	ID          int64                        `json:"id"`
+	Name        string                       `json:"name"`
+	Description string                       `json:"description"`
+	EffectType  models.RandomEventEffectType `json:"effectType"`
+	CompanyID   *int64                       `json:"companyId"`
+	Amount      int64                        `json:"amount"`
+}
+
+func (n *TeamsNotifier) NotifyRandomEvent(sessionID int64, event models.TeamRandomEvent) {
+	msg := randomEventMessage{
+		TeamID:      event.TeamID,
+		Round:       event.Round,
+		ID:          event.Event.ID,
+		Name:        event.Event.Name,
+		Description: event.Event.Description,
+		EffectType:  event.Event.EffectType,
+		CompanyID:   event.Event.CompanyID,
+		Amount:      event.Amount,
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int64("team_id", event.TeamID).
+		Int64("random_event_id", event.Event.ID).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: random event")
+
+	n.publish(sessionID, eventTypeRandomEvent, toTeam(event.TeamID, msg))
+}
+
+type marginCallMessage struct {
+	TeamID      int64           `json:"teamId"`
+	Round       int             `json:"round"`
+	Closed      map[int64]int64 `json:"closed"`
+	Amount      int64           `json:"amount"`
+	Capitalized int64           `json:"capitalized"`
+}
+
+func (n *TeamsNotifier) NotifyMarginCall(sessionID int64, marginCall models.MarginCall) {
+	msg := marginCallMessage{
+		TeamID:      marginCall.TeamID,
+		Round:       marginCall.Round,
+		Closed:      marginCall.Closed,
+		Amount:      marginCall.Amount,
+		Capitalized: marginCall.Capitalized,
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int64("team_id", marginCall.TeamID).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: margin call")
+
+	n.publish(sessionID, eventTypeMarginCall, toTeam(marginCall.TeamID, msg))
+}
+
+type dividendsPaidMessage struct {
+	Round   int                         `json:"round"`
+	Payouts []dividendPayoutMessageItem `json:"payouts"`
+}
+
+type dividendPayoutMessageItem struct {
+	TeamID      int64           `json:"teamId"`
+	Shares      map[int64]int64 `json:"shares"`
+	Amount      int64           `json:"amount"`
+	Capitalized int64           `json:"capitalized"`
+}
+
+func (n *TeamsNotifier) NotifyDividendsPaid(sessionID int64, round int, payouts []models.DividendPayout) {
+	to := toEachTeam(
+		payouts,
+		func(item models.DividendPayout) int64 { return item.TeamID },
+		func(payouts []models.DividendPayout) any {
+			return dividendsPaidMessage{
+				Round: round,
+				Payouts: lo.Map(payouts, func(item models.DividendPayout, _ int) dividendPayoutMessageItem {
+					return dividendPayoutMessageItem{
+						TeamID:      item.TeamID,
+						Shares:      item.Shares,
+						Amount:      item.Amount,
+						Capitalized: item.Capitalized,
+					}
+				}),
+			}
+		},
+	)
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int("round", round).
+		Int("payouts_count", len(payouts)).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: dividends paid")
+
+	n.publish(sessionID, eventTypeDividendsPaid, to)
+}
+
+type bondPaymentsMessage struct {
+	Round    int                      `json:"round"`
+	Payments []bondPaymentMessageItem `json:"payments"`
+}
+
+type bondPaymentMessageItem struct {
+	TeamID     int64 `json:"teamId"`
+	TeamBondID int64 `json:"teamBondId"`
+	Coupon     int64 `json:"coupon"`
+	Principal  int64 `json:"principal"`
+}
+
+func (n *TeamsNotifier) NotifyBondPayments(sessionID int64, round int, payments []models.BondPayment) {
+	to := toEachTeam(
+		payments,
+		func(item models.BondPayment) int64 { return item.TeamID },
+		func(payments []models.BondPayment) any {
+			return bondPaymentsMessage{
+				Round: round,
+				Payments: lo.Map(payments, func(item models.BondPayment, _ int) bondPaymentMessageItem {
+					return bondPaymentMessageItem{
+						TeamID:     item.TeamID,
+						TeamBondID: item.TeamBondID,
+						Coupon:     item.Coupon,
+						Principal:  item.Principal,
+					}
+				}),
+			}
+		},
+	)
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int("round", round).
+		Int("payments_count", len(payments)).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: bond payments")
+
+	n.publish(sessionID, eventTypeBondPayments, to)
+}
+
+type loanInterestMessage struct {
+	Round     int                       `json:"round"`
+	Interests []loanInterestMessageItem `json:"interests"`
+}
+
+type loanInterestMessageItem struct {
+	TeamID      int64 `json:"teamId"`
+	Amount      int64 `json:"amount"`
+	Capitalized int64 `json:"capitalized"`
+}
+
+func (n *TeamsNotifier) NotifyLoanInterestCharged(sessionID int64, round int, interests []models.LoanInterest) {
+	to := toEachTeam(
+		interests,
+		func(item models.LoanInterest) int64 { return item.TeamID },
+		func(interests []models.LoanInterest) any {
+			return loanInterestMessage{
+				Round: round,
+				Interests: lo.Map(interests, func(item models.LoanInterest, _ int) loanInterestMessageItem {
+					return loanInterestMessageItem{
+						TeamID:      item.TeamID,
+						Amount:      item.Amount,
+						Capitalized: item.Capitalized,
+					}
+				}),
+			}
+		},
+	)
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int("round", round).
+		Int("interests_count", len(interests)).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: loan interest charged")
+
+	n.publish(sessionID, eventTypeLoanInterestCharged, to)
+}
+
+type balanceChangedMessage struct {
+	TeamID     int64 `json:"teamId"`
+	Amount     int64 `json:"amount"`
+	Loan       int64 `json:"loan"`
+	Collateral int64 `json:"collateral"`
+}
+
+func (n *TeamsNotifier) NotifyBalanceChanged(sessionID int64, teamID int64, balance models.Balance) {
+	msg := balanceChangedMessage{
+		TeamID:     teamID,
+		Amount:     balance.Amount,
+		Loan:       balance.Loan,
+		Collateral: balance.Collateral,
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int64("team_id", teamID).
+		Int64("amount", balance.Amount).
+		Msg("notify: balance changed")
+
+	n.publish(sessionID, eventTypeBalanceChanged, toTeam(teamID, msg))
+}
+
+type additionalInfoPurchasedMessage struct {
+	TeamID      int64                     `json:"teamId"`
+	ID          int64                     `json:"id"`
+	Name        string                    `json:"name"`
+	Type        models.AdditionalInfoType `json:"type"`
+	CompanyID   *int64                    `json:"companyId"`
+	Description string                    `json:"description"`
+	Cost        int64                     `json:"cost"`
+}
+
+func (n *TeamsNotifier) NotifyAdditionalInfoPurchased(sessionID int64, teamID int64, info models.AdditionalInfo) {
+	msg := additionalInfoPurchasedMessage{
+		TeamID:      teamID,
+		ID:          info.ID,
+		Name:        info.Name,
+		Type:        info.Type,
+		CompanyID:   info.CompanyID,
+		Description: info.Description,
+		Cost:        info.Cost,
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int64("team_id", teamID).
+		Int64("additional_info_id", info.ID).
+		Msg("notify: additional info purchased")
+
+	n.publish(sessionID, eventTypeAdditionalInfoPurchased, toTeam(teamID, msg))
+}
+
+type autopilotStateChangedMessage struct {
+	Phase     models.AutopilotPhase `json:"phase"`
+	Round     int                   `json:"round"`
+	Remaining string                `json:"remaining"`
+	IsPaused  bool                  `json:"isPaused"`
+}
+
+func (n *TeamsNotifier) NotifyAutopilotStateChanged(sessionID int64, state models.AutopilotState) {
+	msg := autopilotStateChangedMessage{
+		Phase:     state.Phase,
+		Round:     state.Round,
+		Remaining: state.Remaining.String(),
+		IsPaused:  state.IsPaused,
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int("phase", int(state.Phase)).
+		Int("round", state.Round).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: autopilot state changed")
+
+	n.publish(sessionID, eventTypeAutopilotStateChanged, toAll(msg))
+}
+
+type autopilotInterruptedMessage struct {
+	Round int `json:"round"`
+}
+
+// NotifyAutopilotInterrupted сообщает администраторам, что автопилот сессии прерван перезапуском
+// сервера и сессия ведётся вручную.
+func (n *TeamsNotifier) NotifyAutopilotInterrupted(sessionID int64, round int) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int("round", round).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: autopilot interrupted")
+
+	n.publish(sessionID, eventTypeAutopilotInterrupted, recipients{admins: autopilotInterruptedMessage{Round: round}})
+}
+
+type gamePauseChangedMessage struct {
+	IsPaused           bool   `json:"isPaused"`
+	TradeTimeRemaining string `json:"tradeTimeRemaining"`
+}
+
+func (n *TeamsNotifier) NotifyGamePauseChanged(sessionID int64, isPaused bool, tradeTimeRemaining time.Duration) {
+	msg := gamePauseChangedMessage{
+		IsPaused:           isPaused,
+		TradeTimeRemaining: tradeTimeRemaining.String(),
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Bool("is_paused", isPaused).
+		Dur("trade_time_remaining", tradeTimeRemaining).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: game pause changed")
+
+	n.publish(sessionID, eventTypeGamePauseChanged, toAll(msg))
+}
+
+type orderBookChangedMessage struct {
+	CompanyID int64                          `json:"companyId"`
+	Bids      []orderBookLevelMessagePayload `json:"bids"`
+	Asks      []orderBookLevelMessagePayload `json:"asks"`
+}
+
+type orderBookLevelMessagePayload struct {
+	Price int64 `json:"price"`
+	Count int64 `json:"count"`
+}
+
+func (n *TeamsNotifier) NotifyOrderBookChanged(sessionID int64, book models.OrderBook) {
+	toPayload := func(item models.OrderBookLevel, _ int) orderBookLevelMessagePayload {
+		return orderBookLevelMessagePayload{
+			Price: item.Price,
+			Count: item.Count,
+		}
+	}
+	msg := orderBookChangedMessage{
+		CompanyID: book.CompanyID,
+		Bids:      lo.Map(book.Bids, toPayload),
+		Asks:      lo.Map(book.Asks, toPayload),
+	}
+
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Int64("company_id", book.CompanyID).
+		Int("conns_count", len(n.conns[sessionID])).
+		Msg("notify: order book changed")
+
+	n.publish(sessionID, eventTypeOrderBookChanged, toAll(msg))
+}
+
+// sessionEventsFor должен вызываться под n.mx.
+func (n *TeamsNotifier) sessionEventsFor(sessionID int64) *sessionEvents {
+	events, ok := n.events[sessionID]
+	if !ok {
+		events = &sessionEvents{}
+		n.events[sessionID] = events
+	}
+	return events
+}
+
+// publish присваивает событию следующий seq сессии, сохраняет его в буфер и рассылает
+// подписчикам. Должен вызываться под n.mx.
+func (n *TeamsNotifier) publish(sessionID int64, eventType eventType, to recipients) {
+	events := n.sessionEventsFor(sessionID)
+	events.seq++
+
+	ts := time.Now()
+	marshal := func(payload any) []byte {
+		msg, _ := jsoniter.Marshal(envelope{
+			Version: envelopeVersion,
+			Epoch:   n.epoch,
+			Type:    eventType,
+			Seq:     events.seq,
+			TS:      ts,
+			Payload: payload,
+		})
+		return msg
+	}
+	e := event{seq: events.seq}
+	if to.all != nil {
+		e.all = marshal(to.all)
+	} else {
+		e.admins = marshal(to.admins)
+		e.teams = lo.MapValues(to.teams, func(payload any, _ int64) []byte {
+			return marshal(payload)
+		})
+	}
+	events.buffer[e.seq%eventsBufferSize] = e
+
+	for _, c := range n.conns[sessionID] {
+		n.enqueue(sessionID, c, e.messageFor(c.sub))
+	}
+}
+
+// connection — подписанное соединение. Сообщения пишет в него отдельная горутина writeLoop
+// из очереди send, поэтому рассылка под n.mx не ждёт медленных клиентов.
+type connection struct {
+	conn *websocket.Conn
+	sub  Subscriber
+	send chan []byte
+	// expiry закрывает соединение по истечении access-токена, останавливается при отключении.
+	expiry *time.Timer
+	// done закрывается один раз через stop, reason — причина закрытия для клиента, пуста, если
+	// соединение закрыл сам клиент или его не удалось записать.
+	done     chan struct{}
+	reason   string
+	stopOnce sync.Once
+}
+
+func (c *connection) stop(reason string) {
+	c.stopOnce.Do(func() {
+		c.reason = reason
+		if c.expiry != nil {
+			c.expiry.Stop()
+		}
+		close(c.done)
+	})
+}
+
+// enqueue ставит msg в очередь соединения. Пустые сообщения пропускаются. Клиент, очередь которого
+// переполнена, не успевает читать события и отключается: при переподключении он получит пропущенное
+// из буфера сессии или resyncRequired. Должен вызываться под n.mx.
+func (n *TeamsNotifier) enqueue(sessionID int64, c *connection, msg []byte) {
+	if msg == nil {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		n.log.Debug().Int64("session_id", sessionID).Msg("notify: close connection with full send queue")
+		n.close(sessionID, c, "")
+	}
+}
+
+// writeLoop отправляет сообщения из очереди соединения до его закрытия. Соединение, запись в которое
+// завершилась ошибкой или не успела за wsWriteTimeout, отписывается и закрывается.
+func (n *TeamsNotifier) writeLoop(sessionID int64, c *connection) {
+	for {
+		select {
+		case <-c.done:
+			if c.reason != "" {
+				_ = c.conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, c.reason),
+					time.Now().Add(wsWriteTimeout),
+				)
+			}
+			_ = c.conn.Close()
+			return
+		case msg := <-c.send:
+			err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err == nil {
+				err = c.conn.WriteMessage(websocket.TextMessage, msg)
+			}
+			if err != nil {
+				n.log.Debug().Err(err).Int64("session_id", sessionID).Msg("notify: close connection after write error")
+				n.mx.Lock()
+				n.close(sessionID, c, "")
+				n.mx.Unlock()
+			}
 		}
 	}
 }
 
-func (n *TeamsNotifier) RegisterConnection(conn *websocket.Conn) {
+// close отписывает соединение и передаёт его закрытие горутине writeLoop, которая сообщит клиенту
+// непустую причину reason. Должен вызываться под n.mx.
+func (n *TeamsNotifier) close(sessionID int64, c *connection, reason string) {
+	if n.conns[sessionID][c.conn] == c {
+		delete(n.conns[sessionID], c.conn)
+	}
+	c.stop(reason)
+}
+
+// CloseAuthSessions закрывает соединения, открытые по токенам отозванных сессий входа:
+// после выхода или исключения команды события перестают приходить сразу, а не по истечении токена.
+func (n *TeamsNotifier) CloseAuthSessions(authSessionIDs []int64) {
 	n.mx.Lock()
 	defer n.mx.Unlock()
-	n.conns[conn] = struct{}{}
+
+	for sessionID, conns := range n.conns {
+		for _, c := range conns {
+			if slices.Contains(authSessionIDs, c.sub.AuthSessionID) {
+				n.log.Trace().
+					Int64("session_id", sessionID).
+					Int64("auth_session_id", c.sub.AuthSessionID).
+					Msg("notify: close connection of revoked auth session")
+				n.close(sessionID, c, "session revoked")
+			}
+		}
+	}
+}
+
+// closeExpired закрывает соединение, если оно ещё подписано: клиент должен переподключиться
+// с новым access-токеном.
+func (n *TeamsNotifier) closeExpired(sessionID int64, c *connection) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if n.conns[sessionID][c.conn] != c {
+		return
+	}
+	n.close(sessionID, c, "token expired")
 }
 
-func (n *TeamsNotifier) RemoveConnection(conn *websocket.Conn) {
+// RegisterConnection подписывает соединение на события сессии. Если задан resumeFrom, клиенту
+// сначала отправляются пропущенные события с seq > resumeFrom.LastSeq, а если их уже нет в буфере
+// или эпоха клиента не совпадает с текущей — событие resyncRequired. Пропущенные события ставятся
+// в очередь соединения под той же блокировкой, что и регистрация, поэтому новые события не теряются
+// и не дублируются. В sub.ExpiresAt соединение закрывается.
+func (n *TeamsNotifier) RegisterConnection(
+	sessionID int64,
+	sub Subscriber,
+	conn *websocket.Conn,
+	resumeFrom *ResumePoint,
+) {
 	n.mx.Lock()
 	defer n.mx.Unlock()
-	delete(n.conns, conn)
+
+	conns, ok := n.conns[sessionID]
+	if !ok {
+		conns = make(map[*websocket.Conn]*connection)
+		n.conns[sessionID] = conns
+	}
+	c := &connection{
+		conn: conn,
+		sub:  sub,
+		send: make(chan []byte, connSendQueueSize),
+		done: make(chan struct{}),
+	}
+	conns[conn] = c
+	if !sub.ExpiresAt.IsZero() {
+		c.expiry = time.AfterFunc(time.Until(sub.ExpiresAt), func() {
+			n.closeExpired(sessionID, c)
+		})
+	}
+	go n.writeLoop(sessionID, c)
+
+	if resumeFrom != nil {
+		n.resume(sessionID, c, *resumeFrom)
+	}
+}
+
+// resume должен вызываться под n.mx.
+func (n *TeamsNotifier) resume(sessionID int64, c *connection, from ResumePoint) {
+	events := n.sessionEventsFor(sessionID)
+	var (
+		missed []event
+		ok     bool
+	)
+	if from.Epoch == n.epoch {
+		missed, ok = events.since(from.LastSeq)
+	}
+
+	n.log.Trace().
+		Int64("session_id", sessionID).
+		Str("epoch", from.Epoch).
+		Int64("last_seq", from.LastSeq).
+		Int64("seq", events.seq).
+		Bool("resync_required", !ok).
+		Int("missed_count", len(missed)).
+		Msg("notify: resume connection")
+
+	if !ok {
+		msg, _ := jsoniter.Marshal(envelope{
+			Version: envelopeVersion,
+			Epoch:   n.epoch,
+			Type:    eventTypeResyncRequired,
+			Seq:     events.seq,
+			TS:      time.Now(),
+		})
+		n.enqueue(sessionID, c, msg)
+		return
+	}
+	for _, e := range missed {
+		n.enqueue(sessionID, c, e.messageFor(c.sub))
+	}
+}
+
+// RemoveConnection отписывает соединение, закрытое клиентом, и останавливает его горутину записи
+// и таймер истечения токена.
+func (n *TeamsNotifier) RemoveConnection(sessionID int64, conn *websocket.Conn) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	if c, ok := n.conns[sessionID][conn]; ok {
+		n.close(sessionID, c, "")
+	}
 }
@@ -0,0 +1,95 @@
+package games
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSessionEventsSince(t *testing.T) {
+	tests := []struct {
+		name      string
+		published int64
+		lastSeq   int64
+		wantSeqs  []int64
+		wantOK    bool
+	}{
+		{
+			name:      "nothing published",
+			published: 0,
+			lastSeq:   0,
+			wantSeqs:  []int64{},
+			wantOK:    true,
+		},
+		{
+			name:      "up to date",
+			published: 5,
+			lastSeq:   5,
+			wantSeqs:  []int64{},
+			wantOK:    true,
+		},
+		{
+			name:      "missed events",
+			published: 5,
+			lastSeq:   2,
+			wantSeqs:  []int64{3, 4, 5},
+			wantOK:    true,
+		},
+		{
+			name:      "buffer wrapped, oldest still kept",
+			published: eventsBufferSize + 10,
+			lastSeq:   10,
+			wantSeqs:  seqRange(11, eventsBufferSize+10),
+			wantOK:    true,
+		},
+		{
+			name:      "buffer wrapped, events evicted",
+			published: eventsBufferSize + 10,
+			lastSeq:   9,
+			wantOK:    false,
+		},
+		{
+			name:      "seq from the future",
+			published: 5,
+			lastSeq:   6,
+			wantOK:    false,
+		},
+		{
+			name:      "negative seq",
+			published: 5,
+			lastSeq:   -1,
+			wantOK:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &sessionEvents{}
+			for range tt.published {
+				events.seq++
+				events.buffer[events.seq%eventsBufferSize] = event{seq: events.seq}
+			}
+
+			got, ok := events.since(tt.lastSeq)
+			if ok != tt.wantOK {
+				t.Fatalf("since() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			seqs := make([]int64, 0, len(got))
+			for _, item := range got {
+				seqs = append(seqs, item.seq)
+			}
+			if !reflect.DeepEqual(seqs, tt.wantSeqs) {
+				t.Errorf("since() seqs = %v, want %v", seqs, tt.wantSeqs)
+			}
+		})
+	}
+}
+
+func seqRange(from, to int64) []int64 {
+	seqs := make([]int64, 0, to-from+1)
+	for seq := from; seq <= to; seq++ {
+		seqs = append(seqs, seq)
+	}
+	return seqs
+}
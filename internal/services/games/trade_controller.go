@@ -6,22 +6,22 @@ import (
 )
 
 type TradeController struct {
-	notify    []func(bool)
-	period    time.Duration
-	isStarted bool
-	mx        sync.Mutex
-	stop      chan struct{}
+	notify  []func(bool)
+	period  time.Duration
+	current *countdown
+	mx      sync.Mutex
 }
 
 func NewTradeController(period time.Duration) *TradeController {
 	return &TradeController{
 		period: period,
 		mx:     sync.Mutex{},
-		stop:   make(chan struct{}),
 	}
 }
 
 func (t *TradeController) SetPeriod(period time.Duration) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
 	t.period = period
 }
 
@@ -29,38 +29,112 @@ func (t *TradeController) RegisterNotify(f func(bool)) {
 	t.notify = append(t.notify, f)
 }
 
-func (t *TradeController) StartTradePeriod() {
+// BeginTradePeriod запускает период торгов и возвращает функцию, блокирующуюся до его окончания.
+// К моменту возврата отсчёт уже идёт, поэтому следующий StopTradePeriod гарантированно
+// завершит именно этот период. wait возвращает false, если период был заменён следующим:
+// тогда торги не закрываются, и вызывающий не должен сохранять их окончание.
+func (t *TradeController) BeginTradePeriod() (wait func() bool) {
+	t.mx.Lock()
+	period := t.period
+	t.mx.Unlock()
+	return t.beginTradePeriod(period, false)
+}
+
+// RestoreTradePeriod продолжает период торгов, прерванный перезапуском сервера,
+// с оставшимся временем remaining. При paused отсчёт сразу приостанавливается.
+func (t *TradeController) RestoreTradePeriod(remaining time.Duration, paused bool) (wait func() bool) {
+	return t.beginTradePeriod(remaining, paused)
+}
+
+func (t *TradeController) beginTradePeriod(d time.Duration, paused bool) func() bool {
 	for _, fn := range t.notify {
 		fn(true)
 	}
 	t.mx.Lock()
-	t.isStarted = true
+	if t.current != nil {
+		t.current.Stop()
+	}
+	current := newCountdown(d)
+	if paused {
+		current.Pause()
+	}
+	t.current = current
 	t.mx.Unlock()
-	select {
-	case <-t.stop:
-	case <-time.After(t.period):
+
+	return func() bool {
+		<-current.Done()
+
+		t.mx.Lock()
+		ended := t.current == current
+		if ended {
+			t.current = nil
+		}
+		t.mx.Unlock()
+		if !ended {
+			return false
+		}
+		for _, fn := range t.notify {
+			fn(false)
+		}
+		return true
 	}
+}
+
+// IsActive сообщает, идёт ли период торгов: отсчёт запущен и ещё не завершён.
+func (t *TradeController) IsActive() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.current != nil && !t.current.IsFinished()
+}
+
+func (t *TradeController) StopTradePeriod() {
 	t.mx.Lock()
-	t.isStarted = false
+	current := t.current
 	t.mx.Unlock()
+
+	if current != nil {
+		current.Stop()
+		return
+	}
 	for _, fn := range t.notify {
 		fn(false)
 	}
+}
 
+// Pause приостанавливает отсчёт текущего периода торгов. Возвращает false, если торги не идут.
+func (t *TradeController) Pause() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.current != nil && t.current.Pause()
 }
 
-func (t *TradeController) StopTradePeriod() {
+func (t *TradeController) Resume() bool {
 	t.mx.Lock()
-	if t.isStarted {
-		select {
-		case t.stop <- struct{}{}:
-		case <-time.After(time.Second):
-		}
-		t.mx.Unlock()
-	} else {
-		t.mx.Unlock()
-		for _, fn := range t.notify {
-			fn(false)
-		}
+	defer t.mx.Unlock()
+	return t.current != nil && t.current.Resume()
+}
+
+func (t *TradeController) Extend(d time.Duration) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.current == nil {
+		return false
 	}
+	t.current.Extend(d)
+	return true
+}
+
+func (t *TradeController) Remaining() time.Duration {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.current == nil {
+		return 0
+	}
+	return t.current.Remaining()
+}
+
+func (t *TradeController) IsPaused() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.current != nil && t.current.IsPaused()
 }
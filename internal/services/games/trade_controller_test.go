@@ -0,0 +1,36 @@
+package games
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTradeControllerReplacedPeriod(t *testing.T) {
+	ctrl := NewTradeController(time.Hour)
+	var notified []bool
+	ctrl.RegisterNotify(func(isTrade bool) {
+		notified = append(notified, isTrade)
+	})
+
+	waitFirst := ctrl.BeginTradePeriod()
+	waitSecond := ctrl.BeginTradePeriod()
+	if waitFirst() {
+		t.Errorf("replaced period wait() = true, want false")
+	}
+	if !ctrl.IsActive() {
+		t.Errorf("IsActive() = false while second period runs")
+	}
+
+	ctrl.StopTradePeriod()
+	if !waitSecond() {
+		t.Errorf("current period wait() = false, want true")
+	}
+	if ctrl.IsActive() {
+		t.Errorf("IsActive() = true after stop")
+	}
+
+	if want := []bool{true, true, false}; !reflect.DeepEqual(notified, want) {
+		t.Errorf("notified = %v, want %v", notified, want)
+	}
+}
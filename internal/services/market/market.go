@@ -0,0 +1,488 @@
+package market
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"investment-game-backend/internal/services/teams"
+	"maps"
+	"sync"
+)
+
+// Проверки торгового периода, паузы, заморозки, баланса и лимита шортов возвращают ошибки
+// сервиса teams, чтобы покупки и заявки отклонялись одинаково.
+var (
+	ErrInvalidOrder  = errors.New("invalid order params")
+	ErrSelfTrade     = errors.New("order would trade against own order")
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotOpen  = errors.New("order is not open")
+)
+
+// Service сводит лимитные заявки команд друг с другом. Стаканы хранятся в памяти по сессиям и компаниям,
+// заявки сохраняются в репозиторий, а сделки проводятся через settleFill.
+type Service struct {
+	repo         repo.MarketOrdersRepo
+	teamsRepo    repo.TeamsRepo
+	balancesRepo repo.BalancesRepo
+	settingsRepo repo.SettingsRepo
+	gamesRepo    repo.GamesRepo
+	txManager    repo.TxManager
+	// checkTradingNotFrozen — проверка заморозки торговли случайным событием из сервиса teams.
+	checkTradingNotFrozen func(ctx context.Context, team *models.Team) error
+	// settleFill проводит сделку в транзакции из ctx, notifyBalanceChanged сообщает командам
+	// их баланс после её фиксации.
+	settleFill           func(ctx context.Context, fill models.MarketFill) error
+	notifyBalanceChanged func(ctx context.Context, teamIDs ...int64)
+	notifyOrderBook      func(sessionID int64, book models.OrderBook)
+	log                  *zerolog.Logger
+	// books: ключ - ID сессии, значение - стаканы по ID компании.
+	books         map[int64]map[int64]*orderBook
+	isTradePeriod map[int64]bool
+	// sessionLocks: ключ - ID сессии. Блокировка сессии сериализует сведение заявок и работу
+	// с её стаканами, сессии друг друга не ждут.
+	sessionLocks map[int64]*sync.Mutex
+	// mx защищает только сами карты books, isTradePeriod и sessionLocks.
+	mx sync.Mutex
+}
+
+func New(
+	repo repo.MarketOrdersRepo,
+	teamsRepo repo.TeamsRepo,
+	balancesRepo repo.BalancesRepo,
+	settingsRepo repo.SettingsRepo,
+	gamesRepo repo.GamesRepo,
+	txManager repo.TxManager,
+	checkTradingNotFrozen func(ctx context.Context, team *models.Team) error,
+	settleFill func(ctx context.Context, fill models.MarketFill) error,
+	notifyBalanceChanged func(ctx context.Context, teamIDs ...int64),
+	notifyOrderBook func(sessionID int64, book models.OrderBook),
+	log *zerolog.Logger,
+) *Service {
+	return &Service{
+		repo:                  repo,
+		teamsRepo:             teamsRepo,
+		balancesRepo:          balancesRepo,
+		settingsRepo:          settingsRepo,
+		gamesRepo:             gamesRepo,
+		txManager:             txManager,
+		checkTradingNotFrozen: checkTradingNotFrozen,
+		settleFill:            settleFill,
+		notifyBalanceChanged:  notifyBalanceChanged,
+		notifyOrderBook:       notifyOrderBook,
+		log:                   log,
+		books:                 make(map[int64]map[int64]*orderBook),
+		isTradePeriod:         make(map[int64]bool),
+		sessionLocks:          make(map[int64]*sync.Mutex),
+		mx:                    sync.Mutex{},
+	}
+}
+
+type PlaceOrderParams struct {
+	TeamID    int64
+	CompanyID int64
+	Side      models.OrderSide
+	Price     int64
+	Count     int64
+	// MemberID — участник, выставляющий заявку, пуст при входе общим паролем команды.
+	MemberID *int64
+}
+
+func (params PlaceOrderParams) Validate() error {
+	if params.Side != models.OrderSideBuy && params.Side != models.OrderSideSell {
+		return fmt.Errorf("%w: unknown side %d", ErrInvalidOrder, params.Side)
+	}
+	if params.Price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidOrder)
+	}
+	if params.Count <= 0 {
+		return fmt.Errorf("%w: count must be positive", ErrInvalidOrder)
+	}
+	return nil
+}
+
+// PlaceOrder выставляет заявку и сразу исполняет её против встречных заявок стакана.
+// Неисполненный остаток остаётся в стакане до конца периода торгов.
+func (s *Service) PlaceOrder(ctx context.Context, params PlaceOrderParams) (*models.MarketOrder, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	team, err := s.teamsRepo.GetByID(ctx, params.TeamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return nil, teams.ErrGamePaused
+	}
+	if err = s.checkTradingNotFrozen(ctx, team); err != nil {
+		return nil, fmt.Errorf("s.checkTradingNotFrozen: %w", err)
+	}
+
+	unlock := s.lockSession(team.SessionID)
+	defer unlock()
+
+	if !s.isTrade(team.SessionID) {
+		s.log.Debug().Int64("session_id", team.SessionID).Msg("cannot place order because is not trade period")
+		return nil, teams.ErrIsNoTradePeriod
+	}
+	if err = s.checkCanPlace(ctx, team, params); err != nil {
+		return nil, fmt.Errorf("s.checkCanPlace: %w", err)
+	}
+
+	order := &models.MarketOrder{
+		SessionID: team.SessionID,
+		TeamID:    team.ID,
+		MemberID:  params.MemberID,
+		CompanyID: params.CompanyID,
+		Side:      params.Side,
+		Round:     game.CurrentRound,
+		Price:     params.Price,
+		Count:     params.Count,
+		Filled:    0,
+		Status:    models.OrderStatusOpen,
+	}
+	book := s.getBook(order.SessionID, order.CompanyID)
+	if book.crossesOwn(order) {
+		return nil, ErrSelfTrade
+	}
+
+	if order.ID, err = s.repo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("s.repo.Create: %w", err)
+	}
+	s.log.Trace().
+		Int64("order_id", order.ID).
+		Int64("team_id", order.TeamID).
+		Int64("company_id", order.CompanyID).
+		Msg("order placed")
+
+	err = s.match(ctx, book, order)
+	if order.Status == models.OrderStatusOpen {
+		book.add(order)
+	}
+	s.notifyOrderBook(order.SessionID, book.depth(order.CompanyID))
+	if err != nil {
+		return nil, fmt.Errorf("s.match: %w", err)
+	}
+
+	return order, nil
+}
+
+// checkCanPlace проверяет новую заявку вместе с уже стоящими в стаканах заявками команды той же стороны:
+// на покупки должно хватать свободных средств, продажи не должны превышать лимит шортов.
+// Должен вызываться под блокировкой сессии.
+func (s *Service) checkCanPlace(ctx context.Context, team *models.Team, params PlaceOrderParams) error {
+	if params.Side == models.OrderSideBuy {
+		balance, err := s.balancesRepo.GetByID(ctx, team.BalanceID)
+		if err != nil {
+			return fmt.Errorf("s.balancesRepo.GetByID: %w", err)
+		}
+		if balance.Available() < s.openBidsCost(team.SessionID, team.ID)+params.Price*params.Count {
+			return teams.ErrNoMoneyForOperation
+		}
+		return nil
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	shares := make(models.TeamSharesState, len(team.Shares))
+	maps.Copy(shares, team.Shares)
+	openAsks, _ := s.getBook(team.SessionID, params.CompanyID).teamOpen(models.OrderSideSell, team.ID)
+	shares.ApplyChanges(map[int64]int64{params.CompanyID: -openAsks - params.Count})
+
+	if err = shares.ValidateShortLimit(settings.ShortSellingLimit); err != nil {
+		if errors.Is(err, models.ErrSharesCountCannotBeNegative) {
+			return teams.ErrIncorrectCountOfShares
+		}
+		if errors.Is(err, models.ErrShortLimitExceeded) {
+			return teams.ErrShortLimitExceeded
+		}
+		return fmt.Errorf("shares.ValidateShortLimit: %w", err)
+	}
+	return nil
+}
+
+// match исполняет заявку order против стакана book, должен вызываться под блокировкой сессии.
+// Встречная заявка, сторона которой не может провести сделку, снимается, и сведение продолжается.
+func (s *Service) match(ctx context.Context, book *orderBook, order *models.MarketOrder) error {
+	for order.Remaining() > 0 {
+		resting := book.best(oppositeSide(order.Side))
+		if resting == nil || !crosses(order, resting) {
+			return nil
+		}
+
+		fill := newFill(order, resting)
+		err := s.settle(ctx, fill, resting, order)
+		if errors.Is(err, models.ErrBuyerCannotSettle) || errors.Is(err, models.ErrSellerCannotSettle) {
+			rejected := order
+			if errors.Is(err, models.ErrBuyerCannotSettle) == (resting.Side == models.OrderSideBuy) {
+				rejected = resting
+			}
+			s.log.Debug().Err(err).Int64("order_id", rejected.ID).Msg("cancel order that cannot be settled")
+
+			if err = s.cancel(ctx, book, rejected); err != nil {
+				return fmt.Errorf("s.cancel: %w", err)
+			}
+			if rejected == order {
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("s.settle: %w", err)
+		}
+
+		s.log.Trace().
+			Int64("buy_order_id", fill.BuyOrderID).
+			Int64("sell_order_id", fill.SellOrderID).
+			Int64("price", fill.Price).
+			Int64("count", fill.Count).
+			Msg("orders matched")
+
+		resting.Fill(fill.Count)
+		order.Fill(fill.Count)
+		if resting.Status == models.OrderStatusFilled {
+			book.remove(resting)
+		}
+		s.notifyBalanceChanged(ctx, fill.BuyerTeamID, fill.SellerTeamID)
+	}
+	return nil
+}
+
+// settle проводит сделку и сохраняет исполнение обеих заявок в одной транзакции, повторяя её
+// при конфликте версий. Заявки в памяти не меняются: это делает вызывающий после успешной фиксации.
+func (s *Service) settle(ctx context.Context, fill models.MarketFill, orders ...*models.MarketOrder) error {
+	return repo.WithinTxRetry(ctx, s.txManager, func(ctx context.Context) error {
+		if err := s.settleFill(ctx, fill); err != nil {
+			return fmt.Errorf("s.settleFill: %w", err)
+		}
+		for _, order := range orders {
+			filled := *order
+			filled.Fill(fill.Count)
+			if err := s.repo.Update(ctx, &filled); err != nil {
+				return fmt.Errorf("s.repo.Update: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func newFill(order, resting *models.MarketOrder) models.MarketFill {
+	buy, sell := order, resting
+	if order.Side == models.OrderSideSell {
+		buy, sell = resting, order
+	}
+	return models.MarketFill{
+		BuyOrderID:     buy.ID,
+		SellOrderID:    sell.ID,
+		BuyerTeamID:    buy.TeamID,
+		SellerTeamID:   sell.TeamID,
+		BuyerMemberID:  buy.MemberID,
+		SellerMemberID: sell.MemberID,
+		CompanyID:      order.CompanyID,
+		Round:          order.Round,
+		Price:          resting.Price,
+		Count:          min(order.Remaining(), resting.Remaining()),
+	}
+}
+
+// cancel снимает заявку, должен вызываться под блокировкой сессии.
+func (s *Service) cancel(ctx context.Context, book *orderBook, order *models.MarketOrder) error {
+	order.Status = models.OrderStatusCancelled
+	if err := s.repo.Update(ctx, order); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	book.remove(order)
+	return nil
+}
+
+// CancelOrder снимает открытую заявку команды teamID.
+func (s *Service) CancelOrder(ctx context.Context, teamID int64, orderID int64) error {
+	order, err := s.getTeamOrder(ctx, teamID, orderID)
+	if err != nil {
+		return fmt.Errorf("s.getTeamOrder: %w", err)
+	}
+
+	unlock := s.lockSession(order.SessionID)
+	defer unlock()
+
+	// заявка перечитывается под блокировкой: до её взятия заявку могли исполнить
+	if order, err = s.getTeamOrder(ctx, teamID, orderID); err != nil {
+		return fmt.Errorf("s.getTeamOrder: %w", err)
+	}
+	if order.Status != models.OrderStatusOpen {
+		return ErrOrderNotOpen
+	}
+
+	book := s.getBook(order.SessionID, order.CompanyID)
+	if err = s.cancel(ctx, book, order); err != nil {
+		return fmt.Errorf("s.cancel: %w", err)
+	}
+	s.notifyOrderBook(order.SessionID, book.depth(order.CompanyID))
+	return nil
+}
+
+func (s *Service) getTeamOrder(ctx context.Context, teamID int64, orderID int64) (*models.MarketOrder, error) {
+	order, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+	if order.TeamID != teamID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// GetOrderBook возвращает глубину стакана компании в сессии.
+func (s *Service) GetOrderBook(_ context.Context, sessionID int64, companyID int64) models.OrderBook {
+	unlock := s.lockSession(sessionID)
+	defer unlock()
+	return s.getBook(sessionID, companyID).depth(companyID)
+}
+
+// GetTeamOrders возвращает заявки команды за текущий раунд.
+func (s *Service) GetTeamOrders(ctx context.Context, teamID int64) ([]models.MarketOrder, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	orders, err := s.repo.GetByTeamIDAndRound(ctx, team.ID, game.CurrentRound)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetByTeamIDAndRound: %w", err)
+	}
+	return orders, nil
+}
+
+// NotifyTradePeriodUpdated снимает все открытые заявки сессии по окончании периода торгов.
+func (s *Service) NotifyTradePeriodUpdated(sessionID int64, isTrade bool) {
+	s.log.Trace().
+		Int64("session_id", sessionID).
+		Bool("is_trade", isTrade).
+		Msg("market service: NotifyTradePeriodUpdated")
+
+	unlock := s.lockSession(sessionID)
+	defer unlock()
+
+	s.mx.Lock()
+	s.isTradePeriod[sessionID] = isTrade
+	s.mx.Unlock()
+	if isTrade {
+		return
+	}
+
+	if err := s.repo.CancelOpenBySessionID(context.Background(), sessionID); err != nil {
+		s.log.Error().Err(err).Int64("session_id", sessionID).Msg("market service: s.repo.CancelOpenBySessionID")
+		return
+	}
+	s.mx.Lock()
+	books := s.books[sessionID]
+	delete(s.books, sessionID)
+	s.mx.Unlock()
+	for companyID := range books {
+		s.notifyOrderBook(sessionID, models.OrderBook{
+			CompanyID: companyID,
+			Bids:      []models.OrderBookLevel{},
+			Asks:      []models.OrderBookLevel{},
+		})
+	}
+}
+
+// RestoreOrderBooks загружает открытые заявки после перезапуска сервера.
+// Заявки сессий, где торги текущего раунда уже не идут, снимаются.
+func (s *Service) RestoreOrderBooks(ctx context.Context) error {
+	orders, err := s.repo.GetAllOpen(ctx)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetAllOpen: %w", err)
+	}
+
+	games := make(map[int64]*models.Game)
+	for _, order := range orders {
+		game, ok := games[order.SessionID]
+		if !ok {
+			if game, err = s.gamesRepo.Get(ctx, order.SessionID); err != nil {
+				return fmt.Errorf("s.gamesRepo.Get: %w", err)
+			}
+			games[order.SessionID] = game
+		}
+
+		if game.TradeState != models.TradeStateStarted || game.CurrentRound != order.Round {
+			order.Status = models.OrderStatusCancelled
+			if err = s.repo.Update(ctx, &order); err != nil {
+				return fmt.Errorf("s.repo.Update: %w", err)
+			}
+			continue
+		}
+		unlock := s.lockSession(order.SessionID)
+		s.getBook(order.SessionID, order.CompanyID).add(&order)
+		unlock()
+	}
+	return nil
+}
+
+// lockSession берёт блокировку сессии и возвращает функцию её снятия.
+func (s *Service) lockSession(sessionID int64) (unlock func()) {
+	s.mx.Lock()
+	lock, ok := s.sessionLocks[sessionID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.sessionLocks[sessionID] = lock
+	}
+	s.mx.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (s *Service) isTrade(sessionID int64) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.isTradePeriod[sessionID]
+}
+
+// openBidsCost возвращает стоимость неисполненного остатка заявок команды на покупку во всех стаканах
+// сессии, должен вызываться под блокировкой сессии.
+func (s *Service) openBidsCost(sessionID int64, teamID int64) int64 {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var cost int64
+	for _, book := range s.books[sessionID] {
+		_, bookCost := book.teamOpen(models.OrderSideBuy, teamID)
+		cost += bookCost
+	}
+	return cost
+}
+
+// getBook возвращает стакан компании, создавая его при необходимости. Содержимое стакана
+// можно менять только под блокировкой сессии.
+func (s *Service) getBook(sessionID int64, companyID int64) *orderBook {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	books, ok := s.books[sessionID]
+	if !ok {
+		books = make(map[int64]*orderBook)
+		s.books[sessionID] = books
+	}
+	book, ok := books[companyID]
+	if !ok {
+		book = &orderBook{}
+		books[companyID] = book
+	}
+	return book
+}
@@ -0,0 +1,247 @@
+package market
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"reflect"
+	"testing"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeOrdersRepo struct {
+	repo.MarketOrdersRepo
+	updated []models.MarketOrder
+}
+
+func (r *fakeOrdersRepo) Update(_ context.Context, order *models.MarketOrder) error {
+	r.updated = append(r.updated, *order)
+	return nil
+}
+
+func newTestService(ordersRepo *fakeOrdersRepo, settleFill func(ctx context.Context, fill models.MarketFill) error) *Service {
+	log := zerolog.Nop()
+	return New(
+		ordersRepo,
+		nil,
+		nil,
+		nil,
+		nil,
+		fakeTxManager{},
+		func(context.Context, *models.Team) error { return nil },
+		settleFill,
+		func(context.Context, ...int64) {},
+		func(int64, models.OrderBook) {},
+		&log,
+	)
+}
+
+func testOrder(id, teamID int64, side models.OrderSide, price, count int64) *models.MarketOrder {
+	return &models.MarketOrder{
+		ID:        id,
+		SessionID: 1,
+		TeamID:    teamID,
+		CompanyID: 1,
+		Side:      side,
+		Round:     1,
+		Price:     price,
+		Count:     count,
+		Status:    models.OrderStatusOpen,
+	}
+}
+
+func TestNewFill(t *testing.T) {
+	buyerMemberID, sellerMemberID := int64(10), int64(20)
+
+	tests := []struct {
+		name    string
+		order   *models.MarketOrder
+		resting *models.MarketOrder
+		want    models.MarketFill
+	}{
+		{
+			name:    "incoming buy takes resting ask price",
+			order:   &models.MarketOrder{ID: 2, TeamID: 2, MemberID: &buyerMemberID, CompanyID: 1, Side: models.OrderSideBuy, Round: 3, Price: 110, Count: 5},
+			resting: &models.MarketOrder{ID: 1, TeamID: 1, MemberID: &sellerMemberID, CompanyID: 1, Side: models.OrderSideSell, Round: 3, Price: 100, Count: 8, Filled: 1},
+			want: models.MarketFill{
+				BuyOrderID:     2,
+				SellOrderID:    1,
+				BuyerTeamID:    2,
+				SellerTeamID:   1,
+				BuyerMemberID:  &buyerMemberID,
+				SellerMemberID: &sellerMemberID,
+				CompanyID:      1,
+				Round:          3,
+				Price:          100,
+				Count:          5,
+			},
+		},
+		{
+			name:    "incoming sell takes resting bid price",
+			order:   &models.MarketOrder{ID: 4, TeamID: 1, MemberID: &sellerMemberID, CompanyID: 1, Side: models.OrderSideSell, Round: 2, Price: 90, Count: 10},
+			resting: &models.MarketOrder{ID: 3, TeamID: 2, CompanyID: 1, Side: models.OrderSideBuy, Round: 2, Price: 95, Count: 6, Filled: 2},
+			want: models.MarketFill{
+				BuyOrderID:     3,
+				SellOrderID:    4,
+				BuyerTeamID:    2,
+				SellerTeamID:   1,
+				BuyerMemberID:  nil,
+				SellerMemberID: &sellerMemberID,
+				CompanyID:      1,
+				Round:          2,
+				Price:          95,
+				Count:          4,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newFill(tt.order, tt.resting); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newFill() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		resting []*models.MarketOrder
+		order   *models.MarketOrder
+		// settleErrs: ключ - ID встречной заявки, значение - ошибка проведения сделки с ней.
+		settleErrs    map[int64]error
+		wantFills     []models.MarketFill
+		wantFilled    int64
+		wantStatus    models.OrderStatus
+		wantBidIDs    []int64
+		wantAskIDs    []int64
+		wantCancelled []int64
+	}{
+		{
+			name:       "no crossing leaves book untouched",
+			resting:    []*models.MarketOrder{testOrder(1, 1, models.OrderSideSell, 105, 5)},
+			order:      testOrder(2, 2, models.OrderSideBuy, 100, 5),
+			wantFilled: 0,
+			wantStatus: models.OrderStatusOpen,
+			wantAskIDs: []int64{1},
+		},
+		{
+			name: "buy sweeps asks by price then time",
+			resting: []*models.MarketOrder{
+				testOrder(1, 1, models.OrderSideSell, 102, 3),
+				testOrder(2, 3, models.OrderSideSell, 101, 2),
+				testOrder(3, 4, models.OrderSideSell, 101, 2),
+			},
+			order: testOrder(4, 2, models.OrderSideBuy, 102, 5),
+			wantFills: []models.MarketFill{
+				{BuyOrderID: 4, SellOrderID: 2, BuyerTeamID: 2, SellerTeamID: 3, CompanyID: 1, Round: 1, Price: 101, Count: 2},
+				{BuyOrderID: 4, SellOrderID: 3, BuyerTeamID: 2, SellerTeamID: 4, CompanyID: 1, Round: 1, Price: 101, Count: 2},
+				{BuyOrderID: 4, SellOrderID: 1, BuyerTeamID: 2, SellerTeamID: 1, CompanyID: 1, Round: 1, Price: 102, Count: 1},
+			},
+			wantFilled: 5,
+			wantStatus: models.OrderStatusFilled,
+			wantAskIDs: []int64{1},
+		},
+		{
+			name: "sell stops at limit price",
+			resting: []*models.MarketOrder{
+				testOrder(1, 1, models.OrderSideBuy, 100, 2),
+				testOrder(2, 3, models.OrderSideBuy, 95, 2),
+			},
+			order: testOrder(3, 2, models.OrderSideSell, 98, 5),
+			wantFills: []models.MarketFill{
+				{BuyOrderID: 1, SellOrderID: 3, BuyerTeamID: 1, SellerTeamID: 2, CompanyID: 1, Round: 1, Price: 100, Count: 2},
+			},
+			wantFilled: 2,
+			wantStatus: models.OrderStatusOpen,
+			wantBidIDs: []int64{2},
+		},
+		{
+			name: "resting order that cannot settle is cancelled and matching continues",
+			resting: []*models.MarketOrder{
+				testOrder(1, 1, models.OrderSideSell, 100, 2),
+				testOrder(2, 3, models.OrderSideSell, 101, 2),
+			},
+			order:      testOrder(3, 2, models.OrderSideBuy, 101, 2),
+			settleErrs: map[int64]error{1: models.ErrSellerCannotSettle},
+			wantFills: []models.MarketFill{
+				{BuyOrderID: 3, SellOrderID: 2, BuyerTeamID: 2, SellerTeamID: 3, CompanyID: 1, Round: 1, Price: 101, Count: 2},
+			},
+			wantFilled:    2,
+			wantStatus:    models.OrderStatusFilled,
+			wantCancelled: []int64{1},
+		},
+		{
+			name:          "incoming order that cannot settle is cancelled",
+			resting:       []*models.MarketOrder{testOrder(1, 1, models.OrderSideSell, 100, 2)},
+			order:         testOrder(2, 2, models.OrderSideBuy, 100, 2),
+			settleErrs:    map[int64]error{1: models.ErrBuyerCannotSettle},
+			wantFilled:    0,
+			wantStatus:    models.OrderStatusCancelled,
+			wantAskIDs:    []int64{1},
+			wantCancelled: []int64{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fills []models.MarketFill
+			ordersRepo := &fakeOrdersRepo{}
+			s := newTestService(ordersRepo, func(_ context.Context, fill models.MarketFill) error {
+				restingID := fill.SellOrderID
+				if tt.order.Side == models.OrderSideSell {
+					restingID = fill.BuyOrderID
+				}
+				if err := tt.settleErrs[restingID]; err != nil {
+					return err
+				}
+				fills = append(fills, fill)
+				return nil
+			})
+
+			book := &orderBook{}
+			for _, order := range tt.resting {
+				book.add(order)
+			}
+			if err := s.match(context.Background(), book, tt.order); err != nil {
+				t.Fatalf("match() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(fills, tt.wantFills) {
+				t.Errorf("fills = %+v, want %+v", fills, tt.wantFills)
+			}
+			if tt.order.Filled != tt.wantFilled || tt.order.Status != tt.wantStatus {
+				t.Errorf("order filled = %d status = %d, want %d %d",
+					tt.order.Filled, tt.order.Status, tt.wantFilled, tt.wantStatus)
+			}
+			if got := orderIDs(book.bids); !reflect.DeepEqual(got, tt.wantBidIDs) {
+				t.Errorf("bids = %v, want %v", got, tt.wantBidIDs)
+			}
+			if got := orderIDs(book.asks); !reflect.DeepEqual(got, tt.wantAskIDs) {
+				t.Errorf("asks = %v, want %v", got, tt.wantAskIDs)
+			}
+			var cancelled []int64
+			for _, order := range ordersRepo.updated {
+				if order.Status == models.OrderStatusCancelled {
+					cancelled = append(cancelled, order.ID)
+				}
+			}
+			if !reflect.DeepEqual(cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled = %v, want %v", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
+
+func orderIDs(orders []*models.MarketOrder) []int64 {
+	var ids []int64
+	for _, order := range orders {
+		ids = append(ids, order.ID)
+	}
+	return ids
+}
@@ -0,0 +1,132 @@
+package market
+
+import (
+	"investment-game-backend/internal/models"
+	"slices"
+)
+
+// orderBook — стакан заявок одной компании с приоритетом по цене, затем по времени выставления.
+type orderBook struct {
+	// bids отсортированы по убыванию цены, asks — по возрастанию, при равной цене раньше идёт меньший ID.
+	bids []*models.MarketOrder
+	asks []*models.MarketOrder
+}
+
+func (b *orderBook) add(order *models.MarketOrder) {
+	if order.Side == models.OrderSideBuy {
+		b.bids = insertOrder(b.bids, order, func(a, c *models.MarketOrder) bool {
+			return a.Price > c.Price || a.Price == c.Price && a.ID < c.ID
+		})
+		return
+	}
+	b.asks = insertOrder(b.asks, order, func(a, c *models.MarketOrder) bool {
+		return a.Price < c.Price || a.Price == c.Price && a.ID < c.ID
+	})
+}
+
+func insertOrder(
+	orders []*models.MarketOrder,
+	order *models.MarketOrder,
+	less func(a, b *models.MarketOrder) bool,
+) []*models.MarketOrder {
+	i, _ := slices.BinarySearchFunc(orders, order, func(item, target *models.MarketOrder) int {
+		if less(item, target) {
+			return -1
+		}
+		return 1
+	})
+	return slices.Insert(orders, i, order)
+}
+
+func (b *orderBook) remove(order *models.MarketOrder) {
+	match := func(item *models.MarketOrder) bool {
+		return item.ID == order.ID
+	}
+	if order.Side == models.OrderSideBuy {
+		b.bids = slices.DeleteFunc(b.bids, match)
+		return
+	}
+	b.asks = slices.DeleteFunc(b.asks, match)
+}
+
+// best возвращает лучшую заявку стороны side или nil, если сторона пуста.
+func (b *orderBook) best(side models.OrderSide) *models.MarketOrder {
+	orders := b.asks
+	if side == models.OrderSideBuy {
+		orders = b.bids
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+	return orders[0]
+}
+
+// teamOpen возвращает количество и стоимость неисполненного остатка заявок команды teamID на стороне side.
+func (b *orderBook) teamOpen(side models.OrderSide, teamID int64) (count, cost int64) {
+	orders := b.asks
+	if side == models.OrderSideBuy {
+		orders = b.bids
+	}
+	for _, order := range orders {
+		if order.TeamID == teamID {
+			count += order.Remaining()
+			cost += order.Price * order.Remaining()
+		}
+	}
+	return count, cost
+}
+
+// crossesOwn проверяет, исполнилась бы заявка против встречной заявки той же команды.
+func (b *orderBook) crossesOwn(order *models.MarketOrder) bool {
+	orders := b.bids
+	if order.Side == models.OrderSideBuy {
+		orders = b.asks
+	}
+	for _, item := range orders {
+		if !crosses(order, item) {
+			return false
+		}
+		if item.TeamID == order.TeamID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *orderBook) depth(companyID int64) models.OrderBook {
+	return models.OrderBook{
+		CompanyID: companyID,
+		Bids:      aggregateLevels(b.bids),
+		Asks:      aggregateLevels(b.asks),
+	}
+}
+
+func aggregateLevels(orders []*models.MarketOrder) []models.OrderBookLevel {
+	levels := make([]models.OrderBookLevel, 0)
+	for _, order := range orders {
+		if len(levels) > 0 && levels[len(levels)-1].Price == order.Price {
+			levels[len(levels)-1].Count += order.Remaining()
+			continue
+		}
+		levels = append(levels, models.OrderBookLevel{
+			Price: order.Price,
+			Count: order.Remaining(),
+		})
+	}
+	return levels
+}
+
+// crosses проверяет, что входящая заявка order может исполниться против заявки resting из стакана.
+func crosses(order, resting *models.MarketOrder) bool {
+	if order.Side == models.OrderSideBuy {
+		return order.Price >= resting.Price
+	}
+	return order.Price <= resting.Price
+}
+
+func oppositeSide(side models.OrderSide) models.OrderSide {
+	if side == models.OrderSideBuy {
+		return models.OrderSideSell
+	}
+	return models.OrderSideBuy
+}
@@ -0,0 +1,169 @@
+package market
+
+import (
+	"investment-game-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestOrderBookPriority(t *testing.T) {
+	tests := []struct {
+		name    string
+		orders  []*models.MarketOrder
+		remove  []int64
+		wantBid []int64
+		wantAsk []int64
+	}{
+		{
+			name: "bids by price desc then id",
+			orders: []*models.MarketOrder{
+				testOrder(3, 1, models.OrderSideBuy, 100, 1),
+				testOrder(1, 1, models.OrderSideBuy, 99, 1),
+				testOrder(2, 1, models.OrderSideBuy, 100, 1),
+				testOrder(4, 1, models.OrderSideBuy, 101, 1),
+			},
+			wantBid: []int64{4, 2, 3, 1},
+		},
+		{
+			name: "asks by price asc then id",
+			orders: []*models.MarketOrder{
+				testOrder(3, 1, models.OrderSideSell, 100, 1),
+				testOrder(1, 1, models.OrderSideSell, 101, 1),
+				testOrder(2, 1, models.OrderSideSell, 100, 1),
+				testOrder(4, 1, models.OrderSideSell, 99, 1),
+			},
+			wantAsk: []int64{4, 2, 3, 1},
+		},
+		{
+			name: "remove keeps order of the rest",
+			orders: []*models.MarketOrder{
+				testOrder(1, 1, models.OrderSideBuy, 100, 1),
+				testOrder(2, 1, models.OrderSideBuy, 101, 1),
+				testOrder(3, 1, models.OrderSideSell, 102, 1),
+				testOrder(4, 1, models.OrderSideSell, 103, 1),
+			},
+			remove:  []int64{2, 3},
+			wantBid: []int64{1},
+			wantAsk: []int64{4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := &orderBook{}
+			byID := make(map[int64]*models.MarketOrder)
+			for _, order := range tt.orders {
+				book.add(order)
+				byID[order.ID] = order
+			}
+			for _, id := range tt.remove {
+				book.remove(byID[id])
+			}
+
+			if got := orderIDs(book.bids); !reflect.DeepEqual(got, tt.wantBid) {
+				t.Errorf("bids = %v, want %v", got, tt.wantBid)
+			}
+			if got := orderIDs(book.asks); !reflect.DeepEqual(got, tt.wantAsk) {
+				t.Errorf("asks = %v, want %v", got, tt.wantAsk)
+			}
+			if len(tt.wantBid) > 0 && book.best(models.OrderSideBuy).ID != tt.wantBid[0] {
+				t.Errorf("best bid = %d, want %d", book.best(models.OrderSideBuy).ID, tt.wantBid[0])
+			}
+			if len(tt.wantAsk) > 0 && book.best(models.OrderSideSell).ID != tt.wantAsk[0] {
+				t.Errorf("best ask = %d, want %d", book.best(models.OrderSideSell).ID, tt.wantAsk[0])
+			}
+		})
+	}
+}
+
+func TestOrderBookCrossesOwn(t *testing.T) {
+	tests := []struct {
+		name    string
+		resting []*models.MarketOrder
+		order   *models.MarketOrder
+		want    bool
+	}{
+		{
+			name:    "empty book",
+			resting: nil,
+			order:   testOrder(1, 1, models.OrderSideBuy, 100, 1),
+			want:    false,
+		},
+		{
+			name:    "own ask crosses",
+			resting: []*models.MarketOrder{testOrder(1, 1, models.OrderSideSell, 100, 1)},
+			order:   testOrder(2, 1, models.OrderSideBuy, 100, 1),
+			want:    true,
+		},
+		{
+			name:    "own ask above limit",
+			resting: []*models.MarketOrder{testOrder(1, 1, models.OrderSideSell, 101, 1)},
+			order:   testOrder(2, 1, models.OrderSideBuy, 100, 1),
+			want:    false,
+		},
+		{
+			name: "own bid behind other team still crosses",
+			resting: []*models.MarketOrder{
+				testOrder(1, 2, models.OrderSideBuy, 105, 1),
+				testOrder(2, 1, models.OrderSideBuy, 100, 1),
+			},
+			order: testOrder(3, 1, models.OrderSideSell, 100, 1),
+			want:  true,
+		},
+		{
+			name: "own bid below limit",
+			resting: []*models.MarketOrder{
+				testOrder(1, 2, models.OrderSideBuy, 105, 1),
+				testOrder(2, 1, models.OrderSideBuy, 99, 1),
+			},
+			order: testOrder(3, 1, models.OrderSideSell, 100, 1),
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := &orderBook{}
+			for _, order := range tt.resting {
+				book.add(order)
+			}
+			if got := book.crossesOwn(tt.order); got != tt.want {
+				t.Errorf("crossesOwn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBookTeamOpen(t *testing.T) {
+	partlyFilled := testOrder(3, 1, models.OrderSideBuy, 90, 10)
+	partlyFilled.Filled = 4
+
+	book := &orderBook{}
+	for _, order := range []*models.MarketOrder{
+		testOrder(1, 1, models.OrderSideBuy, 100, 2),
+		testOrder(2, 2, models.OrderSideBuy, 100, 5),
+		partlyFilled,
+		testOrder(4, 1, models.OrderSideSell, 120, 3),
+	} {
+		book.add(order)
+	}
+
+	tests := []struct {
+		name      string
+		side      models.OrderSide
+		teamID    int64
+		wantCount int64
+		wantCost  int64
+	}{
+		{name: "bids count only remaining", side: models.OrderSideBuy, teamID: 1, wantCount: 8, wantCost: 740},
+		{name: "asks", side: models.OrderSideSell, teamID: 1, wantCount: 3, wantCost: 360},
+		{name: "other team", side: models.OrderSideBuy, teamID: 2, wantCount: 5, wantCost: 500},
+		{name: "no orders", side: models.OrderSideSell, teamID: 2, wantCount: 0, wantCost: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, cost := book.teamOpen(tt.side, tt.teamID)
+			if count != tt.wantCount || cost != tt.wantCost {
+				t.Errorf("teamOpen() = %d, %d, want %d, %d", count, cost, tt.wantCount, tt.wantCost)
+			}
+		})
+	}
+}
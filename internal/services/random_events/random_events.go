@@ -0,0 +1,391 @@
+package random_events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+type Service struct {
+	repo                    repo.RandomEventsRepo
+	teamsRepo               repo.TeamsRepo
+	balancesRepo            repo.BalancesRepo
+	balanceTransactionsRepo repo.BalanceTransactionsRepo
+	sharesRepo              repo.CompanySharesRepo
+	gamesRepo               repo.GamesRepo
+	txManager               repo.TxManager
+	log                     *zerolog.Logger
+}
+
+func New(
+	repo repo.RandomEventsRepo,
+	teamsRepo repo.TeamsRepo,
+	balancesRepo repo.BalancesRepo,
+	balanceTransactionsRepo repo.BalanceTransactionsRepo,
+	sharesRepo repo.CompanySharesRepo,
+	gamesRepo repo.GamesRepo,
+	txManager repo.TxManager,
+	log *zerolog.Logger,
+) *Service {
+	return &Service{
+		repo:                    repo,
+		teamsRepo:               teamsRepo,
+		balancesRepo:            balancesRepo,
+		balanceTransactionsRepo: balanceTransactionsRepo,
+		sharesRepo:              sharesRepo,
+		gamesRepo:               gamesRepo,
+		txManager:               txManager,
+		log:                     log,
+	}
+}
+
+type CreateParams struct {
+	Name            string
+	Description     string
+	EffectType      models.RandomEventEffectType
+	BalanceDelta    int64
+	CompanyID       *int64
+	PriceMultiplier float64
+	Probability     int
+}
+
+var ErrInvalidParams = errors.New("invalid random event params")
+
+func (params CreateParams) Validate() error {
+	return validateEffect(params.EffectType, params.CompanyID, params.PriceMultiplier, params.Probability)
+}
+
+func (s *Service) Create(ctx context.Context, params CreateParams) (*models.RandomEvent, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	event := &models.RandomEvent{
+		Name:            params.Name,
+		Description:     params.Description,
+		EffectType:      params.EffectType,
+		BalanceDelta:    params.BalanceDelta,
+		CompanyID:       params.CompanyID,
+		PriceMultiplier: params.PriceMultiplier,
+		Probability:     params.Probability,
+	}
+	id, err := s.repo.Create(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.Create: %w", err)
+	}
+	event.ID = id
+	return event, nil
+}
+
+type UpdateParams struct {
+	ID              int64
+	Name            string
+	Description     string
+	EffectType      models.RandomEventEffectType
+	BalanceDelta    int64
+	CompanyID       *int64
+	PriceMultiplier float64
+	Probability     int
+}
+
+func (params UpdateParams) Validate() error {
+	return validateEffect(params.EffectType, params.CompanyID, params.PriceMultiplier, params.Probability)
+}
+
+func (s *Service) Update(ctx context.Context, params UpdateParams) error {
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("params.Validate: %w", err)
+	}
+
+	event, err := s.repo.GetByID(ctx, params.ID)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+
+	if event.IsArchived() {
+		return errors.New("cannot update archived random event")
+	}
+
+	event.Name = params.Name
+	event.Description = params.Description
+	event.EffectType = params.EffectType
+	event.BalanceDelta = params.BalanceDelta
+	event.CompanyID = params.CompanyID
+	event.PriceMultiplier = params.PriceMultiplier
+	event.Probability = params.Probability
+
+	if err = s.repo.Update(ctx, event); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Archive(ctx context.Context, id int64) error {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("s.repo.GetByID: %w", err)
+	}
+
+	event.Archived = lo.ToPtr(true)
+
+	if err = s.repo.Update(ctx, event); err != nil {
+		return fmt.Errorf("s.repo.Update: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetAll(ctx context.Context) ([]models.RandomEvent, error) {
+	events, err := s.repo.GetAllNotArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAllNotArchived: %w", err)
+	}
+	return events, nil
+}
+
+// Roll разыгрывает случайные события для всех команд текущей игры сессии и применяет их эффекты.
+// События, выпавшие в предыдущем раунде, сбрасываются. Событие каждой команды применяется в отдельной
+// транзакции, а команды, которым событие раунда уже выпало, пропускаются, поэтому повторный вызов
+// для того же раунда после сбоя не применяет события дважды.
+func (s *Service) Roll(ctx context.Context, sessionID int64, round int) ([]models.TeamRandomEvent, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+	events, err := s.repo.GetAllNotArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.repo.GetAllNotArchived: %w", err)
+	}
+
+	priceByCompanyID, err := s.getPricesForEvents(ctx, game, events, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.getPricesForEvents: %w", err)
+	}
+
+	result := make([]models.TeamRandomEvent, 0, len(teams))
+	for _, team := range teams {
+		var event *models.RandomEvent
+		if picked, ok := pickEvent(events); ok {
+			event = &picked
+		}
+
+		var applied *models.TeamRandomEvent
+		if err = repo.WithinTxRetry(ctx, s.txManager, func(ctx context.Context) error {
+			var err error
+			applied, err = s.rollTeamEvent(ctx, team.ID, round, event, priceByCompanyID)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("repo.WithinTxRetry: %w", err)
+		}
+		if applied == nil {
+			continue
+		}
+
+		s.log.Trace().
+			Int64("team_id", applied.TeamID).
+			Int64("random_event_id", applied.Event.ID).
+			Int64("amount", applied.Amount).
+			Msg("random event applied")
+
+		result = append(result, *applied)
+	}
+
+	return result, nil
+}
+
+// rollTeamEvent применяет к команде выпавшее событие event или сбрасывает событие прошлого раунда,
+// если ничего не выпало. Возвращает nil, если событие не выпало или уже было применено в этом раунде.
+func (s *Service) rollTeamEvent(
+	ctx context.Context,
+	teamID int64,
+	round int,
+	event *models.RandomEvent,
+	priceByCompanyID map[int64]int64,
+) (*models.TeamRandomEvent, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	// команду перечитываем под блокировкой баланса, чтобы не затереть изменения параллельной покупки
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	if slices.ContainsFunc(transactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeRandomEvent
+	}) {
+		return nil, nil
+	}
+
+	if event == nil {
+		if team.RandomEventID == nil {
+			return nil, nil
+		}
+		team.RandomEventID = nil
+		if err = s.teamsRepo.Update(ctx, team); err != nil {
+			return nil, fmt.Errorf("s.teamsRepo.Update: %w", err)
+		}
+		return nil, nil
+	}
+
+	amount := getEffectAmount(team, *event, priceByCompanyID)
+	if err = s.applyEvent(ctx, team, balance, *event, round, amount); err != nil {
+		return nil, fmt.Errorf("s.applyEvent: %w", err)
+	}
+	return &models.TeamRandomEvent{
+		TeamID: team.ID,
+		Round:  round,
+		Event:  *event,
+		Amount: amount,
+	}, nil
+}
+
+func (s *Service) getPricesForEvents(
+	ctx context.Context,
+	game *models.Game,
+	events []models.RandomEvent,
+	round int,
+) (map[int64]int64, error) {
+	companyIDs := lo.FilterMap(events, func(item models.RandomEvent, _ int) (int64, bool) {
+		if item.EffectType != models.RandomEventEffectTypeSharePriceMultiplier || item.CompanyID == nil {
+			return 0, false
+		}
+		return *item.CompanyID, true
+	})
+	if len(companyIDs) == 0 {
+		return nil, nil
+	}
+
+	shares, err := s.sharesRepo.GetListForGameByCompanyIDsAndRound(
+		ctx,
+		game.ID,
+		game.CurrentGame,
+		lo.Uniq(companyIDs),
+		round,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.sharesRepo.GetListForGameByCompanyIDsAndRound: %w", err)
+	}
+	return lo.SliceToMap(
+		shares,
+		func(share models.CompanyShare) (int64, int64) {
+			return share.CompanyID, share.Price
+		},
+	), nil
+}
+
+func (s *Service) applyEvent(
+	ctx context.Context,
+	team *models.Team,
+	balance *models.Balance,
+	event models.RandomEvent,
+	round int,
+	amount int64,
+) error {
+	if _, err := s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeRandomEvent,
+			Round:            round,
+			Amount:           -amount,
+			Details:          nil,
+			AdditionalInfoID: nil,
+			RandomEventID:    &event.ID,
+		},
+	); err != nil {
+		return fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+
+	if amount != 0 {
+		balance.Amount += amount
+		if err := s.balancesRepo.Update(ctx, balance); err != nil {
+			return fmt.Errorf("s.balancesRepo.Update: %w", err)
+		}
+	}
+
+	team.RandomEventID = &event.ID
+	if err := s.teamsRepo.Update(ctx, team); err != nil {
+		return fmt.Errorf("s.teamsRepo.Update: %w", err)
+	}
+	return nil
+}
+
+func getEffectAmount(team *models.Team, event models.RandomEvent, priceByCompanyID map[int64]int64) int64 {
+	switch event.EffectType {
+	case models.RandomEventEffectTypeBalanceDelta:
+		return event.BalanceDelta
+	case models.RandomEventEffectTypeSharePriceMultiplier:
+		if event.CompanyID == nil {
+			return 0
+		}
+		cost := team.Shares[*event.CompanyID] * priceByCompanyID[*event.CompanyID]
+		return int64(math.Round(float64(cost) * (event.PriceMultiplier - 1)))
+	default:
+		return 0
+	}
+}
+
+func pickEvent(events []models.RandomEvent) (models.RandomEvent, bool) {
+	if len(events) == 0 {
+		return models.RandomEvent{}, false
+	}
+
+	shuffled := make([]models.RandomEvent, len(events))
+	copy(shuffled, events)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	roll := rand.Intn(100)
+	for _, event := range shuffled {
+		if roll < event.Probability {
+			return event, true
+		}
+		roll -= event.Probability
+	}
+	return models.RandomEvent{}, false
+}
+
+func validateEffect(
+	effectType models.RandomEventEffectType,
+	companyID *int64,
+	priceMultiplier float64,
+	probability int,
+) error {
+	if probability < 0 || probability > 100 {
+		return fmt.Errorf("%w: probability must be in range [0, 100]", ErrInvalidParams)
+	}
+	switch effectType {
+	case models.RandomEventEffectTypeBalanceDelta, models.RandomEventEffectTypeTradingFrozen:
+		return nil
+	case models.RandomEventEffectTypeSharePriceMultiplier:
+		if companyID == nil {
+			return fmt.Errorf("%w: company is required for share price multiplier", ErrInvalidParams)
+		}
+		if priceMultiplier <= 0 {
+			return fmt.Errorf("%w: price multiplier must be positive", ErrInvalidParams)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown effect type %d", ErrInvalidParams, effectType)
+	}
+}
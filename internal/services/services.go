@@ -4,8 +4,13 @@ import (
 	"context"
 	"investment-game-backend/internal/models"
 	additionalinfos "investment-game-backend/internal/services/additional_infos"
+	"investment-game-backend/internal/services/admins"
+	"investment-game-backend/internal/services/auth"
+	"investment-game-backend/internal/services/bonds"
 	"investment-game-backend/internal/services/companies"
 	"investment-game-backend/internal/services/games"
+	"investment-game-backend/internal/services/market"
+	randomevents "investment-game-backend/internal/services/random_events"
 	"investment-game-backend/internal/services/settings"
 	"investment-game-backend/internal/services/teams"
 	"time"
@@ -17,25 +22,39 @@ type Settings interface {
 }
 
 type Games interface {
-	Get(ctx context.Context) (*models.Game, error)
-	Update(ctx context.Context, params games.UpdateParams) error
+	Get(ctx context.Context, sessionID int64) (*models.Game, error)
+	GetSessions(ctx context.Context) ([]models.Game, error)
+	CreateSession(ctx context.Context, name string) (*models.Game, error)
+	Update(ctx context.Context, sessionID int64, params games.UpdateParams) error
 	UpdateTradePeriod(period time.Duration)
-	CreateNewGame(ctx context.Context) error
-	StartGame(ctx context.Context) error
-	StopGame(ctx context.Context) error
-	StartRegistration(ctx context.Context) error
-	StopRegistration(ctx context.Context) error
-	StartRound(ctx context.Context) error
-	StopRound(_ context.Context) error
-	StartTrade(ctx context.Context) error
-	StopTrade(_ context.Context)
+	CreateNewGame(ctx context.Context, sessionID int64) error
+	StartGame(ctx context.Context, sessionID int64) error
+	StopGame(ctx context.Context, sessionID int64) error
+	PauseGame(ctx context.Context, sessionID int64) error
+	ResumeGame(ctx context.Context, sessionID int64) error
+	StartRegistration(ctx context.Context, sessionID int64) error
+	StopRegistration(ctx context.Context, sessionID int64) error
+	StartRound(ctx context.Context, sessionID int64) error
+	StopRound(ctx context.Context, sessionID int64) error
+	StartTrade(ctx context.Context, sessionID int64) error
+	StopTrade(ctx context.Context, sessionID int64) error
+	StartAutopilot(ctx context.Context, sessionID int64) error
+	StopAutopilot(_ context.Context, sessionID int64) error
+	PauseAutopilot(ctx context.Context, sessionID int64) error
+	ResumeAutopilot(ctx context.Context, sessionID int64) error
+	SkipAutopilotPhase(_ context.Context, sessionID int64) error
+	ExtendAutopilotPhase(ctx context.Context, sessionID int64, d time.Duration) error
+	GetAutopilotState(_ context.Context, sessionID int64) (models.AutopilotState, error)
 }
 
 type Companies interface {
 	CreateWithShares(ctx context.Context, params companies.CreateWithSharesParams) (int64, error)
 	Update(ctx context.Context, params companies.UpdateParams) error
 	Archive(ctx context.Context, id int64) error
-	GetAllWithShares(ctx context.Context, onlyCurrentRound bool) ([]models.CompanyWithShares, error)
+	GetAllWithShares(ctx context.Context, sessionID int64, onlyCurrentRound bool) ([]models.CompanyWithShares, error)
+	PreviewPriceAdjustment(ctx context.Context, sessionID int64) ([]models.SharePriceAdjustment, error)
+	GeneratePrices(ctx context.Context, params []companies.GeneratePricesParams) ([]companies.GeneratedPrices, error)
+	CommitGeneratedPrices(ctx context.Context, params []companies.GeneratePricesParams) ([]companies.GeneratedPrices, error)
 }
 
 type Teams interface {
@@ -43,17 +62,66 @@ type Teams interface {
 	Update(ctx context.Context, params teams.UpdateParams) error
 	Purchase(ctx context.Context, params teams.PurchaseParams) (int64, error)
 	GetDetailedByID(ctx context.Context, id int64) (teams.DetailedTeam, error)
-	NotifyTradePeriodUpdated(isTrade bool)
-	NotifyGameRegistrationPeriodUpdated(idRegistration bool)
-	GetAllForCurrentGame(ctx context.Context) ([]models.Team, error)
-	PurchaseAdditionalInfoCompanyInfo(ctx context.Context, teamId int64) (models.AdditionalInfo, int64, error)
-	ResetTransaction(ctx context.Context, teamID int64) (teams.DetailedTeam, error)
-	GetStatisticsByGame(ctx context.Context, round int) (teams.StatisticsByGame, error)
+	NotifyTradePeriodUpdated(sessionID int64, isTrade bool)
+	NotifyGameRegistrationPeriodUpdated(sessionID int64, idRegistration bool)
+	GetAllForCurrentGame(ctx context.Context, sessionID int64) ([]models.Team, error)
+	PurchaseAdditionalInfoCompanyInfo(
+		ctx context.Context,
+		teamId int64,
+		memberID *int64,
+	) (models.AdditionalInfo, int64, error)
+	ResetTransaction(ctx context.Context, teamID int64, transactionID *int64) (teams.DetailedTeam, error)
+	GetStatisticsByGame(ctx context.Context, sessionID int64, round int) (teams.StatisticsByGame, error)
+	GetTransactionHistory(
+		ctx context.Context,
+		teamID int64,
+		filter teams.TransactionHistoryFilter,
+	) ([]teams.HistoryTransaction, error)
+	GetGameTransactionHistory(
+		ctx context.Context,
+		sessionID int64,
+		filter teams.TransactionHistoryFilter,
+	) ([]teams.HistoryTransaction, error)
+	PurchaseBond(ctx context.Context, params teams.PurchaseBondParams) (int64, error)
+	GetTeamBonds(ctx context.Context, teamID int64) ([]models.TeamBond, error)
+	Borrow(ctx context.Context, params teams.LoanParams) (models.Balance, error)
+	Repay(ctx context.Context, params teams.LoanParams) (models.Balance, error)
+	CreateMember(ctx context.Context, params teams.CreateMemberParams) (*models.TeamMember, error)
+	UpdateMember(ctx context.Context, params teams.UpdateMemberParams) (*models.TeamMember, error)
+	DeleteMember(ctx context.Context, teamID int64, id int64) error
+	GetMembers(ctx context.Context, teamID int64) ([]models.TeamMember, error)
+	CreateJoinCodes(ctx context.Context, params teams.CreateJoinCodesParams) ([]models.JoinCode, error)
+	GetJoinCodes(ctx context.Context, sessionID int64) ([]models.JoinCode, error)
+	GetJoinCode(ctx context.Context, sessionID int64, id int64) (*models.JoinCode, error)
+	RevokeJoinCode(ctx context.Context, sessionID int64, id int64) error
+	CreateByJoinCode(ctx context.Context, params teams.CreateByJoinCodeParams) (int64, error)
+}
+
+type Bonds interface {
+	Create(ctx context.Context, params bonds.CreateParams) (*models.Bond, error)
+	Update(ctx context.Context, params bonds.UpdateParams) error
+	Archive(ctx context.Context, id int64) error
+	GetAll(ctx context.Context) ([]models.Bond, error)
+}
+
+type Admins interface {
+	Create(ctx context.Context, params admins.CreateParams) (*models.Admin, error)
+	Update(ctx context.Context, params admins.UpdateParams) error
+	Delete(ctx context.Context, actorID int64, id int64) error
+	GetAll(ctx context.Context) ([]models.Admin, error)
+	RecordAction(ctx context.Context, adminID int64, sessionID int64, action string) error
+	GetActions(ctx context.Context, sessionID int64) ([]models.AdminAction, error)
 }
 
 type Auth interface {
-	Login(ctx context.Context, credentials string, isAdmin bool) (models.JWTPair, error)
+	Login(ctx context.Context, params auth.LoginParams) (models.JWTPair, error)
 	Refresh(ctx context.Context, refreshToken string) (models.JWTPair, error)
+	Logout(ctx context.Context, authSessionID int64) error
+	IssueSpectatorTokens(ctx context.Context, adminID int64, sessionID int64, userAgent string) (models.JWTPair, error)
+	KickTeam(ctx context.Context, teamID int64) error
+	KickTeamMember(ctx context.Context, teamID int64, memberID int64) error
+	KickTeamSharedSessions(ctx context.Context, teamID int64) error
+	IsAccessTokenRevoked(jti string) bool
 	RefreshTokenExpTime() time.Duration
 }
 
@@ -63,3 +131,17 @@ type AdditionalInfos interface {
 	GetActualListByType(ctx context.Context, infoType models.AdditionalInfoType) ([]models.AdditionalInfo, error)
 	Delete(ctx context.Context, id int64) error
 }
+
+type RandomEvents interface {
+	Create(ctx context.Context, params randomevents.CreateParams) (*models.RandomEvent, error)
+	Update(ctx context.Context, params randomevents.UpdateParams) error
+	Archive(ctx context.Context, id int64) error
+	GetAll(ctx context.Context) ([]models.RandomEvent, error)
+}
+
+type Market interface {
+	PlaceOrder(ctx context.Context, params market.PlaceOrderParams) (*models.MarketOrder, error)
+	CancelOrder(ctx context.Context, teamID int64, orderID int64) error
+	GetOrderBook(_ context.Context, sessionID int64, companyID int64) models.OrderBook
+	GetTeamOrders(ctx context.Context, teamID int64) ([]models.MarketOrder, error)
+}
@@ -50,10 +50,21 @@ func (s *Service) Get(ctx context.Context) (*models.Settings, error) {
 type UpdateParams struct {
 	RoundsCount               int
 	RoundsDuration            time.Duration
+	BriefingDuration          time.Duration
+	ResultsDuration           time.Duration
 	LinkToPDF                 string
 	EnableRandomEvents        bool
 	DefaultBalance            int64
 	DefaultAdditionalInfoCost int64
+	ShortSellingLimit         int64
+	ShortMarginPercent        int64
+	EnableDemandPricing       bool
+	DemandElasticityPercent   int64
+	CommissionFlat            int64
+	CommissionPercent         int64
+	CapitalGainsTaxPercent    int64
+	CreditLimit               int64
+	LoanInterestPercent       int64
 }
 
 func (s *Service) Update(ctx context.Context, params UpdateParams) error {
@@ -67,6 +78,12 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 		s.updateTradePeriodCallback(params.RoundsDuration)
 	}
 	settings.RoundsDuration = params.RoundsDuration
+	if params.BriefingDuration > 0 {
+		settings.BriefingDuration = params.BriefingDuration
+	}
+	if params.ResultsDuration > 0 {
+		settings.ResultsDuration = params.ResultsDuration
+	}
 	settings.EnableRandomEvents = params.EnableRandomEvents
 	settings.LinkToPDF = params.LinkToPDF
 
@@ -83,6 +100,15 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 		}
 	}
 	settings.DefaultAdditionalInfoCost = params.DefaultAdditionalInfoCost
+	settings.ShortSellingLimit = params.ShortSellingLimit
+	settings.ShortMarginPercent = params.ShortMarginPercent
+	settings.EnableDemandPricing = params.EnableDemandPricing
+	settings.DemandElasticityPercent = params.DemandElasticityPercent
+	settings.CommissionFlat = params.CommissionFlat
+	settings.CommissionPercent = params.CommissionPercent
+	settings.CapitalGainsTaxPercent = params.CapitalGainsTaxPercent
+	settings.CreditLimit = params.CreditLimit
+	settings.LoanInterestPercent = params.LoanInterestPercent
 
 	if err = s.repo.Update(ctx, settings); err != nil {
 		return fmt.Errorf("s.repo.Update: %w", err)
@@ -91,28 +117,30 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) error {
 }
 
 func (s *Service) updateDefaultBalanceForActiveTeams(ctx context.Context, defaultBalance int64) error {
-	game, err := s.gameRepo.Get(ctx)
+	games, err := s.gameRepo.GetAll(ctx)
 	if err != nil {
-		return fmt.Errorf("s.gameRepo.Get: %w", err)
-	}
-	if game.State == models.GameStateStarted {
-		return nil
+		return fmt.Errorf("s.gameRepo.GetAll: %w", err)
 	}
 
-	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.CurrentGame)
-	if err != nil {
-		return fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
-	}
-	if len(teams) == 0 {
-		return nil
-	}
+	for _, game := range games {
+		if game.State == models.GameStateStarted {
+			continue
+		}
+
+		teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+		if err != nil {
+			return fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+		}
 
-	for _, team := range teams {
-		if err = s.balanceRepo.Update(ctx, &models.Balance{
-			ID:     team.BalanceID,
-			Amount: defaultBalance,
-		}); err != nil {
-			return fmt.Errorf("s.balanceRepo.Update: %w", err)
+		for _, team := range teams {
+			balance, err := s.balanceRepo.GetByID(ctx, team.BalanceID)
+			if err != nil {
+				return fmt.Errorf("s.balanceRepo.GetByID: %w", err)
+			}
+			balance.Amount = defaultBalance
+			if err = s.balanceRepo.Update(ctx, balance); err != nil {
+				return fmt.Errorf("s.balanceRepo.Update: %w", err)
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,254 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"slices"
+)
+
+var ErrInvalidBondPurchase = errors.New("invalid bond purchase")
+
+type PurchaseBondParams struct {
+	TeamID   int64
+	BondID   int64
+	Count    int64
+	MemberID *int64
+}
+
+// PurchaseBond покупает пакет облигаций (или открывает вклад) в период торгов. Купоны начисляются
+// с начала следующего раунда, номинал возвращается через Bond.MaturityRounds раундов.
+// Возвращает баланс команды после покупки.
+func (s *Service) PurchaseBond(ctx context.Context, params PurchaseBondParams) (int64, error) {
+	var balanceAmount int64
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		var err error
+		balanceAmount, err = s.purchaseBond(ctx, params)
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("s.withinTxRetry: %w", err)
+	}
+
+	s.notifyBalanceChanged(ctx, params.TeamID)
+	return balanceAmount, nil
+}
+
+func (s *Service) purchaseBond(ctx context.Context, params PurchaseBondParams) (int64, error) {
+	if params.Count <= 0 {
+		return 0, fmt.Errorf("%w: count must be positive", ErrInvalidBondPurchase)
+	}
+
+	team, err := s.teamsRepo.GetByID(ctx, params.TeamID)
+	if err != nil {
+		return 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	if !s.isTradePeriodFor(team.SessionID) {
+		return 0, ErrIsNoTradePeriod
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
+	if err != nil {
+		return 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return 0, ErrGamePaused
+	}
+	if err = s.checkTradingNotFrozen(ctx, team); err != nil {
+		return 0, fmt.Errorf("s.checkTradingNotFrozen: %w", err)
+	}
+
+	bond, err := s.bondsRepo.GetByID(ctx, params.BondID)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return 0, fmt.Errorf("%w: bond %d not found", ErrInvalidBondPurchase, params.BondID)
+		}
+		return 0, fmt.Errorf("s.bondsRepo.GetByID: %w", err)
+	}
+	if bond.IsArchived() {
+		return 0, fmt.Errorf("%w: bond %d is archived", ErrInvalidBondPurchase, bond.ID)
+	}
+
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return 0, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+
+	teamBond := &models.TeamBond{
+		TeamID:        team.ID,
+		BondID:        bond.ID,
+		Count:         params.Count,
+		Price:         bond.Price,
+		CouponPercent: bond.CouponPercent,
+		PurchaseRound: game.CurrentRound,
+		MaturityRound: game.CurrentRound + bond.MaturityRounds,
+		Redeemed:      false,
+	}
+	amount := teamBond.Principal()
+	if balance.Available()-amount < 0 {
+		return 0, ErrNoMoneyForOperation
+	}
+
+	if _, err = s.teamBondsRepo.Create(ctx, teamBond); err != nil {
+		return 0, fmt.Errorf("s.teamBondsRepo.Create: %w", err)
+	}
+	if _, err = s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeBondPurchase,
+			Round:            game.CurrentRound,
+			Amount:           amount,
+			Details:          nil,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+			MemberID:         params.MemberID,
+		},
+	); err != nil {
+		return 0, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+
+	balance.Amount -= amount
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return 0, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+
+	return balance.Amount, nil
+}
+
+func (s *Service) GetTeamBonds(ctx context.Context, teamID int64) ([]models.TeamBond, error) {
+	bonds, err := s.teamBondsRepo.GetAllByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamBondsRepo.GetAllByTeamID: %w", err)
+	}
+	return bonds, nil
+}
+
+// PayBondCoupons выплачивает в начале раунда round купоны по непогашенным облигациям команд
+// текущей игры сессии и возвращает номинал пакетов, срок которых наступил. Повторный вызов
+// за тот же раунд выплаты не дублирует.
+func (s *Service) PayBondCoupons(ctx context.Context, sessionID int64, round int) ([]models.BondPayment, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+
+	teamBonds, err := s.teamBondsRepo.GetAllNotRedeemedByTeamIDs(
+		ctx,
+		lo.Map(teams, func(item models.Team, _ int) int64 {
+			return item.ID
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamBondsRepo.GetAllNotRedeemedByTeamIDs: %w", err)
+	}
+	teamBondsByTeamID := lo.GroupBy(teamBonds, func(item models.TeamBond) int64 {
+		return item.TeamID
+	})
+
+	result := make([]models.BondPayment, 0)
+	for _, team := range teams {
+		bonds := lo.Filter(teamBondsByTeamID[team.ID], func(item models.TeamBond, _ int) bool {
+			return item.PurchaseRound < round
+		})
+		if len(bonds) == 0 {
+			continue
+		}
+
+		var payments []models.BondPayment
+		if err = s.withinTxRetry(ctx, func(ctx context.Context) error {
+			var err error
+			payments, err = s.payTeamBondCoupons(ctx, team.BalanceID, round, bonds)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("s.withinTxRetry: %w", err)
+		}
+		result = append(result, payments...)
+	}
+
+	return result, nil
+}
+
+func (s *Service) payTeamBondCoupons(
+	ctx context.Context,
+	balanceID int64,
+	round int,
+	bonds []models.TeamBond,
+) ([]models.BondPayment, error) {
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, balanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	if slices.ContainsFunc(transactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeBondCoupon ||
+			item.Type == models.BalanceTransactionTypeBondRedemption
+	}) {
+		return nil, nil
+	}
+
+	var (
+		coupons    int64
+		principals int64
+	)
+	payments := make([]models.BondPayment, 0, len(bonds))
+	for _, item := range bonds {
+		payment := models.BondPayment{
+			TeamID:     item.TeamID,
+			TeamBondID: item.ID,
+			Round:      round,
+			Coupon:     item.Coupon(),
+		}
+		if round >= item.MaturityRound {
+			payment.Principal = item.Principal()
+			item.Redeemed = true
+			if err = s.teamBondsRepo.Update(ctx, &item); err != nil {
+				return nil, fmt.Errorf("s.teamBondsRepo.Update: %w", err)
+			}
+		}
+		coupons += payment.Coupon
+		principals += payment.Principal
+		payments = append(payments, payment)
+	}
+
+	for _, item := range []struct {
+		trType models.BalanceTransactionType
+		amount int64
+	}{
+		{trType: models.BalanceTransactionTypeBondCoupon, amount: coupons},
+		{trType: models.BalanceTransactionTypeBondRedemption, amount: principals},
+	} {
+		if item.amount == 0 {
+			continue
+		}
+		if _, err = s.balanceTransactionsRepo.Create(
+			ctx,
+			&models.BalanceTransaction{
+				BalanceID:        balance.ID,
+				Type:             item.trType,
+				Round:            round,
+				Amount:           -item.amount,
+				Details:          nil,
+				AdditionalInfoID: nil,
+				RandomEventID:    nil,
+			},
+		); err != nil {
+			return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+		}
+	}
+
+	balance.Amount += coupons + principals
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+	return payments, nil
+}
@@ -0,0 +1,319 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"maps"
+)
+
+// tradeCharges — комиссия и налог с прибыли, списываемые за одну сделку.
+type tradeCharges struct {
+	commission int64
+	tax        int64
+}
+
+func (c tradeCharges) total() int64 {
+	return c.commission + c.tax
+}
+
+type getTradeChargesParams struct {
+	// team — команда с загруженными средними ценами покупки, см. loadAveragePrices.
+	team *models.Team
+	// positions — позиции команды до сделки.
+	positions models.TeamSharesState
+	changes   map[int64]int64
+	prices    map[int64]int64
+	settings  *models.Settings
+}
+
+// getTradeCharges считает комиссию с оборота сделки и налог с прибыли от продажи длинных позиций
+// дороже средней цены покупки. Закрытие короткой позиции налогом не облагается.
+func (s *Service) getTradeCharges(ctx context.Context, params getTradeChargesParams) (tradeCharges, error) {
+	var (
+		charges  tradeCharges
+		turnover int64
+	)
+	for companyID, count := range params.changes {
+		turnover += params.prices[companyID] * max(count, -count)
+	}
+	if turnover > 0 {
+		charges.commission = params.settings.CommissionFlat + turnover*params.settings.CommissionPercent/100
+	}
+
+	charges.tax = getCapitalGainsTax(
+		params.positions,
+		params.changes,
+		params.prices,
+		params.team.AveragePrices,
+		params.settings.CapitalGainsTaxPercent,
+	)
+	return charges, nil
+}
+
+// getCapitalGainsTax считает налог с прибыли от продажи длинных позиций positions дороже средней цены
+// покупки avgPrices. Закрытие короткой позиции налогом не облагается.
+func getCapitalGainsTax(
+	positions models.TeamSharesState,
+	changes map[int64]int64,
+	prices map[int64]int64,
+	avgPrices models.TeamAveragePrices,
+	taxPercent int64,
+) int64 {
+	var gain int64
+	for companyID, count := range changes {
+		if count >= 0 {
+			continue
+		}
+		sold := min(-count, max(positions[companyID], 0))
+		if diff := prices[companyID] - avgPrices[companyID]; sold > 0 && diff > 0 {
+			gain += diff * sold
+		}
+	}
+	return gain * taxPercent / 100
+}
+
+// loadAveragePrices заполняет средние цены покупки команды, созданной до их появления, по истории сделок.
+func (s *Service) loadAveragePrices(ctx context.Context, team *models.Team) error {
+	if team.AveragePrices != nil {
+		return nil
+	}
+	prices, err := s.restoreAveragePrices(ctx, team)
+	if err != nil {
+		return fmt.Errorf("s.restoreAveragePrices: %w", err)
+	}
+	team.AveragePrices = prices
+	return nil
+}
+
+// restoreAveragePrices восстанавливает средние цены покупки длинных позиций, проигрывая историю сделок команды.
+func (s *Service) restoreAveragePrices(ctx context.Context, team *models.Team) (models.TeamAveragePrices, error) {
+	prices, err := s.replayTrades(ctx, team, nil)
+	if err != nil {
+		return nil, fmt.Errorf("s.replayTrades: %w", err)
+	}
+	return prices, nil
+}
+
+// tradeReplayFunc получает сделку tr, позиции и средние цены покупки команды до неё и цены, по которым она прошла.
+type tradeReplayFunc func(
+	tr models.BalanceTransaction,
+	positions models.TeamSharesState,
+	avgPrices models.TeamAveragePrices,
+	prices map[int64]int64,
+) error
+
+// replayTrades проигрывает историю сделок команды, вызывая onTrade для каждой сделки, и возвращает средние
+// цены покупки после неё. Нужна только командам без сохранённых цен и после отмены сделок,
+// цены раундов загружаются один раз.
+func (s *Service) replayTrades(
+	ctx context.Context,
+	team *models.Team,
+	onTrade tradeReplayFunc,
+) (models.TeamAveragePrices, error) {
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceID(ctx, team.BalanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceID: %w", err)
+	}
+
+	var (
+		positions = make(models.TeamSharesState)
+		avgPrices = make(models.TeamAveragePrices)
+		// roundPrices: ключ - раунд, значение - цены акций компаний раунда
+		roundPrices = make(map[int]map[int64]int64)
+	)
+	for _, tr := range transactions {
+		switch tr.Type {
+		case models.BalanceTransactionTypeShares,
+			models.BalanceTransactionTypeMarginCall,
+			models.BalanceTransactionTypeMarketFill:
+		default:
+			continue
+		}
+		prices, err := s.getHistoryPrices(ctx, team, tr, roundPrices)
+		if err != nil {
+			return nil, fmt.Errorf("s.getHistoryPrices: %w", err)
+		}
+		if onTrade != nil {
+			if err = onTrade(tr, positions, avgPrices, prices); err != nil {
+				return nil, fmt.Errorf("onTrade: %w", err)
+			}
+		}
+		avgPrices.ApplyTrade(positions, tr.Details, prices)
+		positions.ApplyChanges(tr.Details)
+	}
+	return avgPrices, nil
+}
+
+// getRoundPrices возвращает цены акций компаний из counts в раунде round игры команды:
+// ключ - ID компании, значение - цена.
+func (s *Service) getRoundPrices(
+	ctx context.Context,
+	team *models.Team,
+	counts map[int64]int64,
+	round int,
+) (map[int64]int64, error) {
+	shares, err := s.sharesRepo.GetListForGameByCompanyIDsAndRound(
+		ctx,
+		team.SessionID,
+		team.GameID,
+		lo.Keys(counts),
+		round,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.sharesRepo.GetListForGameByCompanyIDsAndRound: %w", err)
+	}
+
+	prices := make(map[int64]int64, len(shares))
+	for _, share := range shares {
+		prices[share.CompanyID] = share.Price
+	}
+	return prices, nil
+}
+
+// getChargesAmount возвращает сумму комиссий и налогов из transactions, списанных за сделку parentID.
+func getChargesAmount(transactions []models.BalanceTransaction, parentID int64) int64 {
+	var amount int64
+	for _, tr := range transactions {
+		if tr.ParentID != nil && *tr.ParentID == parentID {
+			amount += tr.Amount
+		}
+	}
+	return amount
+}
+
+type createTradeChargesParams struct {
+	balanceID int64
+	round     int
+	parentID  int64
+	charges   tradeCharges
+}
+
+// createTradeCharges записывает комиссию и налог отдельными транзакциями, баланс не изменяет.
+func (s *Service) createTradeCharges(ctx context.Context, params createTradeChargesParams) error {
+	for _, item := range []struct {
+		trType models.BalanceTransactionType
+		amount int64
+	}{
+		{trType: models.BalanceTransactionTypeCommission, amount: params.charges.commission},
+		{trType: models.BalanceTransactionTypeCapitalGainsTax, amount: params.charges.tax},
+	} {
+		if item.amount == 0 {
+			continue
+		}
+		if _, err := s.balanceTransactionsRepo.Create(
+			ctx,
+			&models.BalanceTransaction{
+				BalanceID:        params.balanceID,
+				Type:             item.trType,
+				Round:            params.round,
+				Amount:           item.amount,
+				Details:          nil,
+				AdditionalInfoID: nil,
+				RandomEventID:    nil,
+				ParentID:         &params.parentID,
+			},
+		); err != nil {
+			return fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+		}
+	}
+	return nil
+}
+
+type recalculateRoundTaxesParams struct {
+	team    *models.Team
+	balance *models.Balance
+	round   int
+	// roundTransactions — транзакции раунда до отмены сделок, из них берутся записанные налоги.
+	roundTransactions []models.BalanceTransaction
+	firstResetID      int64
+	taxPercent        int64
+}
+
+// recalculateRoundTaxes пересчитывает средние цены покупки после отмены сделок раунда и налог с прибыли
+// сделок раунда, совершённых после первой отменённой: отмена меняет позиции и среднюю цену, от которых
+// он посчитан. Разница налога возвращается на баланс или списывается с него.
+func (s *Service) recalculateRoundTaxes(
+	ctx context.Context,
+	params recalculateRoundTaxesParams,
+) (models.TeamAveragePrices, error) {
+	taxByParentID := make(map[int64]*models.BalanceTransaction)
+	for _, tr := range params.roundTransactions {
+		if tr.Type == models.BalanceTransactionTypeCapitalGainsTax && tr.ParentID != nil {
+			taxByParentID[*tr.ParentID] = &tr
+		}
+	}
+
+	avgPrices, err := s.replayTrades(
+		ctx,
+		params.team,
+		func(
+			tr models.BalanceTransaction,
+			positions models.TeamSharesState,
+			avgPrices models.TeamAveragePrices,
+			prices map[int64]int64,
+		) error {
+			// принудительное закрытие коротких позиций налогом не облагается
+			if tr.Round != params.round || tr.ID < params.firstResetID || tr.Type == models.BalanceTransactionTypeMarginCall {
+				return nil
+			}
+			tax := getCapitalGainsTax(positions, tr.Details, prices, avgPrices, params.taxPercent)
+			if err := s.replaceTradeTax(ctx, params.balance, tr, taxByParentID[tr.ID], tax); err != nil {
+				return fmt.Errorf("s.replaceTradeTax: %w", err)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.replayTrades: %w", err)
+	}
+	return avgPrices, nil
+}
+
+// replaceTradeTax заменяет налог с прибыли сделки tr, записанный транзакцией current, на tax
+// и возвращает разницу на баланс.
+func (s *Service) replaceTradeTax(
+	ctx context.Context,
+	balance *models.Balance,
+	tr models.BalanceTransaction,
+	current *models.BalanceTransaction,
+	tax int64,
+) error {
+	var currentTax int64
+	if current != nil {
+		currentTax = current.Amount
+	}
+	if currentTax == tax {
+		return nil
+	}
+
+	if current != nil {
+		if err := s.balanceTransactionsRepo.Delete(ctx, current.ID); err != nil {
+			return fmt.Errorf("s.balanceTransactionsRepo.Delete: %w", err)
+		}
+	}
+	if err := s.createTradeCharges(
+		ctx,
+		createTradeChargesParams{
+			balanceID: balance.ID,
+			round:     tr.Round,
+			parentID:  tr.ID,
+			charges:   tradeCharges{tax: tax},
+		},
+	); err != nil {
+		return fmt.Errorf("s.createTradeCharges: %w", err)
+	}
+	balance.Amount += currentTax - tax
+	return nil
+}
+
+// positionsBeforeTrade возвращает позиции команды без учёта изменений сделки changes.
+func positionsBeforeTrade(shares models.TeamSharesState, changes map[int64]int64) models.TeamSharesState {
+	positions := make(models.TeamSharesState, len(shares))
+	maps.Copy(positions, shares)
+	for companyID, count := range changes {
+		positions[companyID] -= count
+	}
+	return positions
+}
@@ -0,0 +1,88 @@
+package teams
+
+import (
+	"investment-game-backend/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestGetCapitalGainsTax(t *testing.T) {
+	tests := []struct {
+		name       string
+		positions  models.TeamSharesState
+		changes    map[int64]int64
+		prices     map[int64]int64
+		avgPrices  models.TeamAveragePrices
+		taxPercent int64
+		want       int64
+	}{
+		{
+			name:       "purchase is not taxed",
+			positions:  models.TeamSharesState{1: 10},
+			changes:    map[int64]int64{1: 5},
+			prices:     map[int64]int64{1: 200},
+			avgPrices:  models.TeamAveragePrices{1: 100},
+			taxPercent: 10,
+			want:       0,
+		},
+		{
+			name:       "sale with gain",
+			positions:  models.TeamSharesState{1: 10},
+			changes:    map[int64]int64{1: -4},
+			prices:     map[int64]int64{1: 150},
+			avgPrices:  models.TeamAveragePrices{1: 100},
+			taxPercent: 10,
+			want:       20,
+		},
+		{
+			name:       "sale with loss",
+			positions:  models.TeamSharesState{1: 10},
+			changes:    map[int64]int64{1: -4},
+			prices:     map[int64]int64{1: 90},
+			avgPrices:  models.TeamAveragePrices{1: 100},
+			taxPercent: 10,
+			want:       0,
+		},
+		{
+			name:       "short part of sale is not taxed",
+			positions:  models.TeamSharesState{1: 3},
+			changes:    map[int64]int64{1: -10},
+			prices:     map[int64]int64{1: 200},
+			avgPrices:  models.TeamAveragePrices{1: 100},
+			taxPercent: 10,
+			want:       30,
+		},
+		{
+			name:       "gains and losses by company are not netted",
+			positions:  models.TeamSharesState{1: 10, 2: 10},
+			changes:    map[int64]int64{1: -10, 2: -10},
+			prices:     map[int64]int64{1: 120, 2: 50},
+			avgPrices:  models.TeamAveragePrices{1: 100, 2: 100},
+			taxPercent: 50,
+			want:       100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getCapitalGainsTax(tt.positions, tt.changes, tt.prices, tt.avgPrices, tt.taxPercent)
+			if got != tt.want {
+				t.Errorf("getCapitalGainsTax() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionsBeforeTrade(t *testing.T) {
+	shares := models.TeamSharesState{1: 10, 2: -3}
+	changes := map[int64]int64{1: 4, 2: -5, 3: 2}
+
+	got := positionsBeforeTrade(shares, changes)
+
+	want := models.TeamSharesState{1: 6, 2: 2, 3: -2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("positionsBeforeTrade() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(shares, models.TeamSharesState{1: 10, 2: -3}) {
+		t.Errorf("positionsBeforeTrade() changed shares: %v", shares)
+	}
+}
@@ -0,0 +1,163 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"slices"
+)
+
+// PayDividends начисляет командам текущей игры сессии дивиденды за раунд round пропорционально
+// их позициям. Короткие позиции выплачивают дивиденд, недостающая для выплаты сумма оформляется
+// как кредит. Команды, которым дивиденды за раунд уже начислены, пропускаются, поэтому повторная
+// остановка раунда не дублирует выплаты.
+func (s *Service) PayDividends(ctx context.Context, sessionID int64, round int) ([]models.DividendPayout, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+
+	dividends, err := s.getRoundDividends(ctx, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.getRoundDividends: %w", err)
+	}
+	if len(dividends) == 0 {
+		return []models.DividendPayout{}, nil
+	}
+
+	result := make([]models.DividendPayout, 0)
+	for _, team := range teams {
+		var payout *models.DividendPayout
+		if err = s.withinTxRetry(ctx, func(ctx context.Context) error {
+			var err error
+			payout, err = s.payTeamDividends(ctx, team.ID, round, dividends)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("s.withinTxRetry: %w", err)
+		}
+		if payout == nil {
+			continue
+		}
+
+		s.log.Trace().
+			Int64("team_id", payout.TeamID).
+			Int64("amount", payout.Amount).
+			Msg("dividends paid")
+		result = append(result, *payout)
+	}
+
+	return result, nil
+}
+
+// getRoundDividends возвращает ненулевые дивиденды раунда: ключ - ID компании, значение - дивиденд на акцию.
+func (s *Service) getRoundDividends(ctx context.Context, round int) (map[int64]int64, error) {
+	shares, err := s.sharesRepo.GetAllActual(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.sharesRepo.GetAllActual: %w", err)
+	}
+
+	dividends := make(map[int64]int64)
+	for _, share := range shares {
+		if share.Round == round && share.Dividend != 0 {
+			dividends[share.CompanyID] = share.Dividend
+		}
+	}
+	return dividends, nil
+}
+
+func (s *Service) payTeamDividends(
+	ctx context.Context,
+	teamID int64,
+	round int,
+	dividends map[int64]int64,
+) (*models.DividendPayout, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	if slices.ContainsFunc(transactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeDividend
+	}) {
+		return nil, nil
+	}
+
+	var amount int64
+	shares := make(map[int64]int64)
+	for companyID, count := range team.Shares {
+		dividend, ok := dividends[companyID]
+		if !ok || count == 0 {
+			continue
+		}
+		shares[companyID] = count
+		amount += dividend * count
+	}
+	if len(shares) == 0 {
+		return nil, nil
+	}
+
+	if _, err = s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeDividend,
+			Round:            round,
+			Amount:           -amount,
+			Details:          shares,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+		},
+	); err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+
+	balance.Amount += amount
+
+	// дивиденд по коротким позициям может превысить баланс: недостающая сумма оформляется как кредит,
+	// чтобы баланс не уходил в минус, а сумма транзакций сходилась с балансом
+	capitalized := max(-balance.Amount, 0)
+	if capitalized != 0 {
+		if _, err = s.balanceTransactionsRepo.Create(
+			ctx,
+			&models.BalanceTransaction{
+				BalanceID:        balance.ID,
+				Type:             models.BalanceTransactionTypeLoan,
+				Round:            round,
+				Amount:           -capitalized,
+				Details:          nil,
+				AdditionalInfoID: nil,
+				RandomEventID:    nil,
+			},
+		); err != nil {
+			return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+		}
+		balance.Amount += capitalized
+		balance.Loan += capitalized
+	}
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+
+	return &models.DividendPayout{
+		TeamID:      team.ID,
+		Round:       round,
+		Shares:      shares,
+		Amount:      amount,
+		Capitalized: capitalized,
+	}, nil
+}
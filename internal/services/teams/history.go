@@ -0,0 +1,205 @@
+package teams
+
+import (
+	"context"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"maps"
+	"slices"
+)
+
+type TransactionHistoryFilter struct {
+	Round *int
+	Type  *models.BalanceTransactionType
+}
+
+// HistoryTransaction — транзакция команды с расшифровкой изменений акций.
+type HistoryTransaction struct {
+	TeamID   int64
+	TeamName string
+	// MemberName — имя участника, совершившего сделку, пусто для операций без участника.
+	MemberName  string
+	Transaction models.BalanceTransaction
+	Shares      []HistoryTransactionShare
+}
+
+// HistoryTransactionShare — изменение позиции по компании и цена одной акции в сделке.
+type HistoryTransactionShare struct {
+	CompanyID   int64
+	CompanyName string
+	Count       int64
+	Price       int64
+}
+
+// GetTransactionHistory возвращает все транзакции команды за игру в порядке создания.
+func (s *Service) GetTransactionHistory(
+	ctx context.Context,
+	teamID int64,
+	filter TransactionHistoryFilter,
+) ([]HistoryTransaction, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	history, err := s.getTransactionHistory(ctx, []models.Team{*team}, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.getTransactionHistory: %w", err)
+	}
+	return history, nil
+}
+
+// GetGameTransactionHistory возвращает транзакции всех команд текущей игры сессии в порядке создания.
+func (s *Service) GetGameTransactionHistory(
+	ctx context.Context,
+	sessionID int64,
+	filter TransactionHistoryFilter,
+) ([]HistoryTransaction, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+
+	history, err := s.getTransactionHistory(ctx, teams, filter)
+	if err != nil {
+		return nil, fmt.Errorf("s.getTransactionHistory: %w", err)
+	}
+	return history, nil
+}
+
+func (s *Service) getTransactionHistory(
+	ctx context.Context,
+	teams []models.Team,
+	filter TransactionHistoryFilter,
+) ([]HistoryTransaction, error) {
+	teamByBalanceID := make(map[int64]models.Team, len(teams))
+	memberNames := make(map[int64]string)
+	for _, team := range teams {
+		teamByBalanceID[team.BalanceID] = team
+
+		members, err := s.membersRepo.GetAllByTeamID(ctx, team.ID)
+		if err != nil {
+			return nil, fmt.Errorf("s.membersRepo.GetAllByTeamID: %w", err)
+		}
+		for _, member := range members {
+			memberNames[member.ID] = member.Name
+		}
+	}
+
+	transactions, err := s.balanceTransactionsRepo.GetList(
+		ctx,
+		models.BalanceTransactionFilter{
+			BalanceIDs: slices.Sorted(maps.Keys(teamByBalanceID)),
+			Round:      filter.Round,
+			Type:       filter.Type,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetList: %w", err)
+	}
+
+	var (
+		companyNames = make(map[int64]string)
+		// roundPrices: ключ - раунд, значение - цены акций компаний раунда
+		roundPrices = make(map[int]map[int64]int64)
+	)
+	result := make([]HistoryTransaction, 0, len(transactions))
+	for _, tr := range transactions {
+		team := teamByBalanceID[tr.BalanceID]
+		prices, err := s.getHistoryPrices(ctx, &team, tr, roundPrices)
+		if err != nil {
+			return nil, fmt.Errorf("s.getHistoryPrices: %w", err)
+		}
+
+		shares := make([]HistoryTransactionShare, 0, len(tr.Details))
+		for _, companyID := range slices.Sorted(maps.Keys(tr.Details)) {
+			name, ok := companyNames[companyID]
+			if !ok {
+				company, err := s.companiesRepo.GetByID(ctx, companyID)
+				if err != nil {
+					return nil, fmt.Errorf("s.companiesRepo.GetByID: %w", err)
+				}
+				name = company.Name
+				companyNames[companyID] = name
+			}
+			shares = append(shares, HistoryTransactionShare{
+				CompanyID:   companyID,
+				CompanyName: name,
+				Count:       tr.Details[companyID],
+				Price:       prices[companyID],
+			})
+		}
+
+		var memberName string
+		if tr.MemberID != nil {
+			memberName = memberNames[*tr.MemberID]
+		}
+		result = append(result, HistoryTransaction{
+			TeamID:      team.ID,
+			TeamName:    team.Name,
+			MemberName:  memberName,
+			Transaction: tr,
+			Shares:      shares,
+		})
+	}
+	return result, nil
+}
+
+// getHistoryPrices возвращает цены акций, по которым прошла сделка tr. Сделки из стакана
+// исполняются по цене заявки, для дивидендов возвращается дивиденд на акцию, остальные
+// сделки — по цене раунда игры команды, которая кэшируется в roundPrices.
+func (s *Service) getHistoryPrices(
+	ctx context.Context,
+	team *models.Team,
+	tr models.BalanceTransaction,
+	roundPrices map[int]map[int64]int64,
+) (map[int64]int64, error) {
+	if len(tr.Details) == 0 {
+		return nil, nil
+	}
+
+	if tr.Type == models.BalanceTransactionTypeMarketFill {
+		prices := make(map[int64]int64, len(tr.Details))
+		for companyID, count := range tr.Details {
+			prices[companyID] = max(tr.Amount, -tr.Amount) / max(count, -count)
+		}
+		return prices, nil
+	}
+	if tr.Type == models.BalanceTransactionTypeDividend {
+		shares, err := s.sharesRepo.GetListByCompanyIDsAndRound(ctx, slices.Collect(maps.Keys(tr.Details)), tr.Round)
+		if err != nil {
+			return nil, fmt.Errorf("s.sharesRepo.GetListByCompanyIDsAndRound: %w", err)
+		}
+		prices := make(map[int64]int64, len(shares))
+		for _, share := range shares {
+			prices[share.CompanyID] = share.Dividend
+		}
+		return prices, nil
+	}
+
+	prices, ok := roundPrices[tr.Round]
+	if !ok {
+		prices = make(map[int64]int64)
+		roundPrices[tr.Round] = prices
+	}
+	missed := make(map[int64]int64)
+	for companyID, count := range tr.Details {
+		if _, ok = prices[companyID]; !ok {
+			missed[companyID] = count
+		}
+	}
+	if len(missed) != 0 {
+		loaded, err := s.getRoundPrices(ctx, team, missed, tr.Round)
+		if err != nil {
+			return nil, fmt.Errorf("s.getRoundPrices: %w", err)
+		}
+		for companyID := range missed {
+			prices[companyID] = loaded[companyID]
+		}
+	}
+	return prices, nil
+}
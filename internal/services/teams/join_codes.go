@@ -0,0 +1,196 @@
+package teams
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"math/big"
+	"time"
+)
+
+var (
+	ErrInvalidJoinCodeParams = errors.New("invalid join code params")
+	ErrJoinCodeNotFound      = errors.New("join code not found")
+	ErrJoinCodeInactive      = errors.New("join code is expired, revoked or used up")
+)
+
+const (
+	// joinCodeAlphabet не содержит похожих символов (0/O, 1/I/L), код набирают вручную с доски.
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 8
+	maxJoinCodes     = 100
+)
+
+type CreateJoinCodesParams struct {
+	SessionID int64
+	Count     int
+	// MaxUses — сколько команд может зарегистрироваться по одному коду, 0 означает один раз.
+	MaxUses int
+	// TTL — срок действия кодов, 0 — бессрочные.
+	TTL time.Duration
+}
+
+func (params CreateJoinCodesParams) Validate() error {
+	if params.Count <= 0 || params.Count > maxJoinCodes {
+		return fmt.Errorf("%w: count must be in [1, %d]", ErrInvalidJoinCodeParams, maxJoinCodes)
+	}
+	if params.MaxUses < 0 {
+		return fmt.Errorf("%w: max uses cannot be negative", ErrInvalidJoinCodeParams)
+	}
+	if params.TTL < 0 {
+		return fmt.Errorf("%w: ttl cannot be negative", ErrInvalidJoinCodeParams)
+	}
+	return nil
+}
+
+// CreateJoinCodes выпускает коды регистрации для текущей игры сессии.
+func (s *Service) CreateJoinCodes(ctx context.Context, params CreateJoinCodesParams) ([]models.JoinCode, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+	maxUses := params.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+
+	game, err := s.gamesRepo.Get(ctx, params.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if params.TTL > 0 {
+		expiresAt = new(time.Time)
+		*expiresAt = now.Add(params.TTL)
+	}
+
+	codes := make([]models.JoinCode, 0, params.Count)
+	if err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		for range params.Count {
+			value, err := newJoinCode()
+			if err != nil {
+				return fmt.Errorf("newJoinCode: %w", err)
+			}
+			code := models.JoinCode{
+				CreatedAt: now,
+				SessionID: game.ID,
+				GameID:    game.CurrentGame,
+				Code:      value,
+				ExpiresAt: expiresAt,
+				MaxUses:   maxUses,
+			}
+			if code.ID, err = s.joinCodesRepo.Create(ctx, &code); err != nil {
+				return fmt.Errorf("s.joinCodesRepo.Create: %w", err)
+			}
+			codes = append(codes, code)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.txManager.WithinTx: %w", err)
+	}
+	return codes, nil
+}
+
+// GetJoinCodes возвращает все коды текущей игры сессии, включая неактивные.
+func (s *Service) GetJoinCodes(ctx context.Context, sessionID int64) ([]models.JoinCode, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	codes, err := s.joinCodesRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.joinCodesRepo.GetAllByGameID: %w", err)
+	}
+	return codes, nil
+}
+
+// GetJoinCode возвращает код, только если он выпущен в сессии sessionID.
+func (s *Service) GetJoinCode(ctx context.Context, sessionID int64, id int64) (*models.JoinCode, error) {
+	code, err := s.joinCodesRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrJoinCodeNotFound
+		}
+		return nil, fmt.Errorf("s.joinCodesRepo.GetByID: %w", err)
+	}
+	if code.SessionID != sessionID {
+		return nil, ErrJoinCodeNotFound
+	}
+	return code, nil
+}
+
+func (s *Service) RevokeJoinCode(ctx context.Context, sessionID int64, id int64) error {
+	if _, err := s.GetJoinCode(ctx, sessionID, id); err != nil {
+		return fmt.Errorf("s.GetJoinCode: %w", err)
+	}
+	// повторный отзыв ничего не меняет и ошибкой не считается
+	if err := s.joinCodesRepo.Revoke(ctx, id); err != nil && !errors.Is(err, repo.ErrNothingUpdated) {
+		return fmt.Errorf("s.joinCodesRepo.Revoke: %w", err)
+	}
+	return nil
+}
+
+type CreateByJoinCodeParams struct {
+	Code     string
+	Name     string
+	Password string
+}
+
+// CreateByJoinCode регистрирует команду в игре, для которой выпущен код. Код блокируется
+// до конца транзакции, поэтому лимит использований не превышается при одновременной регистрации.
+// Период регистрации проверяется так же, как при обычной регистрации.
+func (s *Service) CreateByJoinCode(ctx context.Context, params CreateByJoinCodeParams) (int64, error) {
+	var teamID int64
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		code, err := s.joinCodesRepo.GetByCodeForUpdate(ctx, params.Code)
+		if err != nil {
+			if errors.Is(err, repo.ErrNotFound) {
+				return ErrJoinCodeNotFound
+			}
+			return fmt.Errorf("s.joinCodesRepo.GetByCodeForUpdate: %w", err)
+		}
+		if !code.IsActive(time.Now()) {
+			return ErrJoinCodeInactive
+		}
+		game, err := s.gamesRepo.Get(ctx, code.SessionID)
+		if err != nil {
+			return fmt.Errorf("s.gamesRepo.Get: %w", err)
+		}
+		// коды прошлых игр сессии не действуют после перезапуска игры
+		if code.GameID != game.CurrentGame {
+			return ErrJoinCodeInactive
+		}
+
+		if teamID, err = s.Create(ctx, CreateParams{
+			SessionID: code.SessionID,
+			Name:      params.Name,
+			Password:  params.Password,
+		}); err != nil {
+			return fmt.Errorf("s.Create: %w", err)
+		}
+		if err = s.joinCodesRepo.IncrementUses(ctx, code.ID); err != nil {
+			return fmt.Errorf("s.joinCodesRepo.IncrementUses: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("s.txManager.WithinTx: %w", err)
+	}
+	return teamID, nil
+}
+
+func newJoinCode() (string, error) {
+	alphabetLen := big.NewInt(int64(len(joinCodeAlphabet)))
+	code := make([]byte, joinCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetLen)
+		if err != nil {
+			return "", fmt.Errorf("rand.Int: %w", err)
+		}
+		code[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,280 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"slices"
+)
+
+var (
+	ErrInvalidLoanAmount   = errors.New("invalid loan amount")
+	ErrCreditLimitExceeded = errors.New("credit limit exceeded")
+)
+
+type LoanParams struct {
+	TeamID int64
+	Amount int64
+}
+
+func (params LoanParams) Validate() error {
+	if params.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidLoanAmount)
+	}
+	return nil
+}
+
+// Borrow выдаёт команде кредит в период торгов, если общий долг не превысит Settings.CreditLimit.
+// Возвращает баланс команды после операции.
+func (s *Service) Borrow(ctx context.Context, params LoanParams) (models.Balance, error) {
+	if err := params.Validate(); err != nil {
+		return models.Balance{}, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	var balance models.Balance
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		var err error
+		balance, err = s.borrow(ctx, params)
+		return err
+	}); err != nil {
+		return models.Balance{}, fmt.Errorf("s.withinTxRetry: %w", err)
+	}
+
+	s.notifyBalanceChanged(ctx, params.TeamID)
+	return balance, nil
+}
+
+func (s *Service) borrow(ctx context.Context, params LoanParams) (models.Balance, error) {
+	balance, round, err := s.getBalanceForLoanOperation(ctx, params.TeamID)
+	if err != nil {
+		return models.Balance{}, fmt.Errorf("s.getBalanceForLoanOperation: %w", err)
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return models.Balance{}, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if balance.Loan+params.Amount > settings.CreditLimit {
+		return models.Balance{}, ErrCreditLimitExceeded
+	}
+
+	if _, err = s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeLoan,
+			Round:            round,
+			Amount:           -params.Amount,
+			Details:          nil,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+		},
+	); err != nil {
+		return models.Balance{}, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+
+	balance.Amount += params.Amount
+	balance.Loan += params.Amount
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return models.Balance{}, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+	return *balance, nil
+}
+
+// Repay погашает часть долга команды в период торгов. Погасить больше текущего долга нельзя.
+// Возвращает баланс команды после операции.
+func (s *Service) Repay(ctx context.Context, params LoanParams) (models.Balance, error) {
+	if err := params.Validate(); err != nil {
+		return models.Balance{}, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	var balance models.Balance
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		var err error
+		balance, err = s.repay(ctx, params)
+		return err
+	}); err != nil {
+		return models.Balance{}, fmt.Errorf("s.withinTxRetry: %w", err)
+	}
+
+	s.notifyBalanceChanged(ctx, params.TeamID)
+	return balance, nil
+}
+
+func (s *Service) repay(ctx context.Context, params LoanParams) (models.Balance, error) {
+	balance, round, err := s.getBalanceForLoanOperation(ctx, params.TeamID)
+	if err != nil {
+		return models.Balance{}, fmt.Errorf("s.getBalanceForLoanOperation: %w", err)
+	}
+
+	if params.Amount > balance.Loan {
+		return models.Balance{}, fmt.Errorf("%w: amount exceeds debt %d", ErrInvalidLoanAmount, balance.Loan)
+	}
+	if balance.Available()-params.Amount < 0 {
+		return models.Balance{}, ErrNoMoneyForOperation
+	}
+
+	if _, err = s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeLoanRepayment,
+			Round:            round,
+			Amount:           params.Amount,
+			Details:          nil,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+		},
+	); err != nil {
+		return models.Balance{}, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+
+	balance.Amount -= params.Amount
+	balance.Loan -= params.Amount
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return models.Balance{}, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+	return *balance, nil
+}
+
+// getBalanceForLoanOperation проверяет, что сейчас можно брать и гасить кредиты,
+// и блокирует баланс команды. Возвращает баланс и текущий раунд.
+func (s *Service) getBalanceForLoanOperation(ctx context.Context, teamID int64) (*models.Balance, int, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	if !s.isTradePeriodFor(team.SessionID) {
+		return nil, 0, ErrIsNoTradePeriod
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return nil, 0, ErrGamePaused
+	}
+
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	return balance, game.CurrentRound, nil
+}
+
+// ChargeLoanInterest начисляет в начале раунда round проценты по долгам команд текущей игры сессии.
+// Если свободных средств не хватает, неоплаченная часть процентов добавляется к долгу, даже сверх
+// Settings.CreditLimit. Повторный вызов за тот же раунд проценты не дублирует.
+func (s *Service) ChargeLoanInterest(ctx context.Context, sessionID int64, round int) ([]models.LoanInterest, error) {
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if settings.LoanInterestPercent == 0 {
+		return []models.LoanInterest{}, nil
+	}
+
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+
+	result := make([]models.LoanInterest, 0)
+	for _, team := range teams {
+		var interest *models.LoanInterest
+		if err = s.withinTxRetry(ctx, func(ctx context.Context) error {
+			var err error
+			interest, err = s.chargeTeamLoanInterest(ctx, team, round, settings.LoanInterestPercent)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("s.withinTxRetry: %w", err)
+		}
+		if interest == nil {
+			continue
+		}
+		result = append(result, *interest)
+	}
+
+	return result, nil
+}
+
+func (s *Service) chargeTeamLoanInterest(
+	ctx context.Context,
+	team models.Team,
+	round int,
+	interestPercent int64,
+) (*models.LoanInterest, error) {
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	if balance.Loan == 0 {
+		return nil, nil
+	}
+
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	if slices.ContainsFunc(transactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeLoanInterest
+	}) {
+		return nil, nil
+	}
+
+	amount := balance.Loan * interestPercent / 100
+	if amount == 0 {
+		return nil, nil
+	}
+	// неоплаченная часть процентов оформляется как новый кредит, чтобы сумма транзакций сходилась с балансом
+	capitalized := getCapitalizedInterest(balance.Available(), amount)
+
+	for _, item := range []struct {
+		trType models.BalanceTransactionType
+		amount int64
+	}{
+		{trType: models.BalanceTransactionTypeLoan, amount: -capitalized},
+		{trType: models.BalanceTransactionTypeLoanInterest, amount: amount},
+	} {
+		if item.amount == 0 {
+			continue
+		}
+		if _, err = s.balanceTransactionsRepo.Create(
+			ctx,
+			&models.BalanceTransaction{
+				BalanceID:        balance.ID,
+				Type:             item.trType,
+				Round:            round,
+				Amount:           item.amount,
+				Details:          nil,
+				AdditionalInfoID: nil,
+				RandomEventID:    nil,
+			},
+		); err != nil {
+			return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+		}
+	}
+
+	balance.Amount += capitalized - amount
+	balance.Loan += capitalized
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+
+	return &models.LoanInterest{
+		TeamID:      team.ID,
+		Round:       round,
+		Amount:      amount,
+		Capitalized: capitalized,
+	}, nil
+}
+
+// getCapitalizedInterest возвращает часть процентов amount, которую не покрывают свободные средства available.
+func getCapitalizedInterest(available, amount int64) int64 {
+	return amount - max(min(available, amount), 0)
+}
@@ -0,0 +1,25 @@
+package teams
+
+import "testing"
+
+func TestGetCapitalizedInterest(t *testing.T) {
+	tests := []struct {
+		name      string
+		available int64
+		amount    int64
+		want      int64
+	}{
+		{name: "paid in full", available: 500, amount: 100, want: 0},
+		{name: "exactly enough", available: 100, amount: 100, want: 0},
+		{name: "partly paid", available: 30, amount: 100, want: 70},
+		{name: "nothing available", available: 0, amount: 100, want: 100},
+		{name: "negative available", available: -50, amount: 100, want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getCapitalizedInterest(tt.available, tt.amount); got != tt.want {
+				t.Errorf("getCapitalizedInterest() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
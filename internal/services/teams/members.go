@@ -0,0 +1,221 @@
+package teams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"investment-game-backend/pkg/password"
+)
+
+var (
+	ErrInvalidMemberParams = errors.New("invalid team member params")
+	ErrMemberNameTaken     = errors.New("team member name is already taken")
+	ErrMemberNotFound      = errors.New("team member not found")
+)
+
+type CreateMemberParams struct {
+	TeamID    int64
+	Name      string
+	Password  string
+	IsCaptain bool
+}
+
+func (params CreateMemberParams) Validate() error {
+	if params.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMemberParams)
+	}
+	if params.Password == "" {
+		return fmt.Errorf("%w: password is required", ErrInvalidMemberParams)
+	}
+	return nil
+}
+
+// CreateMember заводит участнику команды отдельный вход. Имя участника уникально в пределах команды.
+// С появлением первого участника общий вход команды теряет право торговать, поэтому его сессии
+// завершаются; сессии прежнего капитана завершаются при назначении нового.
+func (s *Service) CreateMember(ctx context.Context, params CreateMemberParams) (*models.TeamMember, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("params.Validate: %w", err)
+	}
+
+	passwordHash, err := password.Hash(params.Password)
+	if err != nil {
+		return nil, fmt.Errorf("password.Hash: %w", err)
+	}
+
+	member := &models.TeamMember{
+		TeamID:       params.TeamID,
+		Name:         params.Name,
+		PasswordHash: passwordHash,
+		IsCaptain:    params.IsCaptain,
+	}
+	var (
+		isFirstMember bool
+		demotedIDs    []int64
+	)
+	if err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.teamsRepo.GetByID(ctx, params.TeamID); err != nil {
+			return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+		}
+		members, err := s.membersRepo.GetAllByTeamID(ctx, params.TeamID)
+		if err != nil {
+			return fmt.Errorf("s.membersRepo.GetAllByTeamID: %w", err)
+		}
+		isFirstMember = len(members) == 0
+
+		_, err = s.membersRepo.GetByName(ctx, params.TeamID, params.Name)
+		if err == nil {
+			return ErrMemberNameTaken
+		}
+		if !errors.Is(err, repo.ErrNotFound) {
+			return fmt.Errorf("s.membersRepo.GetByName: %w", err)
+		}
+
+		if member.IsCaptain {
+			if demotedIDs, err = s.demoteCaptains(ctx, params.TeamID, 0); err != nil {
+				return fmt.Errorf("s.demoteCaptains: %w", err)
+			}
+		}
+		member.ID, err = s.membersRepo.Create(ctx, member)
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			return ErrMemberNameTaken
+		}
+		if err != nil {
+			return fmt.Errorf("s.membersRepo.Create: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.txManager.WithinTx: %w", err)
+	}
+
+	if isFirstMember {
+		if err = s.kickTeamSharedSessions(ctx, params.TeamID); err != nil {
+			return nil, fmt.Errorf("s.kickTeamSharedSessions: %w", err)
+		}
+	}
+	if err = s.kickTeamMembers(ctx, params.TeamID, demotedIDs); err != nil {
+		return nil, fmt.Errorf("s.kickTeamMembers: %w", err)
+	}
+	return member, nil
+}
+
+type UpdateMemberParams struct {
+	TeamID int64
+	ID     int64
+	// Password — новый пароль участника, пустой оставляет текущий.
+	Password  string
+	IsCaptain bool
+}
+
+// UpdateMember меняет пароль и права участника. Капитан в команде один: назначение нового
+// снимает права с прежнего. Сессии участников, потерявших права капитана, завершаются,
+// чтобы выданные им токены перестали давать право торговать.
+func (s *Service) UpdateMember(ctx context.Context, params UpdateMemberParams) (*models.TeamMember, error) {
+	var (
+		member     *models.TeamMember
+		demotedIDs []int64
+	)
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if member, err = s.getTeamMember(ctx, params.TeamID, params.ID); err != nil {
+			return fmt.Errorf("s.getTeamMember: %w", err)
+		}
+
+		if params.Password != "" {
+			if member.PasswordHash, err = password.Hash(params.Password); err != nil {
+				return fmt.Errorf("password.Hash: %w", err)
+			}
+		}
+		if params.IsCaptain && !member.IsCaptain {
+			if demotedIDs, err = s.demoteCaptains(ctx, params.TeamID, member.ID); err != nil {
+				return fmt.Errorf("s.demoteCaptains: %w", err)
+			}
+		}
+		if !params.IsCaptain && member.IsCaptain {
+			demotedIDs = append(demotedIDs, member.ID)
+		}
+		member.IsCaptain = params.IsCaptain
+
+		if err = s.membersRepo.Update(ctx, member); err != nil {
+			return fmt.Errorf("s.membersRepo.Update: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("s.txManager.WithinTx: %w", err)
+	}
+
+	if err := s.kickTeamMembers(ctx, params.TeamID, demotedIDs); err != nil {
+		return nil, fmt.Errorf("s.kickTeamMembers: %w", err)
+	}
+	return member, nil
+}
+
+func (s *Service) DeleteMember(ctx context.Context, teamID int64, id int64) error {
+	if _, err := s.getTeamMember(ctx, teamID, id); err != nil {
+		return fmt.Errorf("s.getTeamMember: %w", err)
+	}
+	if err := s.membersRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return ErrMemberNotFound
+		}
+		return fmt.Errorf("s.membersRepo.Delete: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetMembers(ctx context.Context, teamID int64) ([]models.TeamMember, error) {
+	members, err := s.membersRepo.GetAllByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.membersRepo.GetAllByTeamID: %w", err)
+	}
+	return members, nil
+}
+
+// getTeamMember возвращает участника, только если он состоит в команде teamID.
+func (s *Service) getTeamMember(ctx context.Context, teamID int64, id int64) (*models.TeamMember, error) {
+	member, err := s.membersRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("s.membersRepo.GetByID: %w", err)
+	}
+	if member.TeamID != teamID {
+		return nil, ErrMemberNotFound
+	}
+	return member, nil
+}
+
+// demoteCaptains снимает права капитана со всех участников команды, кроме exceptID,
+// и возвращает ID участников, лишившихся прав.
+func (s *Service) demoteCaptains(ctx context.Context, teamID int64, exceptID int64) ([]int64, error) {
+	members, err := s.membersRepo.GetAllByTeamID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.membersRepo.GetAllByTeamID: %w", err)
+	}
+	var demotedIDs []int64
+	for _, member := range members {
+		if !member.IsCaptain || member.ID == exceptID {
+			continue
+		}
+		member.IsCaptain = false
+		if err = s.membersRepo.Update(ctx, &member); err != nil {
+			return nil, fmt.Errorf("s.membersRepo.Update: %w", err)
+		}
+		demotedIDs = append(demotedIDs, member.ID)
+	}
+	return demotedIDs, nil
+}
+
+// kickTeamMembers завершает сессии участников после фиксации транзакции, в которой они
+// лишились прав капитана.
+func (s *Service) kickTeamMembers(ctx context.Context, teamID int64, memberIDs []int64) error {
+	for _, memberID := range memberIDs {
+		if err := s.kickTeamMember(ctx, teamID, memberID); err != nil {
+			return fmt.Errorf("s.kickTeamMember: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package teams
+
+import (
+	"context"
+)
+
+// NotifyBalanceChanged отправляет командам актуальный баланс после операций, проведённых
+// другими сервисами, например сделок биржи.
+func (s *Service) NotifyBalanceChanged(ctx context.Context, teamIDs ...int64) {
+	s.notifyBalanceChanged(ctx, teamIDs...)
+}
+
+// notifyBalanceChanged отправляет командам их актуальный баланс. Вызывается после фиксации
+// транзакции, поэтому ошибки только логируются: операция уже выполнена.
+func (s *Service) notifyBalanceChanged(ctx context.Context, teamIDs ...int64) {
+	for _, teamID := range teamIDs {
+		team, err := s.teamsRepo.GetByID(ctx, teamID)
+		if err != nil {
+			s.log.Error().Err(err).Int64("team_id", teamID).Msg("notify balance changed: get team")
+			continue
+		}
+		balance, err := s.balancesRepo.GetByID(ctx, team.BalanceID)
+		if err != nil {
+			s.log.Error().Err(err).Int64("team_id", teamID).Msg("notify balance changed: get balance")
+			continue
+		}
+		s.onBalanceChanged(team.SessionID, team.ID, *balance)
+	}
+}
+
+// notifyAdditionalInfoPurchased отправляет команде купленную дополнительную информацию.
+func (s *Service) notifyAdditionalInfoPurchased(ctx context.Context, teamID int64, additionalInfoID int64) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		s.log.Error().Err(err).Int64("team_id", teamID).Msg("notify additional info purchased: get team")
+		return
+	}
+	info, err := s.additionalInfosRepo.GetByID(ctx, additionalInfoID)
+	if err != nil {
+		s.log.Error().
+			Err(err).
+			Int64("additional_info_id", additionalInfoID).
+			Msg("notify additional info purchased: get additional info")
+		return
+	}
+	s.onAdditionalInfoPurchased(team.SessionID, team.ID, *info)
+}
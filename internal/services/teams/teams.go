@@ -8,8 +8,10 @@ import (
 	"github.com/samber/lo"
 	"investment-game-backend/internal/models"
 	"investment-game-backend/internal/repo"
+	"investment-game-backend/pkg/password"
 	"math/rand"
 	"slices"
+	"sync"
 )
 
 type Service struct {
@@ -21,9 +23,22 @@ type Service struct {
 	sharesRepo              repo.CompanySharesRepo
 	gamesRepo               repo.GamesRepo
 	companiesRepo           repo.CompaniesRepo
-	log                     *zerolog.Logger
-	isTradePeriod           bool
-	isRegistrationPeriod    bool
+	randomEventsRepo        repo.RandomEventsRepo
+	bondsRepo               repo.BondsRepo
+	teamBondsRepo           repo.TeamBondsRepo
+	membersRepo             repo.TeamMembersRepo
+	joinCodesRepo           repo.JoinCodesRepo
+	txManager               repo.TxManager
+	// onBalanceChanged и onAdditionalInfoPurchased доставляют команде адресные события по websocket
+	onBalanceChanged          func(sessionID int64, teamID int64, balance models.Balance)
+	onAdditionalInfoPurchased func(sessionID int64, teamID int64, info models.AdditionalInfo)
+	// kickTeamMember и kickTeamSharedSessions завершают сессии, потерявшие право торговать
+	kickTeamMember         func(ctx context.Context, teamID int64, memberID int64) error
+	kickTeamSharedSessions func(ctx context.Context, teamID int64) error
+	log                    *zerolog.Logger
+	isTradePeriod          map[int64]bool
+	isRegistrationPeriod   map[int64]bool
+	mx                     sync.RWMutex
 }
 
 func New(
@@ -35,63 +50,129 @@ func New(
 	balanceTransactionsRepo repo.BalanceTransactionsRepo,
 	gamesRepo repo.GamesRepo,
 	companiesRepo repo.CompaniesRepo,
+	randomEventsRepo repo.RandomEventsRepo,
+	bondsRepo repo.BondsRepo,
+	teamBondsRepo repo.TeamBondsRepo,
+	membersRepo repo.TeamMembersRepo,
+	joinCodesRepo repo.JoinCodesRepo,
+	txManager repo.TxManager,
+	onBalanceChanged func(sessionID int64, teamID int64, balance models.Balance),
+	onAdditionalInfoPurchased func(sessionID int64, teamID int64, info models.AdditionalInfo),
+	kickTeamMember func(ctx context.Context, teamID int64, memberID int64) error,
+	kickTeamSharedSessions func(ctx context.Context, teamID int64) error,
 	log *zerolog.Logger,
 ) *Service {
 	return &Service{
-		teamsRepo:               teamsRepo,
-		balancesRepo:            balancesRepo,
-		settingsRepo:            settingsRepo,
-		additionalInfosRepo:     additionalInfosRepo,
-		sharesRepo:              sharesRepo,
-		balanceTransactionsRepo: balanceTransactionsRepo,
-		gamesRepo:               gamesRepo,
-		companiesRepo:           companiesRepo,
-		log:                     log,
+		teamsRepo:                 teamsRepo,
+		balancesRepo:              balancesRepo,
+		settingsRepo:              settingsRepo,
+		additionalInfosRepo:       additionalInfosRepo,
+		sharesRepo:                sharesRepo,
+		balanceTransactionsRepo:   balanceTransactionsRepo,
+		gamesRepo:                 gamesRepo,
+		companiesRepo:             companiesRepo,
+		randomEventsRepo:          randomEventsRepo,
+		bondsRepo:                 bondsRepo,
+		teamBondsRepo:             teamBondsRepo,
+		membersRepo:               membersRepo,
+		joinCodesRepo:             joinCodesRepo,
+		txManager:                 txManager,
+		onBalanceChanged:          onBalanceChanged,
+		onAdditionalInfoPurchased: onAdditionalInfoPurchased,
+		kickTeamMember:            kickTeamMember,
+		kickTeamSharedSessions:    kickTeamSharedSessions,
+		log:                       log,
+		isTradePeriod:             make(map[int64]bool),
+		isRegistrationPeriod:      make(map[int64]bool),
+		mx:                        sync.RWMutex{},
 	}
 }
 
 type CreateParams struct {
-	Name        string
-	Credentials string
+	SessionID int64
+	Name      string
+	Password  string
 }
 
-var ErrNoRegistrationPeriod = errors.New("cannot create team because is not registration period")
+func (params CreateParams) Validate() error {
+	if params.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCredentials)
+	}
+	if params.Password == "" {
+		return fmt.Errorf("%w: password is required", ErrInvalidCredentials)
+	}
+	return nil
+}
+
+var (
+	ErrNoRegistrationPeriod = errors.New("cannot create team because is not registration period")
+	ErrInvalidCredentials   = errors.New("invalid team credentials")
+	ErrTeamNameTaken        = errors.New("team name is already taken")
+)
 
 func (s *Service) Create(ctx context.Context, params CreateParams) (int64, error) {
-	if !s.isRegistrationPeriod {
-		s.log.Debug().Msg("cannot create team because is not registration period")
+	if err := params.Validate(); err != nil {
+		return 0, fmt.Errorf("params.Validate: %w", err)
+	}
+	if !s.isRegistrationPeriodFor(params.SessionID) {
+		s.log.Debug().Int64("session_id", params.SessionID).Msg("cannot create team because is not registration period")
 		return 0, ErrNoRegistrationPeriod
 	}
 
-	settings, err := s.settingsRepo.Get(ctx)
+	game, err := s.gamesRepo.Get(ctx, params.SessionID)
 	if err != nil {
-		return 0, fmt.Errorf("s.settingsRepo.Get: %w", err)
+		return 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	// вход выполняется по имени команды, поэтому оно должно быть уникальным в игре
+	_, err = s.teamsRepo.GetByName(ctx, params.Name, game.ID, game.CurrentGame)
+	if err == nil {
+		return 0, ErrTeamNameTaken
+	}
+	if !errors.Is(err, repo.ErrNotFound) {
+		return 0, fmt.Errorf("s.teamsRepo.GetByName: %w", err)
 	}
 
-	balanceID, err := s.balancesRepo.Create(
-		ctx,
-		&models.Balance{Amount: settings.DefaultBalanceAmount},
-	)
+	passwordHash, err := password.Hash(params.Password)
 	if err != nil {
-		return 0, fmt.Errorf("s.balancesRepo.Create: %w", err)
+		return 0, fmt.Errorf("password.Hash: %w", err)
 	}
 
-	game, err := s.gamesRepo.Get(ctx)
+	settings, err := s.settingsRepo.Get(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
+		return 0, fmt.Errorf("s.settingsRepo.Get: %w", err)
 	}
 
-	teamID, err := s.teamsRepo.Create(
-		ctx,
-		&models.Team{
-			Name:        params.Name,
-			Credentials: params.Credentials,
-			BalanceID:   balanceID,
-			GameID:      game.CurrentGame,
-		},
-	)
-	if err != nil {
-		return 0, fmt.Errorf("s.teamsRepo.Create: %w", err)
+	var teamID int64
+	if err = s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		balanceID, err := s.balancesRepo.Create(
+			ctx,
+			&models.Balance{Amount: settings.DefaultBalanceAmount},
+		)
+		if err != nil {
+			return fmt.Errorf("s.balancesRepo.Create: %w", err)
+		}
+
+		// проверка выше не защищает от одновременной регистрации: окончательно имя проверяет уникальный индекс
+		teamID, err = s.teamsRepo.Create(
+			ctx,
+			&models.Team{
+				Name:          params.Name,
+				PasswordHash:  passwordHash,
+				BalanceID:     balanceID,
+				SessionID:     game.ID,
+				GameID:        game.CurrentGame,
+				AveragePrices: make(models.TeamAveragePrices),
+			},
+		)
+		if errors.Is(err, repo.ErrAlreadyExists) {
+			return ErrTeamNameTaken
+		}
+		if err != nil {
+			return fmt.Errorf("s.teamsRepo.Create: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("s.txManager.WithinTx: %w", err)
 	}
 
 	return teamID, nil
@@ -122,6 +203,8 @@ type PurchaseParams struct {
 	TeamID           int64
 	SharesChanges    map[int64]int64
 	AdditionalInfoID *int64
+	// MemberID — участник, совершающий покупку, пуст при входе общим паролем команды.
+	MemberID *int64
 }
 
 func (params PurchaseParams) Validate() error {
@@ -135,46 +218,86 @@ var (
 	ErrIsNoTradePeriod        = errors.New("cannot do purchase because is not trade period")
 	ErrIncorrectCountOfShares = errors.New("incorrect count of shares")
 	ErrNoMoneyForOperation    = errors.New("insufficient balance to complete the transaction")
+	ErrTradingFrozen          = errors.New("trading is frozen for team by random event")
+	ErrGamePaused             = errors.New("cannot do purchase because game is paused")
+	ErrConcurrentPurchase     = errors.New("purchase conflicts with concurrent operation, try again")
+	ErrShortLimitExceeded     = errors.New("short position exceeds allowed limit")
 )
 
-func (s *Service) Purchase(ctx context.Context, params PurchaseParams) (int64, error) {
-	if !s.isTradePeriod {
-		s.log.Debug().Msg("cannot do purchase because is not trade period")
-		return 0, ErrIsNoTradePeriod
+// withinTxRetry выполняет fn через repo.WithinTxRetry и сообщает о неснятом конфликте версий
+// как об ErrConcurrentPurchase.
+func (s *Service) withinTxRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := repo.WithinTxRetry(ctx, s.txManager, fn)
+	if errors.Is(err, repo.ErrVersionConflict) {
+		return fmt.Errorf("%w: %w", ErrConcurrentPurchase, err)
 	}
+	return err
+}
 
+func (s *Service) Purchase(ctx context.Context, params PurchaseParams) (int64, error) {
 	if err := params.Validate(); err != nil {
 		return 0, fmt.Errorf("params.Validate: %w", err)
 	}
 
-	game, err := s.gamesRepo.Get(ctx)
+	var balanceAmount int64
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		var err error
+		balanceAmount, err = s.purchase(ctx, params)
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("s.withinTxRetry: %w", err)
+	}
+
+	s.notifyBalanceChanged(ctx, params.TeamID)
+	if params.AdditionalInfoID != nil {
+		s.notifyAdditionalInfoPurchased(ctx, params.TeamID, *params.AdditionalInfoID)
+	}
+	return balanceAmount, nil
+}
+
+func (s *Service) purchase(ctx context.Context, params PurchaseParams) (int64, error) {
+	team, err := s.teamsRepo.GetByID(ctx, params.TeamID)
+	if err != nil {
+		return 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	if !s.isTradePeriodFor(team.SessionID) {
+		s.log.Debug().Int64("session_id", team.SessionID).Msg("cannot do purchase because is not trade period")
+		return 0, ErrIsNoTradePeriod
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
 	if err != nil {
 		return 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
 	}
-	team, err := s.teamsRepo.GetByID(ctx, params.TeamID)
+	if game.State == models.GameStatePaused {
+		return 0, ErrGamePaused
+	}
+	if err = s.checkTradingNotFrozen(ctx, team); err != nil {
+		return 0, fmt.Errorf("s.checkTradingNotFrozen: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
 	if err != nil {
+		return 0, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	// команду перечитываем под блокировкой баланса, чтобы не затереть изменения параллельной покупки
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
 		return 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
-	balance, err := s.balancesRepo.GetByID(ctx, team.BalanceID)
+	settings, err := s.settingsRepo.Get(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("s.balancesRepo.GetByID: %w", err)
+		return 0, fmt.Errorf("s.settingsRepo.Get: %w", err)
 	}
 
 	if params.SharesChanges != nil {
 		if team.Shares == nil {
 			team.Shares = make(models.TeamSharesState)
 		}
-		if err = team.Shares.MergeChanges(params.SharesChanges); err != nil {
-			if errors.Is(err, models.ErrSharesCountCannotBeNegative) {
-				return 0, ErrIncorrectCountOfShares
-			}
-			return 0, fmt.Errorf("team.Shares.MergeChanges: %w", err)
-		}
+		team.Shares.ApplyChanges(params.SharesChanges)
 	}
 
 	purchaseAmount, err := s.getPurchaseAmount(
 		ctx,
 		getPurchaseAmountParams{
+			team:             team,
 			round:            game.CurrentRound,
 			sharesChanges:    params.SharesChanges,
 			additionalInfoID: params.AdditionalInfoID,
@@ -199,6 +322,7 @@ func (s *Service) Purchase(ctx context.Context, params PurchaseParams) (int64, e
 				balance:          balance,
 				additionalInfoID: params.AdditionalInfoID,
 				amount:           purchaseAmount,
+				memberID:         params.MemberID,
 			},
 		); err != nil {
 			return 0, fmt.Errorf("s.purchaseAdditionalInfo: %w", err)
@@ -212,12 +336,25 @@ func (s *Service) Purchase(ctx context.Context, params PurchaseParams) (int64, e
 				sharesChanges: params.SharesChanges,
 				amount:        purchaseAmount,
 				team:          team,
+				settings:      settings,
+				memberID:      params.MemberID,
 			},
 		); err != nil {
 			return 0, fmt.Errorf("s.purchaseShares: %w", err)
 		}
 	}
 
+	// позиции проверяются после применения сделки, при ошибке транзакция откатится
+	if err = team.Shares.ValidateShortLimit(settings.ShortSellingLimit); err != nil {
+		if errors.Is(err, models.ErrSharesCountCannotBeNegative) {
+			return 0, ErrIncorrectCountOfShares
+		}
+		if errors.Is(err, models.ErrShortLimitExceeded) {
+			return 0, ErrShortLimitExceeded
+		}
+		return 0, fmt.Errorf("team.Shares.ValidateShortLimit: %w", err)
+	}
+
 	if params.AdditionalInfoID != nil {
 		team.AdditionalInfos = append(team.AdditionalInfos, *params.AdditionalInfoID)
 	}
@@ -228,7 +365,37 @@ func (s *Service) Purchase(ctx context.Context, params PurchaseParams) (int64, e
 	return balance.Amount, nil
 }
 
+// CheckTradingNotFrozen возвращает ErrTradingFrozen, если торговля команды заморожена случайным
+// событием. Используется биржей, чтобы заявки проверялись так же, как покупки.
+func (s *Service) CheckTradingNotFrozen(ctx context.Context, team *models.Team) error {
+	return s.checkTradingNotFrozen(ctx, team)
+}
+
+func (s *Service) checkTradingNotFrozen(ctx context.Context, team *models.Team) error {
+	if team.RandomEventID == nil {
+		return nil
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if !settings.EnableRandomEvents {
+		return nil
+	}
+
+	event, err := s.randomEventsRepo.GetByID(ctx, *team.RandomEventID)
+	if err != nil {
+		return fmt.Errorf("s.randomEventsRepo.GetByID: %w", err)
+	}
+	if event.EffectType == models.RandomEventEffectTypeTradingFrozen {
+		return ErrTradingFrozen
+	}
+	return nil
+}
+
 type getPurchaseAmountParams struct {
+	team             *models.Team
 	round            int
 	sharesChanges    map[int64]int64
 	additionalInfoID *int64
@@ -243,17 +410,10 @@ func (s *Service) getPurchaseAmount(ctx context.Context, params getPurchaseAmoun
 		return additionalInfo.Cost, nil
 	}
 
-	companyIDs := lo.Keys(params.sharesChanges)
-	shares, err := s.sharesRepo.GetListByCompanyIDsAndRound(ctx, companyIDs, params.round)
+	priceByCompanyID, err := s.getRoundPrices(ctx, params.team, params.sharesChanges, params.round)
 	if err != nil {
-		return 0, fmt.Errorf("s.sharesRepo.GetListByCompanyIDsAndRound: %w", err)
+		return 0, fmt.Errorf("s.getRoundPrices: %w", err)
 	}
-	priceByCompanyID := lo.SliceToMap(
-		shares,
-		func(share models.CompanyShare) (int64, int64) {
-			return share.CompanyID, share.Price
-		},
-	)
 
 	var amount int64
 	for companyID, count := range params.sharesChanges {
@@ -269,10 +429,11 @@ type purchaseAdditionalInfo struct {
 	balance          *models.Balance
 	additionalInfoID *int64
 	amount           int64
+	memberID         *int64
 }
 
 func (s *Service) purchaseAdditionalInfo(ctx context.Context, params purchaseAdditionalInfo) error {
-	if params.balance.Amount-params.amount < 0 {
+	if params.balance.Available()-params.amount < 0 {
 		return ErrNoMoneyForOperation
 	}
 
@@ -280,11 +441,13 @@ func (s *Service) purchaseAdditionalInfo(ctx context.Context, params purchaseAdd
 		ctx,
 		&models.BalanceTransaction{
 			BalanceID:        params.balance.ID,
+			Type:             models.BalanceTransactionTypeAdditionalInfo,
 			Round:            params.game.CurrentRound,
 			Amount:           params.amount,
 			Details:          nil,
 			AdditionalInfoID: params.additionalInfoID,
 			RandomEventID:    nil,
+			MemberID:         params.memberID,
 		},
 	)
 	if err != nil {
@@ -305,59 +468,64 @@ type purchaseSharesParams struct {
 	sharesChanges map[int64]int64
 	amount        int64
 	team          *models.Team
+	settings      *models.Settings
+	memberID      *int64
 }
 
 func (s *Service) purchaseShares(ctx context.Context, params purchaseSharesParams) error {
-	transaction, err := s.balanceTransactionsRepo.Get(ctx, params.balance.ID, params.game.CurrentRound)
-	if err != nil && !errors.Is(err, repo.ErrNotFound) {
-		return fmt.Errorf("s.balanceTransactionsRepo.Get: %w", err)
+	collateral, err := s.getShortCollateral(
+		ctx,
+		params.team,
+		params.game.CurrentRound,
+		params.settings.ShortMarginPercent,
+	)
+	if err != nil {
+		return fmt.Errorf("s.getShortCollateral: %w", err)
 	}
+	params.balance.Collateral = collateral
 
-	if errors.Is(err, repo.ErrNotFound) {
-		if params.balance.Amount-params.amount < 0 {
-			return ErrNoMoneyForOperation
-		}
-
-		if err = s.createNewBalanceTransaction(
-			ctx,
-			createNewBalanceTransactionParams{
-				balance:       params.balance,
-				game:          params.game,
-				sharesChanges: params.sharesChanges,
-				amount:        params.amount,
-			},
-		); err != nil {
-			return fmt.Errorf("s.createNewBalanceTransaction: %w", err)
-		}
-
-		return nil
-	} else {
-		balanceAfterTransactionUpdate := params.balance.Amount + transaction.Amount - params.amount
-		if balanceAfterTransactionUpdate < 0 {
-			return ErrNoMoneyForOperation
-		}
-
-		for key, value := range transaction.Details {
-			transaction.Details[key] = -value
-		}
-		if err = params.team.Shares.MergeChanges(transaction.Details); err != nil {
-			return fmt.Errorf("params.team.Shares.MergeChanges: %w", err)
-		}
+	prices, err := s.getRoundPrices(ctx, params.team, params.sharesChanges, params.game.CurrentRound)
+	if err != nil {
+		return fmt.Errorf("s.getRoundPrices: %w", err)
+	}
+	if err = s.loadAveragePrices(ctx, params.team); err != nil {
+		return fmt.Errorf("s.loadAveragePrices: %w", err)
+	}
+	positions := positionsBeforeTrade(params.team.Shares, params.sharesChanges)
+	charges, err := s.getTradeCharges(
+		ctx,
+		getTradeChargesParams{
+			team:      params.team,
+			positions: positions,
+			changes:   params.sharesChanges,
+			prices:    prices,
+			settings:  params.settings,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("s.getTradeCharges: %w", err)
+	}
+	params.team.AveragePrices.ApplyTrade(positions, params.sharesChanges, prices)
 
-		if err = s.updateExistedBalanceTransaction(
-			ctx,
-			updateExistedBalanceTransactionParams{
-				balance:       params.balance,
-				transaction:   transaction,
-				sharesChanges: params.sharesChanges,
-				amount:        params.amount,
-			},
-		); err != nil {
-			return fmt.Errorf("s.updateExistedBalanceTransaction: %w", err)
-		}
+	if params.balance.Available()-params.amount-charges.total() < 0 {
+		return ErrNoMoneyForOperation
+	}
 
-		return nil
+	if err = s.createNewBalanceTransaction(
+		ctx,
+		createNewBalanceTransactionParams{
+			balance:       params.balance,
+			game:          params.game,
+			sharesChanges: params.sharesChanges,
+			amount:        params.amount,
+			charges:       charges,
+			memberID:      params.memberID,
+		},
+	); err != nil {
+		return fmt.Errorf("s.createNewBalanceTransaction: %w", err)
 	}
+
+	return nil
 }
 
 type createNewBalanceTransactionParams struct {
@@ -365,82 +533,59 @@ type createNewBalanceTransactionParams struct {
 	balance       *models.Balance
 	sharesChanges map[int64]int64
 	amount        int64
+	charges       tradeCharges
+	memberID      *int64
 }
 
 func (s *Service) createNewBalanceTransaction(
 	ctx context.Context,
 	params createNewBalanceTransactionParams,
 ) error {
-	_, err := s.balanceTransactionsRepo.Create(
+	id, err := s.balanceTransactionsRepo.Create(
 		ctx,
 		&models.BalanceTransaction{
 			BalanceID:        params.balance.ID,
+			Type:             models.BalanceTransactionTypeShares,
 			Round:            params.game.CurrentRound,
 			Amount:           params.amount,
 			Details:          params.sharesChanges,
 			AdditionalInfoID: nil,
 			RandomEventID:    nil,
+			MemberID:         params.memberID,
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
 	}
-
-	params.balance.Amount -= params.amount
-	if err = s.balancesRepo.Update(ctx, params.balance); err != nil {
-		return fmt.Errorf("s.balancesRepo.Update: %w", err)
-	}
-
-	return nil
-}
-
-type updateExistedBalanceTransactionParams struct {
-	balance       *models.Balance
-	transaction   *models.BalanceTransaction
-	sharesChanges map[int64]int64
-	amount        int64
-}
-
-func (s *Service) updateExistedBalanceTransaction(
-	ctx context.Context,
-	params updateExistedBalanceTransactionParams,
-) error {
-	if err := s.balanceTransactionsRepo.Update(
+	if err = s.createTradeCharges(
 		ctx,
-		&models.BalanceTransaction{
-			ID:               params.transaction.ID,
-			BalanceID:        params.transaction.BalanceID,
-			Round:            params.transaction.Round,
-			Amount:           params.amount,
-			Details:          params.sharesChanges,
-			AdditionalInfoID: nil,
-			RandomEventID:    nil,
+		createTradeChargesParams{
+			balanceID: params.balance.ID,
+			round:     params.game.CurrentRound,
+			parentID:  id,
+			charges:   params.charges,
 		},
 	); err != nil {
-		return fmt.Errorf("s.balanceTransactionsRepo.Update: %w", err)
+		return fmt.Errorf("s.createTradeCharges: %w", err)
 	}
 
-	params.balance.Amount = params.balance.Amount + params.transaction.Amount - params.amount
-	if err := s.balancesRepo.Update(ctx, params.balance); err != nil {
+	params.balance.Amount -= params.amount + params.charges.total()
+	if err = s.balancesRepo.Update(ctx, params.balance); err != nil {
 		return fmt.Errorf("s.balancesRepo.Update: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) GetListForGame(ctx context.Context) ([]models.Team, error) {
-	teams, err := s.teamsRepo.GetAllByGameID(ctx, 1)
-	if err != nil {
-		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
-	}
-	return teams, nil
-}
-
 type DetailedTeam struct {
 	Team                      *models.Team
 	AdditionalInfos           []models.AdditionalInfo
 	Balance                   int64
+	Loan                      int64
 	HasTransactionInThisRound bool
+	// RoundTransactions — все транзакции команды в текущем раунде в порядке создания,
+	// включая сделки, комиссии и налоги.
+	RoundTransactions []models.BalanceTransaction
 }
 
 func (s *Service) GetDetailedByID(ctx context.Context, id int64) (DetailedTeam, error) {
@@ -458,26 +603,27 @@ func (s *Service) GetDetailedByID(ctx context.Context, id int64) (DetailedTeam,
 		return DetailedTeam{}, fmt.Errorf("s.fillTeamSharesByZeroValuesIfNeeded: %w", err)
 	}
 
-	game, err := s.gamesRepo.Get(ctx)
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
 	if err != nil {
 		return DetailedTeam{}, fmt.Errorf("s.gamesRepo.Get: %w", err)
 	}
 
-	var hasTransactionInThisRound bool
-	_, err = s.balanceTransactionsRepo.Get(ctx, team.BalanceID, game.CurrentRound)
-	if err != nil && !errors.Is(err, repo.ErrNotFound) {
-		return DetailedTeam{}, fmt.Errorf("s.balanceTransactionsRepo.Get: %w", err)
-	}
-	if err == nil {
-		hasTransactionInThisRound = true
+	roundTransactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, team.BalanceID, game.CurrentRound)
+	if err != nil {
+		return DetailedTeam{}, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
 	}
+	hasTransactionInThisRound := lo.ContainsBy(roundTransactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeShares
+	})
 
 	if len(team.AdditionalInfos) == 0 {
 		return DetailedTeam{
 			Team:                      team,
 			AdditionalInfos:           nil,
 			Balance:                   balance.Amount,
+			Loan:                      balance.Loan,
 			HasTransactionInThisRound: hasTransactionInThisRound,
+			RoundTransactions:         roundTransactions,
 		}, nil
 	}
 
@@ -490,7 +636,9 @@ func (s *Service) GetDetailedByID(ctx context.Context, id int64) (DetailedTeam,
 		Team:                      team,
 		AdditionalInfos:           additionalInfos,
 		Balance:                   balance.Amount,
+		Loan:                      balance.Loan,
 		HasTransactionInThisRound: hasTransactionInThisRound,
+		RoundTransactions:         roundTransactions,
 	}, nil
 }
 
@@ -516,23 +664,47 @@ func (s *Service) fillTeamSharesByZeroValuesIfNeeded(ctx context.Context, team *
 	return nil
 }
 
-func (s *Service) NotifyTradePeriodUpdated(isTrade bool) {
-	s.log.Trace().Bool("is_trade", isTrade).Msg("team service: NotifyTradePeriodUpdated")
-	s.isTradePeriod = isTrade
+func (s *Service) NotifyTradePeriodUpdated(sessionID int64, isTrade bool) {
+	s.log.Trace().
+		Int64("session_id", sessionID).
+		Bool("is_trade", isTrade).
+		Msg("team service: NotifyTradePeriodUpdated")
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.isTradePeriod[sessionID] = isTrade
+}
+
+func (s *Service) NotifyGameRegistrationPeriodUpdated(sessionID int64, idRegistration bool) {
+	s.log.Trace().
+		Int64("session_id", sessionID).
+		Bool("is_registration", idRegistration).
+		Msg("team service: NotifyGameRegistrationPeriodUpdated")
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.isRegistrationPeriod[sessionID] = idRegistration
+}
+
+func (s *Service) isTradePeriodFor(sessionID int64) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.isTradePeriod[sessionID]
 }
 
-func (s *Service) NotifyGameRegistrationPeriodUpdated(idRegistration bool) {
-	s.log.Trace().Bool("is_registration", idRegistration).Msg("team service: NotifyGameRegistrationPeriodUpdated")
-	s.isRegistrationPeriod = idRegistration
+func (s *Service) isRegistrationPeriodFor(sessionID int64) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.isRegistrationPeriod[sessionID]
 }
 
-func (s *Service) GetAllForCurrentGame(ctx context.Context) ([]models.Team, error) {
-	game, err := s.gamesRepo.Get(ctx)
+func (s *Service) GetAllForCurrentGame(ctx context.Context, sessionID int64) ([]models.Team, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
 	}
 
-	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.CurrentGame)
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
 	if err != nil {
 		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
 	}
@@ -542,23 +714,57 @@ func (s *Service) GetAllForCurrentGame(ctx context.Context) ([]models.Team, erro
 
 var ErrNoAdditionalInfos = errors.New("no additional infos")
 
-func (s *Service) PurchaseAdditionalInfoCompanyInfo(ctx context.Context, teamId int64) (models.AdditionalInfo, int64, error) {
-	if !s.isTradePeriod {
-		s.log.Debug().Msg("cannot do purchase because is not trade period")
-		return models.AdditionalInfo{}, 0, ErrIsNoTradePeriod
+func (s *Service) PurchaseAdditionalInfoCompanyInfo(
+	ctx context.Context,
+	teamId int64,
+	memberID *int64,
+) (models.AdditionalInfo, int64, error) {
+	var (
+		additionalInfo models.AdditionalInfo
+		balanceAmount  int64
+	)
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		var err error
+		additionalInfo, balanceAmount, err = s.purchaseAdditionalInfoCompanyInfo(ctx, teamId, memberID)
+		return err
+	}); err != nil {
+		return models.AdditionalInfo{}, 0, fmt.Errorf("s.withinTxRetry: %w", err)
 	}
 
-	game, err := s.gamesRepo.Get(ctx)
-	if err != nil {
-		return models.AdditionalInfo{}, 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
-	}
+	s.notifyBalanceChanged(ctx, teamId)
+	s.notifyAdditionalInfoPurchased(ctx, teamId, additionalInfo.ID)
+	return additionalInfo, balanceAmount, nil
+}
+
+func (s *Service) purchaseAdditionalInfoCompanyInfo(
+	ctx context.Context,
+	teamId int64,
+	memberID *int64,
+) (models.AdditionalInfo, int64, error) {
 	team, err := s.teamsRepo.GetByID(ctx, teamId)
 	if err != nil {
 		return models.AdditionalInfo{}, 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
-	balance, err := s.balancesRepo.GetByID(ctx, team.BalanceID)
+	if !s.isTradePeriodFor(team.SessionID) {
+		s.log.Debug().Int64("session_id", team.SessionID).Msg("cannot do purchase because is not trade period")
+		return models.AdditionalInfo{}, 0, ErrIsNoTradePeriod
+	}
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
+	if err != nil {
+		return models.AdditionalInfo{}, 0, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	if game.State == models.GameStatePaused {
+		return models.AdditionalInfo{}, 0, ErrGamePaused
+	}
+	if err = s.checkTradingNotFrozen(ctx, team); err != nil {
+		return models.AdditionalInfo{}, 0, fmt.Errorf("s.checkTradingNotFrozen: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
 	if err != nil {
-		return models.AdditionalInfo{}, 0, fmt.Errorf("s.balancesRepo.GetByID: %w", err)
+		return models.AdditionalInfo{}, 0, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
+		return models.AdditionalInfo{}, 0, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
 
 	additionalInfos, err := s.additionalInfosRepo.GetAllActualWithType(ctx, models.AdditionalInfoTypeCompanyInfo)
@@ -597,6 +803,7 @@ func (s *Service) PurchaseAdditionalInfoCompanyInfo(ctx context.Context, teamId
 			balance:          balance,
 			additionalInfoID: &additionalInfoToBuy.ID,
 			amount:           additionalInfoToBuy.Cost,
+			memberID:         memberID,
 		},
 	); err != nil {
 		return models.AdditionalInfo{}, 0, fmt.Errorf("s.purchaseAdditionalInfo: %w", err)
@@ -610,48 +817,481 @@ func (s *Service) PurchaseAdditionalInfoCompanyInfo(ctx context.Context, teamId
 	return additionalInfoToBuy, balance.Amount, nil
 }
 
-func (s *Service) ResetTransaction(ctx context.Context, teamID int64) (DetailedTeam, error) {
-	game, err := s.gamesRepo.Get(ctx)
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ResetTransaction отменяет сделку transactionID текущего раунда, а если он не задан — все сделки раунда.
+func (s *Service) ResetTransaction(ctx context.Context, teamID int64, transactionID *int64) (DetailedTeam, error) {
+	if err := s.withinTxRetry(ctx, func(ctx context.Context) error {
+		return s.resetTransaction(ctx, teamID, transactionID)
+	}); err != nil {
+		return DetailedTeam{}, fmt.Errorf("s.withinTxRetry: %w", err)
+	}
+	s.notifyBalanceChanged(ctx, teamID)
+
+	detailedTeam, err := s.GetDetailedByID(ctx, teamID)
 	if err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.gamesRepo.Get: %w", err)
+		return DetailedTeam{}, fmt.Errorf("s.GetDetailedByID: %w", err)
 	}
+	return detailedTeam, nil
+}
+
+func (s *Service) resetTransaction(ctx context.Context, teamID int64, transactionID *int64) error {
 	team, err := s.teamsRepo.GetByID(ctx, teamID)
 	if err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
-	balance, err := s.balancesRepo.GetByID(ctx, team.BalanceID)
+	game, err := s.gamesRepo.Get(ctx, team.SessionID)
 	if err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.balancesRepo.GetByID: %w", err)
+		return fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
 
-	transaction, err := s.balanceTransactionsRepo.Get(ctx, balance.ID, game.CurrentRound)
+	roundTransactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, game.CurrentRound)
 	if err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.balanceTransactionsRepo.Get: %w", err)
+		return fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	transactions := lo.Filter(roundTransactions, func(item models.BalanceTransaction, _ int) bool {
+		if item.Type != models.BalanceTransactionTypeShares {
+			return false
+		}
+		return transactionID == nil || item.ID == *transactionID
+	})
+	if len(transactions) == 0 {
+		return ErrTransactionNotFound
+	}
+
+	if team.Shares == nil {
+		team.Shares = make(models.TeamSharesState)
+	}
+	for _, transaction := range transactions {
+		// комиссии и налоги сделки удаляются вместе с ней и возвращаются на баланс
+		balance.Amount += transaction.Amount + getChargesAmount(roundTransactions, transaction.ID)
+
+		for key, value := range transaction.Details {
+			transaction.Details[key] = -value
+		}
+		team.Shares.ApplyChanges(transaction.Details)
+
+		if err = s.balanceTransactionsRepo.Delete(ctx, transaction.ID); err != nil {
+			return fmt.Errorf("s.balanceTransactionsRepo.Delete: %w", err)
+		}
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	// отмена одной сделки может оставить позиции, которые последующие сделки раунда уже продали
+	if err = team.Shares.ValidateShortLimit(settings.ShortSellingLimit); err != nil {
+		if errors.Is(err, models.ErrSharesCountCannotBeNegative) {
+			return ErrIncorrectCountOfShares
+		}
+		if errors.Is(err, models.ErrShortLimitExceeded) {
+			return ErrShortLimitExceeded
+		}
+		return fmt.Errorf("team.Shares.ValidateShortLimit: %w", err)
+	}
+	if balance.Collateral, err = s.getShortCollateral(
+		ctx,
+		team,
+		game.CurrentRound,
+		settings.ShortMarginPercent,
+	); err != nil {
+		return fmt.Errorf("s.getShortCollateral: %w", err)
+	}
+	if team.AveragePrices, err = s.recalculateRoundTaxes(
+		ctx,
+		recalculateRoundTaxesParams{
+			team:              team,
+			balance:           balance,
+			round:             game.CurrentRound,
+			roundTransactions: roundTransactions,
+			// транзакции раунда упорядочены по ID
+			firstResetID: transactions[0].ID,
+			taxPercent:   settings.CapitalGainsTaxPercent,
+		},
+	); err != nil {
+		return fmt.Errorf("s.recalculateRoundTaxes: %w", err)
+	}
+	// отмена продажи уменьшает баланс, и его может не хватить на уже совершённые покупки
+	if balance.Available() < 0 {
+		return ErrNoMoneyForOperation
 	}
-	balance.Amount = balance.Amount + transaction.Amount
 	if err = s.balancesRepo.Update(ctx, balance); err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.balancesRepo.Update: %w", err)
+		return fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+
+	if err = s.teamsRepo.Update(ctx, team); err != nil {
+		return fmt.Errorf("s.teamsRepo.Update: %w", err)
+	}
+	return nil
+}
+
+// getShortCollateral считает залог под короткие позиции команды по ценам раунда с учётом процента маржи.
+func (s *Service) getShortCollateral(
+	ctx context.Context,
+	team *models.Team,
+	round int,
+	marginPercent int64,
+) (int64, error) {
+	shorts := team.Shares.ShortPositions()
+	if len(shorts) == 0 {
+		return 0, nil
+	}
+
+	cost, err := s.getSharesCost(ctx, team, shorts, round)
+	if err != nil {
+		return 0, fmt.Errorf("s.getSharesCost: %w", err)
+	}
+	return cost * marginPercent / 100, nil
+}
+
+func (s *Service) getSharesCost(
+	ctx context.Context,
+	team *models.Team,
+	counts map[int64]int64,
+	round int,
+) (int64, error) {
+	prices, err := s.getRoundPrices(ctx, team, counts, round)
+	if err != nil {
+		return 0, fmt.Errorf("s.getRoundPrices: %w", err)
+	}
+
+	var cost int64
+	for companyID, count := range counts {
+		cost += prices[companyID] * count
+	}
+	return cost, nil
+}
+
+// MarkShortsToMarket пересчитывает залог под короткие позиции команд сессии по ценам нового раунда.
+// Если баланса команды не хватает на залог, все её короткие позиции выкупаются по текущей цене,
+// а недостающая на выкуп сумма добавляется к долгу команды. Команды, позиции которых уже
+// закрыты в этом раунде, пропускаются.
+func (s *Service) MarkShortsToMarket(ctx context.Context, sessionID int64, round int) ([]models.MarginCall, error) {
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+	if settings.ShortSellingLimit == 0 {
+		return nil, nil
+	}
+
+	game, err := s.gamesRepo.Get(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("s.gamesRepo.Get: %w", err)
+	}
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
+	}
+
+	result := make([]models.MarginCall, 0)
+	for _, team := range teams {
+		if len(team.Shares.ShortPositions()) == 0 {
+			continue
+		}
+
+		var marginCall *models.MarginCall
+		if err = s.withinTxRetry(ctx, func(ctx context.Context) error {
+			var err error
+			marginCall, err = s.markTeamShortsToMarket(ctx, team.ID, round, settings.ShortMarginPercent)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("s.withinTxRetry: %w", err)
+		}
+		if marginCall == nil {
+			continue
+		}
+
+		s.log.Trace().
+			Int64("team_id", marginCall.TeamID).
+			Int64("amount", marginCall.Amount).
+			Msg("short positions closed by margin call")
+		result = append(result, *marginCall)
+	}
+
+	return result, nil
+}
+
+func (s *Service) markTeamShortsToMarket(
+	ctx context.Context,
+	teamID int64,
+	round int,
+	marginPercent int64,
+) (*models.MarginCall, error) {
+	team, err := s.teamsRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	balance, err := s.balancesRepo.GetByIDForUpdate(ctx, team.BalanceID)
+	if err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+	}
+	if team, err = s.teamsRepo.GetByID(ctx, team.ID); err != nil {
+		return nil, fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	// повторный запуск раунда не должен закрывать позиции дважды
+	transactions, err := s.balanceTransactionsRepo.GetAllByBalanceIDAndRound(ctx, balance.ID, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.GetAllByBalanceIDAndRound: %w", err)
+	}
+	if slices.ContainsFunc(transactions, func(item models.BalanceTransaction) bool {
+		return item.Type == models.BalanceTransactionTypeMarginCall
+	}) {
+		return nil, nil
+	}
+
+	collateral, err := s.getShortCollateral(ctx, team, round, marginPercent)
+	if err != nil {
+		return nil, fmt.Errorf("s.getShortCollateral: %w", err)
+	}
+	if balance.Amount >= collateral {
+		balance.Collateral = collateral
+		if err = s.balancesRepo.Update(ctx, balance); err != nil {
+			return nil, fmt.Errorf("s.balancesRepo.Update: %w", err)
+		}
+		return nil, nil
+	}
+
+	closed := team.Shares.ShortPositions()
+	cost, err := s.getSharesCost(ctx, team, closed, round)
+	if err != nil {
+		return nil, fmt.Errorf("s.getSharesCost: %w", err)
+	}
+
+	if _, err = s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        balance.ID,
+			Type:             models.BalanceTransactionTypeMarginCall,
+			Round:            round,
+			Amount:           cost,
+			Details:          closed,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+		},
+	); err != nil {
+		return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
 	}
 
-	for key, value := range transaction.Details {
-		transaction.Details[key] = -value
+	balance.Amount -= cost
+	balance.Collateral = 0
+
+	// выкуп по текущей цене может стоить дороже баланса: недостающая сумма оформляется как кредит,
+	// чтобы баланс не уходил в минус, а сумма транзакций сходилась с балансом
+	capitalized := max(-balance.Amount, 0)
+	if capitalized != 0 {
+		if _, err = s.balanceTransactionsRepo.Create(
+			ctx,
+			&models.BalanceTransaction{
+				BalanceID:        balance.ID,
+				Type:             models.BalanceTransactionTypeLoan,
+				Round:            round,
+				Amount:           -capitalized,
+				Details:          nil,
+				AdditionalInfoID: nil,
+				RandomEventID:    nil,
+			},
+		); err != nil {
+			return nil, fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+		}
+		balance.Amount += capitalized
+		balance.Loan += capitalized
 	}
-	if err = team.Shares.MergeChanges(transaction.Details); err != nil {
-		return DetailedTeam{}, fmt.Errorf("params.team.Shares.MergeChanges: %w", err)
+	if err = s.balancesRepo.Update(ctx, balance); err != nil {
+		return nil, fmt.Errorf("s.balancesRepo.Update: %w", err)
 	}
 
+	team.Shares.ApplyChanges(closed)
 	if err = s.teamsRepo.Update(ctx, team); err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.teamsRepo.Update: %w", err)
+		return nil, fmt.Errorf("s.teamsRepo.Update: %w", err)
 	}
-	if err = s.balanceTransactionsRepo.Delete(ctx, balance.ID, game.CurrentRound); err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.balanceTransactionsRepo.Delete: %w", err)
+
+	return &models.MarginCall{
+		TeamID:      team.ID,
+		Round:       round,
+		Closed:      closed,
+		Amount:      cost,
+		Capitalized: capitalized,
+	}, nil
+}
+
+// SettleMarketFill проводит сделку между командами по заявкам из стакана: деньги и акции
+// переходят от одной команды к другой, каждой записывается транзакция BalanceTransactionTypeMarketFill.
+// Если одна из сторон не может исполнить сделку, возвращается models.ErrBuyerCannotSettle
+// или models.ErrSellerCannotSettle. Вызывается в транзакции биржи вместе с обновлением заявок,
+// поэтому повтор при конфликте версий и уведомление о балансах остаются вызывающему.
+func (s *Service) SettleMarketFill(ctx context.Context, fill models.MarketFill) error {
+	if err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return s.settleMarketFill(ctx, fill)
+	}); err != nil {
+		return fmt.Errorf("s.txManager.WithinTx: %w", err)
 	}
+	return nil
+}
 
-	detailedTeam, err := s.GetDetailedByID(ctx, teamID)
+func (s *Service) settleMarketFill(ctx context.Context, fill models.MarketFill) error {
+	buyer, err := s.teamsRepo.GetByID(ctx, fill.BuyerTeamID)
 	if err != nil {
-		return DetailedTeam{}, fmt.Errorf("s.GetDetailedByID: %w", err)
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
 	}
-	return detailedTeam, nil
+	seller, err := s.teamsRepo.GetByID(ctx, fill.SellerTeamID)
+	if err != nil {
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	// балансы блокируются в порядке ID, чтобы встречные сделки не взаимоблокировались
+	balances := make(map[int64]*models.Balance, 2)
+	for _, id := range slices.Sorted(slices.Values([]int64{buyer.BalanceID, seller.BalanceID})) {
+		if balances[id], err = s.balancesRepo.GetByIDForUpdate(ctx, id); err != nil {
+			return fmt.Errorf("s.balancesRepo.GetByIDForUpdate: %w", err)
+		}
+	}
+	if buyer, err = s.teamsRepo.GetByID(ctx, buyer.ID); err != nil {
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+	if seller, err = s.teamsRepo.GetByID(ctx, seller.ID); err != nil {
+		return fmt.Errorf("s.teamsRepo.GetByID: %w", err)
+	}
+
+	settings, err := s.settingsRepo.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("s.settingsRepo.Get: %w", err)
+	}
+
+	amount := fill.Price * fill.Count
+	if err = s.settleMarketFillSide(
+		ctx,
+		settleMarketFillSideParams{
+			team:          buyer,
+			memberID:      fill.BuyerMemberID,
+			balance:       balances[buyer.BalanceID],
+			fill:          fill,
+			sharesChanges: map[int64]int64{fill.CompanyID: fill.Count},
+			amount:        amount,
+			settings:      settings,
+			settleErr:     models.ErrBuyerCannotSettle,
+		},
+	); err != nil {
+		return fmt.Errorf("s.settleMarketFillSide: %w", err)
+	}
+	if err = s.settleMarketFillSide(
+		ctx,
+		settleMarketFillSideParams{
+			team:          seller,
+			memberID:      fill.SellerMemberID,
+			balance:       balances[seller.BalanceID],
+			fill:          fill,
+			sharesChanges: map[int64]int64{fill.CompanyID: -fill.Count},
+			amount:        -amount,
+			settings:      settings,
+			settleErr:     models.ErrSellerCannotSettle,
+		},
+	); err != nil {
+		return fmt.Errorf("s.settleMarketFillSide: %w", err)
+	}
+
+	return nil
+}
+
+type settleMarketFillSideParams struct {
+	team          *models.Team
+	memberID      *int64
+	balance       *models.Balance
+	fill          models.MarketFill
+	sharesChanges map[int64]int64
+	// amount списывается с баланса, у продавца он отрицательный.
+	amount   int64
+	settings *models.Settings
+	// settleErr возвращается, если сторона не может исполнить сделку.
+	settleErr error
+}
+
+func (s *Service) settleMarketFillSide(ctx context.Context, params settleMarketFillSideParams) error {
+	if params.team.Shares == nil {
+		params.team.Shares = make(models.TeamSharesState)
+	}
+	if err := s.loadAveragePrices(ctx, params.team); err != nil {
+		return fmt.Errorf("s.loadAveragePrices: %w", err)
+	}
+	prices := map[int64]int64{params.fill.CompanyID: params.fill.Price}
+	charges, err := s.getTradeCharges(
+		ctx,
+		getTradeChargesParams{
+			team:      params.team,
+			positions: params.team.Shares,
+			changes:   params.sharesChanges,
+			prices:    prices,
+			settings:  params.settings,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("s.getTradeCharges: %w", err)
+	}
+
+	params.team.AveragePrices.ApplyTrade(params.team.Shares, params.sharesChanges, prices)
+	params.team.Shares.ApplyChanges(params.sharesChanges)
+	if err = params.team.Shares.ValidateShortLimit(params.settings.ShortSellingLimit); err != nil {
+		return fmt.Errorf("%w: %w", params.settleErr, err)
+	}
+
+	collateral, err := s.getShortCollateral(
+		ctx,
+		params.team,
+		params.fill.Round,
+		params.settings.ShortMarginPercent,
+	)
+	if err != nil {
+		return fmt.Errorf("s.getShortCollateral: %w", err)
+	}
+	params.balance.Collateral = collateral
+	params.balance.Amount -= params.amount + charges.total()
+	if params.balance.Available() < 0 {
+		return fmt.Errorf("%w: %w", params.settleErr, ErrNoMoneyForOperation)
+	}
+
+	id, err := s.balanceTransactionsRepo.Create(
+		ctx,
+		&models.BalanceTransaction{
+			BalanceID:        params.balance.ID,
+			Type:             models.BalanceTransactionTypeMarketFill,
+			Round:            params.fill.Round,
+			Amount:           params.amount,
+			Details:          params.sharesChanges,
+			AdditionalInfoID: nil,
+			RandomEventID:    nil,
+			MemberID:         params.memberID,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("s.balanceTransactionsRepo.Create: %w", err)
+	}
+	if err = s.createTradeCharges(
+		ctx,
+		createTradeChargesParams{
+			balanceID: params.balance.ID,
+			round:     params.fill.Round,
+			parentID:  id,
+			charges:   charges,
+		},
+	); err != nil {
+		return fmt.Errorf("s.createTradeCharges: %w", err)
+	}
+	if err = s.balancesRepo.Update(ctx, params.balance); err != nil {
+		return fmt.Errorf("s.balancesRepo.Update: %w", err)
+	}
+	if err = s.teamsRepo.Update(ctx, params.team); err != nil {
+		return fmt.Errorf("s.teamsRepo.Update: %w", err)
+	}
+	return nil
 }
 
 type StatisticsByGame struct {
@@ -664,12 +1304,12 @@ type TeamResult struct {
 	Score    int64  `json:"score"`
 }
 
-func (s *Service) GetStatisticsByGame(ctx context.Context, round int) (StatisticsByGame, error) {
-	game, err := s.gamesRepo.Get(ctx)
+func (s *Service) GetStatisticsByGame(ctx context.Context, sessionID int64, round int) (StatisticsByGame, error) {
+	game, err := s.gamesRepo.Get(ctx, sessionID)
 	if err != nil {
 		return StatisticsByGame{}, fmt.Errorf("s.gamesRepo.Get: %w", err)
 	}
-	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.CurrentGame)
+	teams, err := s.teamsRepo.GetAllByGameID(ctx, game.ID, game.CurrentGame)
 	if err != nil {
 		return StatisticsByGame{}, fmt.Errorf("s.teamsRepo.GetAllByGameID: %w", err)
 	}
@@ -677,9 +1317,9 @@ func (s *Service) GetStatisticsByGame(ctx context.Context, round int) (Statistic
 		return StatisticsByGame{}, errors.New("no teams for current game")
 	}
 
-	companiesShares, err := s.sharesRepo.GetAllActual(ctx)
+	companiesShares, err := s.sharesRepo.GetAllActualForGame(ctx, game.ID, game.CurrentGame)
 	if err != nil {
-		return StatisticsByGame{}, fmt.Errorf("s.sharesRepo.GetAllActual: %w", err)
+		return StatisticsByGame{}, fmt.Errorf("s.sharesRepo.GetAllActualForGame: %w", err)
 	}
 	companiesSharesOnlyLastRound := lo.Filter(
 		companiesShares,
@@ -694,6 +1334,20 @@ func (s *Service) GetStatisticsByGame(ctx context.Context, round int) (Statistic
 		},
 	)
 
+	teamBonds, err := s.teamBondsRepo.GetAllNotRedeemedByTeamIDs(
+		ctx,
+		lo.Map(teams, func(item models.Team, _ int) int64 {
+			return item.ID
+		}),
+	)
+	if err != nil {
+		return StatisticsByGame{}, fmt.Errorf("s.teamBondsRepo.GetAllNotRedeemedByTeamIDs: %w", err)
+	}
+	bondsCostByTeamID := make(map[int64]int64, len(teams))
+	for _, item := range teamBonds {
+		bondsCostByTeamID[item.TeamID] += item.Principal()
+	}
+
 	statistics := StatisticsByGame{
 		Results: make([]TeamResult, 0, len(teams)),
 	}
@@ -703,11 +1357,15 @@ func (s *Service) GetStatisticsByGame(ctx context.Context, round int) (Statistic
 		if err != nil {
 			return StatisticsByGame{}, fmt.Errorf("s.balancesRepo.GetByID: %w", err)
 		}
-		score := balance.Amount
+		// долг перед банком уже зачислен в Amount, поэтому вычитается из результата
+		score := balance.Amount - balance.Loan
+		// короткие позиции уменьшают результат на свою рыночную стоимость в раунде
 		for companyId, count := range team.Shares {
 			cost := shareCostByCompanyID[companyId]
 			score += cost * count
 		}
+		// непогашенные облигации и вклады учитываются по номиналу
+		score += bondsCostByTeamID[team.ID]
 		statistics.Results = append(statistics.Results, TeamResult{
 			ID:       team.ID,
 			TeamName: team.Name,
@@ -14,10 +14,13 @@ import (
 func (r *Router) initAdditionalInfosRoutes(router chi.Router) {
 	router.Route("/additional-info", func(subRouter chi.Router) {
 		subRouter.Use(r.AuthMiddleware)
-		subRouter.Post("/", r.createAdditionalInfo)
-		subRouter.Put("/{additional_info_id}", r.updateAdditionalInfo)
-		subRouter.Get("/", r.getAllActualAdditionalInfos)
-		subRouter.Delete("/{additional_info_id}", r.deleteAdditionalInfo)
+		subRouter.With(r.RequireRole(allRoles...)).Get("/", r.getAllActualAdditionalInfos)
+		subRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Post("/", r.createAdditionalInfo)
+			adminRouter.Put("/{additional_info_id}", r.updateAdditionalInfo)
+			adminRouter.Delete("/{additional_info_id}", r.deleteAdditionalInfo)
+		})
 	})
 }
 
@@ -0,0 +1,254 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/repo"
+	"investment-game-backend/internal/services/admins"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (r *Router) initAdminsRoutes(router chi.Router) {
+	router.Route("/admin", func(subRouter chi.Router) {
+		subRouter.Use(r.AuthMiddleware, r.RequireRole(adminOnly...), r.AuditAdminAction)
+		subRouter.Get("/", r.getAllAdmins)
+		subRouter.Post("/", r.createAdmin)
+		subRouter.Put("/{admin_id}", r.updateAdmin)
+		subRouter.Delete("/{admin_id}", r.deleteAdmin)
+		subRouter.Get("/actions", r.getAdminActions)
+	})
+}
+
+// adminErrorStatus возвращает статус ответа для ошибок сервиса администраторов.
+func adminErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, admins.ErrInvalidParams),
+		errors.Is(err, admins.ErrCannotDeleteSelf),
+		errors.Is(err, admins.ErrLastAdmin):
+		return http.StatusBadRequest
+	case errors.Is(err, admins.ErrUsernameTaken):
+		return http.StatusConflict
+	case errors.Is(err, repo.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type (
+	adminResp struct {
+		ID        int64     `json:"id"`
+		Username  string    `json:"username"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	getAllAdminsResp struct {
+		Data []adminResp `json:"data"`
+	}
+)
+
+func toAdminResp(admin models.Admin) adminResp {
+	return adminResp{
+		ID:        admin.ID,
+		Username:  admin.Username,
+		CreatedAt: admin.CreatedAt,
+	}
+}
+
+func (r *Router) getAllAdmins(resp http.ResponseWriter, req *http.Request) {
+	items, err := r.adminsService.GetAll(req.Context())
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetAll admins error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getAllAdminsResp{
+			Data: lo.Map(items, func(item models.Admin, _ int) adminResp {
+				return toAdminResp(item)
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type createAdminReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (r *Router) createAdmin(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createAdminReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	admin, err := r.adminsService.Create(
+		req.Context(),
+		admins.CreateParams{
+			Username: request.Username,
+			Password: request.Password,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("create admin error")
+		status := adminErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(toAdminResp(*admin))
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
+
+type updateAdminReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (r *Router) updateAdmin(resp http.ResponseWriter, req *http.Request) {
+	adminID, err := strconv.ParseInt(chi.URLParam(req, "admin_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request updateAdminReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if err = r.adminsService.Update(
+		req.Context(),
+		admins.UpdateParams{
+			ID:       adminID,
+			Username: request.Username,
+			Password: request.Password,
+		},
+	); err != nil {
+		r.log.Error().Err(err).Msg("update admin error")
+		status := adminErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+func (r *Router) deleteAdmin(resp http.ResponseWriter, req *http.Request) {
+	adminID, err := strconv.ParseInt(chi.URLParam(req, "admin_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.adminsService.Delete(req.Context(), adminIDFromContext(req.Context()), adminID); err != nil {
+		r.log.Error().Err(err).Msg("delete admin error")
+		status := adminErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+type (
+	getAdminActionsResp struct {
+		Data []getAdminActionsRespItem `json:"data"`
+	}
+	getAdminActionsRespItem struct {
+		ID        int64     `json:"id"`
+		AdminID   *int64    `json:"adminId"`
+		Action    string    `json:"action"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+)
+
+func (r *Router) getAdminActions(resp http.ResponseWriter, req *http.Request) {
+	actions, err := r.adminsService.GetActions(req.Context(), sessionIDFromContext(req.Context()))
+	if err != nil {
+		r.log.Error().Err(err).Msg("get admin actions error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getAdminActionsResp{
+			Data: lo.Map(actions, func(item models.AdminAction, _ int) getAdminActionsRespItem {
+				return getAdminActionsRespItem{
+					ID:        item.ID,
+					AdminID:   item.AdminID,
+					Action:    item.Action,
+					CreatedAt: item.CreatedAt,
+				}
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
@@ -4,24 +4,31 @@ import (
 	"errors"
 	"github.com/go-chi/chi/v5"
 	jsoniter "github.com/json-iterator/go"
+	"investment-game-backend/internal/services/auth"
 	"investment-game-backend/internal/services/teams"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 func (r *Router) initAuthRoutes(router chi.Router) {
 	router.Route("/auth", func(settingsRouter chi.Router) {
 		settingsRouter.Post("/registration", r.registration)
+		settingsRouter.Post("/registration/code", r.registrationByCode)
 		settingsRouter.Post("/login", r.login)
 		settingsRouter.Post("/refresh", r.refresh)
+		settingsRouter.With(r.AuthMiddleware, r.RequireRole(allRoles...)).Post("/logout", r.logout)
+		settingsRouter.With(r.AuthMiddleware, r.RequireRole(adminOnly...), r.AuditAdminAction).
+			Post("/spectator", r.issueSpectatorTokens)
 	})
 }
 
 type (
 	registrationReq struct {
-		TeamName string `json:"teamName"`
-		Password string `json:"password"`
+		SessionID int64  `json:"sessionId"`
+		TeamName  string `json:"teamName"`
+		Password  string `json:"password"`
 	}
 )
 
@@ -43,15 +50,20 @@ func (r *Router) registration(resp http.ResponseWriter, req *http.Request) {
 	}
 
 	_, err = r.teamService.Create(req.Context(), teams.CreateParams{
-		Name:        request.TeamName,
-		Credentials: request.TeamName + ":" + request.Password,
+		SessionID: request.SessionID,
+		Name:      request.TeamName,
+		Password:  request.Password,
 	})
 	if err != nil {
 		r.log.Error().Err(err).Msg("create team error")
-		if errors.Is(err, teams.ErrNoRegistrationPeriod) {
+		if errors.Is(err, teams.ErrNoRegistrationPeriod) || errors.Is(err, teams.ErrInvalidCredentials) {
 			resp.WriteHeader(http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, teams.ErrTeamNameTaken) {
+			resp.WriteHeader(http.StatusConflict)
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
 		return
@@ -63,9 +75,11 @@ func (r *Router) registration(resp http.ResponseWriter, req *http.Request) {
 
 type (
 	loginReq struct {
-		TeamName string `json:"teamName"`
-		Password string `json:"password"`
-		IsAdmin  bool   `json:"isAdmin"`
+		SessionID  int64  `json:"sessionId"`
+		TeamName   string `json:"teamName"`
+		MemberName string `json:"memberName"`
+		Password   string `json:"password"`
+		IsAdmin    bool   `json:"isAdmin"`
 	}
 	loginResp struct {
 		AccessToken  string `json:"accessToken"`
@@ -90,7 +104,17 @@ func (r *Router) login(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	jwtPair, err := r.authService.Login(req.Context(), request.TeamName+":"+request.Password, request.IsAdmin)
+	jwtPair, err := r.authService.Login(
+		req.Context(),
+		auth.LoginParams{
+			SessionID:  request.SessionID,
+			Name:       request.TeamName,
+			MemberName: request.MemberName,
+			Password:   request.Password,
+			IsAdmin:    request.IsAdmin,
+			UserAgent:  req.UserAgent(),
+		},
+	)
 	if err != nil {
 		r.log.Error().Err(err).Msg("login error")
 		resp.WriteHeader(http.StatusUnauthorized)
@@ -153,6 +177,11 @@ func (r *Router) refresh(resp http.ResponseWriter, req *http.Request) {
 	jwtPair, err := r.authService.Refresh(req.Context(), refreshToken)
 	if err != nil {
 		r.log.Error().Err(err).Str("token", refreshToken).Msg("refresh error")
+		if errors.Is(err, auth.ErrSessionRevoked) {
+			resp.WriteHeader(http.StatusUnauthorized)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusUnauthorized)))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
 		return
@@ -185,3 +214,84 @@ func (r *Router) refresh(resp http.ResponseWriter, req *http.Request) {
 	_, _ = resp.Write(response)
 	return
 }
+
+// issueSpectatorTokens выдаёт администратору пару токенов наблюдателя его игры, например для экрана
+// с ходом игры. Refresh-токен возвращается только в теле, чтобы не заменить cookie самого администратора.
+func (r *Router) issueSpectatorTokens(resp http.ResponseWriter, req *http.Request) {
+	jwtPair, err := r.authService.IssueSpectatorTokens(
+		req.Context(),
+		adminIDFromContext(req.Context()),
+		sessionIDFromContext(req.Context()),
+		req.UserAgent(),
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("issue spectator tokens error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		loginResp{
+			AccessToken:  jwtPair.AccessToken,
+			RefreshToken: jwtPair.RefreshToken,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
+
+// logout завершает сессию текущего устройства. Сессии команды на других устройствах не затрагиваются.
+func (r *Router) logout(resp http.ResponseWriter, req *http.Request) {
+	if err := r.authService.Logout(req.Context(), authSessionIDFromContext(req.Context())); err != nil {
+		r.log.Error().Err(err).Msg("logout error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	http.SetCookie(resp, &http.Cookie{
+		Name:     "refreshToken",
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		Secure:   false,
+		HttpOnly: true,
+	})
+
+	resp.WriteHeader(http.StatusNoContent)
+	return
+}
+
+// kickTeam разлогинивает команду на всех устройствах.
+func (r *Router) kickTeam(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.authService.KickTeam(req.Context(), teamID); err != nil {
+		r.log.Error().Err(err).Int64("team_id", teamID).Msg("kick team error")
+		if errors.Is(err, auth.ErrTeamNotFound) {
+			resp.WriteHeader(http.StatusNotFound)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusNotFound)))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusNoContent)
+	return
+}
@@ -0,0 +1,352 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/bonds"
+	"investment-game-backend/internal/services/teams"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (r *Router) initBondsRoutes(router chi.Router) {
+	router.Route("/bond", func(subRouter chi.Router) {
+		subRouter.Use(r.AuthMiddleware)
+		subRouter.With(r.RequireRole(allRoles...)).Get("/", r.getAllBonds)
+		subRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Post("/", r.createBond)
+			adminRouter.Put("/{bond_id}", r.updateBond)
+			adminRouter.Patch("/{bond_id}", r.archiveBond)
+		})
+		subRouter.Group(func(teamRouter chi.Router) {
+			teamRouter.Use(r.RequireRole(traders...))
+			teamRouter.With(r.RequireTradePermission).Post("/purchase", r.purchaseBond)
+			teamRouter.With(r.RequireOwnTeam).Get("/team/{team_id}", r.getTeamBonds)
+		})
+	})
+}
+
+type (
+	createBondReq struct {
+		Name           string          `json:"name"`
+		Type           models.BondType `json:"type"`
+		Price          int64           `json:"price"`
+		CouponPercent  int64           `json:"couponPercent"`
+		MaturityRounds int             `json:"maturityRounds"`
+	}
+	bondResp struct {
+		ID             int64           `json:"id"`
+		Name           string          `json:"name"`
+		Type           models.BondType `json:"type"`
+		Price          int64           `json:"price"`
+		CouponPercent  int64           `json:"couponPercent"`
+		MaturityRounds int             `json:"maturityRounds"`
+	}
+)
+
+func toBondResp(bond models.Bond) bondResp {
+	return bondResp{
+		ID:             bond.ID,
+		Name:           bond.Name,
+		Type:           bond.Type,
+		Price:          bond.Price,
+		CouponPercent:  bond.CouponPercent,
+		MaturityRounds: bond.MaturityRounds,
+	}
+}
+
+func (r *Router) createBond(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createBondReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	bond, err := r.bondsService.Create(
+		req.Context(),
+		bonds.CreateParams{
+			Name:           request.Name,
+			Type:           request.Type,
+			Price:          request.Price,
+			CouponPercent:  request.CouponPercent,
+			MaturityRounds: request.MaturityRounds,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("create bond error")
+		if errors.Is(err, bonds.ErrInvalidParams) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(toBondResp(*bond))
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
+
+type getAllBondsResp struct {
+	Data []bondResp `json:"data"`
+}
+
+func (r *Router) getAllBonds(resp http.ResponseWriter, req *http.Request) {
+	items, err := r.bondsService.GetAll(req.Context())
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetAll bonds error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getAllBondsResp{
+			Data: lo.Map(items, func(item models.Bond, _ int) bondResp {
+				return toBondResp(item)
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type updateBondReq struct {
+	Name           string          `json:"name"`
+	Type           models.BondType `json:"type"`
+	Price          int64           `json:"price"`
+	CouponPercent  int64           `json:"couponPercent"`
+	MaturityRounds int             `json:"maturityRounds"`
+}
+
+func (r *Router) updateBond(resp http.ResponseWriter, req *http.Request) {
+	bondID, err := strconv.ParseInt(chi.URLParam(req, "bond_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request updateBondReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if err = r.bondsService.Update(
+		req.Context(),
+		bonds.UpdateParams{
+			ID:             bondID,
+			Name:           request.Name,
+			Type:           request.Type,
+			Price:          request.Price,
+			CouponPercent:  request.CouponPercent,
+			MaturityRounds: request.MaturityRounds,
+		},
+	); err != nil {
+		r.log.Error().Err(err).Msg("update bond error")
+		if errors.Is(err, bonds.ErrInvalidParams) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+func (r *Router) archiveBond(resp http.ResponseWriter, req *http.Request) {
+	bondID, err := strconv.ParseInt(chi.URLParam(req, "bond_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.bondsService.Archive(req.Context(), bondID); err != nil {
+		r.log.Error().Err(err).Msg("archive bond error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+const errInvalidBondPurchase = 10014
+
+// purchaseBondErrors сопоставляет ошибки покупки облигаций кодам ответа.
+var purchaseBondErrors = []codedError{
+	{err: teams.ErrInvalidBondPurchase, code: errInvalidBondPurchase, status: http.StatusBadRequest},
+	{err: teams.ErrIsNoTradePeriod, code: errIsNoTradePeriod, status: http.StatusBadRequest},
+	{err: teams.ErrGamePaused, code: errGamePaused, status: http.StatusBadRequest},
+	{err: teams.ErrTradingFrozen, code: errTradingFrozen, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrConcurrentPurchase, code: errConcurrentPurchase, status: http.StatusConflict},
+}
+
+type purchaseBondReq struct {
+	TeamID int64 `json:"teamId"`
+	BondID int64 `json:"bondId"`
+	Count  int64 `json:"count"`
+}
+
+func (r *Router) purchaseBond(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request purchaseBondReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if writeForbiddenIfForeignTeam(resp, req, request.TeamID) {
+		return
+	}
+
+	amount, err := r.teamService.PurchaseBond(
+		req.Context(),
+		teams.PurchaseBondParams{
+			TeamID:   request.TeamID,
+			BondID:   request.BondID,
+			Count:    request.Count,
+			MemberID: memberIDFromContext(req.Context()),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("purchase bond error")
+		r.writeCodedError(resp, err, purchaseBondErrors)
+		return
+	}
+
+	response, err := jsoniter.Marshal(teamPurchaseResp{BalanceAmount: amount})
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	getTeamBondsResp struct {
+		Data []getTeamBondsRespItem `json:"data"`
+	}
+	getTeamBondsRespItem struct {
+		ID            int64 `json:"id"`
+		BondID        int64 `json:"bondId"`
+		Count         int64 `json:"count"`
+		Price         int64 `json:"price"`
+		CouponPercent int64 `json:"couponPercent"`
+		PurchaseRound int   `json:"purchaseRound"`
+		MaturityRound int   `json:"maturityRound"`
+		Redeemed      bool  `json:"redeemed"`
+	}
+)
+
+func (r *Router) getTeamBonds(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	items, err := r.teamService.GetTeamBonds(req.Context(), teamID)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get team bonds error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getTeamBondsResp{
+			Data: lo.Map(items, func(item models.TeamBond, _ int) getTeamBondsRespItem {
+				return getTeamBondsRespItem{
+					ID:            item.ID,
+					BondID:        item.BondID,
+					Count:         item.Count,
+					Price:         item.Price,
+					CouponPercent: item.CouponPercent,
+					PurchaseRound: item.PurchaseRound,
+					MaturityRound: item.MaturityRound,
+					Redeemed:      item.Redeemed,
+				}
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
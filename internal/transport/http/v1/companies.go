@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"context"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/samber/lo"
@@ -14,22 +16,30 @@ import (
 func (r *Router) initCompanyRoutes(router chi.Router) {
 	router.Route("/company", func(companyRouter chi.Router) {
 		companyRouter.Use(r.AuthMiddleware)
-		companyRouter.Post("/", r.createCompanyWithShares)
-		companyRouter.Get("/", r.getCompaniesWithShares)
-		companyRouter.Put("/{company_id}", r.updateCompanyWithShares)
-		companyRouter.Patch("/{company_id}", r.archiveCompanyWithShares)
+		companyRouter.With(r.RequireRole(allRoles...)).Get("/", r.getCompaniesWithShares)
+		companyRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Post("/", r.createCompanyWithShares)
+			adminRouter.Get("/price-adjustment", r.previewPriceAdjustment)
+			adminRouter.Post("/price-generation/preview", r.previewGeneratedPrices)
+			adminRouter.Post("/price-generation/commit", r.commitGeneratedPrices)
+			adminRouter.Put("/{company_id}", r.updateCompanyWithShares)
+			adminRouter.Patch("/{company_id}", r.archiveCompanyWithShares)
+		})
 	})
 }
 
 type (
 	createCompanyReq struct {
-		Name   string             `json:"name"`
-		Shares map[string]float64 `json:"shares"`
+		Name      string             `json:"name"`
+		Shares    map[string]float64 `json:"shares"`
+		Dividends map[string]float64 `json:"dividends"`
 	}
 	createCompanyResp struct {
-		ID     int64              `json:"id"`
-		Name   string             `json:"name"`
-		Shares map[string]float64 `json:"shares"`
+		ID        int64              `json:"id"`
+		Name      string             `json:"name"`
+		Shares    map[string]float64 `json:"shares"`
+		Dividends map[string]float64 `json:"dividends"`
 	}
 )
 
@@ -61,10 +71,22 @@ func (r *Router) createCompanyWithShares(resp http.ResponseWriter, req *http.Req
 					return roundItn, int64(price)
 				},
 			),
+			Dividends: lo.MapEntries(
+				request.Dividends,
+				func(round string, dividend float64) (int, int64) {
+					roundItn, _ := strconv.Atoi(round)
+					return roundItn, int64(dividend)
+				},
+			),
 		},
 	)
 	if err != nil {
 		r.log.Error().Err(err).Msg("CreateWithShares error")
+		if errors.Is(err, companies.ErrInvalidDividend) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
 		return
@@ -77,9 +99,10 @@ func (r *Router) createCompanyWithShares(resp http.ResponseWriter, req *http.Req
 
 	response, err := jsoniter.Marshal(
 		createCompanyResp{
-			ID:     createdCompanyID,
-			Name:   request.Name,
-			Shares: shares,
+			ID:        createdCompanyID,
+			Name:      request.Name,
+			Shares:    shares,
+			Dividends: request.Dividends,
 		},
 	)
 	if err != nil {
@@ -99,17 +122,17 @@ type (
 		Data []getCompaniesWithSharesRespItem `json:"data"`
 	}
 	getCompaniesWithSharesRespItem struct {
-		ID     int64         `json:"id"`
-		Name   string        `json:"name"`
-		Shares map[int]int64 `json:"shares"`
+		ID        int64         `json:"id"`
+		Name      string        `json:"name"`
+		Shares    map[int]int64 `json:"shares"`
+		Dividends map[int]int64 `json:"dividends"`
 	}
 )
 
 func (r *Router) getCompaniesWithShares(resp http.ResponseWriter, req *http.Request) {
-	role := req.Context().Value("role").(string)
-	onlyCurrentRound := role != "admin"
+	onlyCurrentRound := roleFromContext(req.Context()) != models.RoleAdmin
 
-	companyWithShares, err := r.companiesService.GetAllWithShares(req.Context(), onlyCurrentRound)
+	companyWithShares, err := r.companiesService.GetAllWithShares(req.Context(), sessionIDFromContext(req.Context()), onlyCurrentRound)
 	if err != nil {
 		r.log.Error().Err(err).Msg("GetAllWithShares error")
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -123,9 +146,10 @@ func (r *Router) getCompaniesWithShares(resp http.ResponseWriter, req *http.Requ
 				companyWithShares,
 				func(item models.CompanyWithShares, _ int) getCompaniesWithSharesRespItem {
 					return getCompaniesWithSharesRespItem{
-						ID:     item.ID,
-						Name:   item.Name,
-						Shares: item.Shares,
+						ID:        item.ID,
+						Name:      item.Name,
+						Shares:    item.Shares,
+						Dividends: item.Dividends,
 					}
 				},
 			),
@@ -145,8 +169,9 @@ func (r *Router) getCompaniesWithShares(resp http.ResponseWriter, req *http.Requ
 
 type (
 	updateCompanyReq struct {
-		Name   string             `json:"name"`
-		Shares map[string]float64 `json:"shares"`
+		Name      string             `json:"name"`
+		Shares    map[string]float64 `json:"shares"`
+		Dividends map[string]float64 `json:"dividends"`
 	}
 )
 
@@ -188,10 +213,22 @@ func (r *Router) updateCompanyWithShares(resp http.ResponseWriter, req *http.Req
 					return roundItn, int64(price)
 				},
 			),
+			Dividends: lo.MapEntries(
+				request.Dividends,
+				func(round string, dividend float64) (int, int64) {
+					roundItn, _ := strconv.Atoi(round)
+					return roundItn, int64(dividend)
+				},
+			),
 		},
 	)
 	if err != nil {
 		r.log.Error().Err(err).Msg("Update error")
+		if errors.Is(err, companies.ErrInvalidDividend) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
 		return
@@ -221,3 +258,160 @@ func (r *Router) archiveCompanyWithShares(resp http.ResponseWriter, req *http.Re
 	resp.WriteHeader(http.StatusOK)
 	return
 }
+
+type (
+	previewPriceAdjustmentResp struct {
+		Data []previewPriceAdjustmentRespItem `json:"data"`
+	}
+	previewPriceAdjustmentRespItem struct {
+		CompanyID int64 `json:"companyId"`
+		Round     int   `json:"round"`
+		BasePrice int64 `json:"basePrice"`
+		NetDemand int64 `json:"netDemand"`
+		Volume    int64 `json:"volume"`
+		Price     int64 `json:"price"`
+	}
+)
+
+func (r *Router) previewPriceAdjustment(resp http.ResponseWriter, req *http.Request) {
+	adjustments, err := r.companiesService.PreviewPriceAdjustment(req.Context(), sessionIDFromContext(req.Context()))
+	if err != nil {
+		r.log.Error().Err(err).Msg("PreviewPriceAdjustment error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		previewPriceAdjustmentResp{
+			Data: lo.Map(
+				adjustments,
+				func(item models.SharePriceAdjustment, _ int) previewPriceAdjustmentRespItem {
+					return previewPriceAdjustmentRespItem{
+						CompanyID: item.CompanyID,
+						Round:     item.Round,
+						BasePrice: item.BasePrice,
+						NetDemand: item.NetDemand,
+						Volume:    item.Volume,
+						Price:     item.Price,
+					}
+				},
+			),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	generatePricesReq struct {
+		Companies []generatePricesReqItem `json:"companies"`
+	}
+	generatePricesReqItem struct {
+		CompanyID      int64   `json:"companyId"`
+		InitialPrice   int64   `json:"initialPrice"`
+		Drift          float64 `json:"drift"`
+		Volatility     float64 `json:"volatility"`
+		Seed           int64   `json:"seed"`
+		JumpMean       float64 `json:"jumpMean"`
+		JumpVolatility float64 `json:"jumpVolatility"`
+	}
+	generatePricesResp struct {
+		Data []generatePricesRespItem `json:"data"`
+	}
+	generatePricesRespItem struct {
+		CompanyID int64         `json:"companyId"`
+		Shares    map[int]int64 `json:"shares"`
+	}
+)
+
+func (r *Router) previewGeneratedPrices(resp http.ResponseWriter, req *http.Request) {
+	r.generatePrices(resp, req, r.companiesService.GeneratePrices)
+}
+
+func (r *Router) commitGeneratedPrices(resp http.ResponseWriter, req *http.Request) {
+	r.generatePrices(resp, req, r.companiesService.CommitGeneratedPrices)
+}
+
+func (r *Router) generatePrices(
+	resp http.ResponseWriter,
+	req *http.Request,
+	generate func(ctx context.Context, params []companies.GeneratePricesParams) ([]companies.GeneratedPrices, error),
+) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request generatePricesReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	generated, err := generate(
+		req.Context(),
+		lo.Map(
+			request.Companies,
+			func(item generatePricesReqItem, _ int) companies.GeneratePricesParams {
+				return companies.GeneratePricesParams{
+					CompanyID:      item.CompanyID,
+					InitialPrice:   item.InitialPrice,
+					Drift:          item.Drift,
+					Volatility:     item.Volatility,
+					Seed:           item.Seed,
+					JumpMean:       item.JumpMean,
+					JumpVolatility: item.JumpVolatility,
+				}
+			},
+		),
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("generate prices error")
+		if errors.Is(err, companies.ErrInvalidGenerateParams) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		generatePricesResp{
+			Data: lo.Map(
+				generated,
+				func(item companies.GeneratedPrices, _ int) generatePricesRespItem {
+					return generatePricesRespItem{
+						CompanyID: item.CompanyID,
+						Shares:    item.Prices,
+					}
+				},
+			),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
@@ -1,41 +1,59 @@
 package v1
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	jsoniter "github.com/json-iterator/go"
 	"investment-game-backend/internal/models"
 	gamesservice "investment-game-backend/internal/services/games"
 	"io"
 	"net/http"
+	"time"
 )
 
 func (r *Router) initGamesRoutes(router chi.Router) {
 	router.Route("/game", func(gameRouter chi.Router) {
 		gameRouter.Use(r.AuthMiddleware)
-		gameRouter.Get("/", r.getGame)
-		gameRouter.Put("/", r.updateGame)
-		gameRouter.Patch("/create", r.createNewGame)
-		gameRouter.Patch("/start", r.startGame)
-		gameRouter.Patch("/stop", r.stopGame)
-		gameRouter.Patch("/registration/start", r.startRegistration)
-		gameRouter.Patch("/registration/stop", r.stopRegistration)
-		gameRouter.Patch("/round/start", r.startRound)
-		gameRouter.Patch("/round/stop", r.stopRound)
-		gameRouter.Patch("/trade/start", r.startTrade)
-		gameRouter.Patch("/trade/stop", r.stopTrade)
+		gameRouter.With(r.RequireRole(allRoles...)).Get("/", r.getGame)
+		gameRouter.With(r.RequireRole(allRoles...)).Get("/autopilot", r.getAutopilotState)
+		gameRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Put("/", r.updateGame)
+			adminRouter.Patch("/create", r.createNewGame)
+			adminRouter.Patch("/start", r.startGame)
+			adminRouter.Patch("/stop", r.stopGame)
+			adminRouter.Patch("/pause", r.pauseGame)
+			adminRouter.Patch("/resume", r.resumeGame)
+			adminRouter.Patch("/registration/start", r.startRegistration)
+			adminRouter.Patch("/registration/stop", r.stopRegistration)
+			adminRouter.Patch("/round/start", r.startRound)
+			adminRouter.Patch("/round/stop", r.stopRound)
+			adminRouter.Patch("/trade/start", r.startTrade)
+			adminRouter.Patch("/trade/stop", r.stopTrade)
+			adminRouter.Patch("/autopilot/start", r.startAutopilot)
+			adminRouter.Patch("/autopilot/stop", r.stopAutopilot)
+			adminRouter.Patch("/autopilot/pause", r.pauseAutopilot)
+			adminRouter.Patch("/autopilot/resume", r.resumeAutopilot)
+			adminRouter.Patch("/autopilot/skip", r.skipAutopilotPhase)
+			adminRouter.Patch("/autopilot/extend", r.extendAutopilotPhase)
+		})
 	})
 }
 
 type (
 	getGameResp struct {
+		ID           int64             `json:"id"`
+		Name         string            `json:"name"`
 		State        models.GameState  `json:"state"`
 		CurrentRound int               `json:"currentRound"`
 		TradeState   models.TradeState `json:"tradeState"`
+		// AutopilotStatus показывает администратору, что автопилот прерван перезапуском сервера.
+		AutopilotStatus models.AutopilotStatus `json:"autopilotStatus"`
 	}
 )
 
 func (r *Router) getGame(resp http.ResponseWriter, req *http.Request) {
-	game, err := r.gamesService.Get(req.Context())
+	game, err := r.gamesService.Get(req.Context(), sessionIDFromContext(req.Context()))
 	if err != nil {
 		r.log.Error().Err(err).Msg("games service: get error")
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -45,9 +63,12 @@ func (r *Router) getGame(resp http.ResponseWriter, req *http.Request) {
 
 	response, err := jsoniter.Marshal(
 		getGameResp{
-			State:        game.State,
-			CurrentRound: game.CurrentRound,
-			TradeState:   game.TradeState,
+			ID:              game.ID,
+			Name:            game.Name,
+			State:           game.State,
+			CurrentRound:    game.CurrentRound,
+			TradeState:      game.TradeState,
+			AutopilotStatus: game.AutopilotStatus,
 		},
 	)
 	if err != nil {
@@ -89,6 +110,7 @@ func (r *Router) updateGame(resp http.ResponseWriter, req *http.Request) {
 
 	if err = r.gamesService.Update(
 		req.Context(),
+		sessionIDFromContext(req.Context()),
 		gamesservice.UpdateParams{
 			State:        request.State,
 			CurrentRound: request.CurrentRound,
@@ -96,6 +118,11 @@ func (r *Router) updateGame(resp http.ResponseWriter, req *http.Request) {
 		},
 	); err != nil {
 		r.log.Error().Err(err).Msg("games service: update error")
+		if errors.Is(err, gamesservice.ErrTradeAlreadyStarted) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
 		return
@@ -106,7 +133,7 @@ func (r *Router) updateGame(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) createNewGame(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.CreateNewGame(req.Context()); err != nil {
+	if err := r.gamesService.CreateNewGame(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StartNewGame error")
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
@@ -117,7 +144,7 @@ func (r *Router) createNewGame(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) startGame(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StartGame(req.Context()); err != nil {
+	if err := r.gamesService.StartGame(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StartGame error")
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
@@ -128,7 +155,7 @@ func (r *Router) startGame(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) stopGame(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StopGame(req.Context()); err != nil {
+	if err := r.gamesService.StopGame(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StopGame error")
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
@@ -138,8 +165,40 @@ func (r *Router) stopGame(resp http.ResponseWriter, req *http.Request) {
 	return
 }
 
+func (r *Router) pauseGame(resp http.ResponseWriter, req *http.Request) {
+	if err := r.gamesService.PauseGame(req.Context(), sessionIDFromContext(req.Context())); err != nil {
+		r.log.Error().Err(err).Msg("PauseGame error")
+		if errors.Is(err, gamesservice.ErrGameNotStarted) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(err.Error()))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+func (r *Router) resumeGame(resp http.ResponseWriter, req *http.Request) {
+	if err := r.gamesService.ResumeGame(req.Context(), sessionIDFromContext(req.Context())); err != nil {
+		r.log.Error().Err(err).Msg("ResumeGame error")
+		if errors.Is(err, gamesservice.ErrGameNotPaused) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(err.Error()))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
 func (r *Router) startRegistration(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StartRegistration(req.Context()); err != nil {
+	if err := r.gamesService.StartRegistration(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StartRegistration error")
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
@@ -150,7 +209,7 @@ func (r *Router) startRegistration(resp http.ResponseWriter, req *http.Request)
 }
 
 func (r *Router) stopRegistration(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StopRegistration(req.Context()); err != nil {
+	if err := r.gamesService.StopRegistration(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StopRegistration error")
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
@@ -161,8 +220,13 @@ func (r *Router) stopRegistration(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) startRound(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StartRound(req.Context()); err != nil {
+	if err := r.gamesService.StartRound(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StartRound error")
+		if errors.Is(err, gamesservice.ErrGamePaused) || errors.Is(err, gamesservice.ErrAutopilotRunning) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
 		return
@@ -172,14 +236,31 @@ func (r *Router) startRound(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) stopRound(resp http.ResponseWriter, req *http.Request) {
-	_ = r.gamesService.StopRound(req.Context())
+	if err := r.gamesService.StopRound(req.Context(), sessionIDFromContext(req.Context())); err != nil {
+		r.log.Error().Err(err).Msg("StopRound error")
+		if errors.Is(err, gamesservice.ErrAutopilotRunning) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(err.Error()))
+		return
+	}
 	resp.WriteHeader(http.StatusOK)
 	return
 }
 
 func (r *Router) startTrade(resp http.ResponseWriter, req *http.Request) {
-	if err := r.gamesService.StartTrade(req.Context()); err != nil {
+	if err := r.gamesService.StartTrade(req.Context(), sessionIDFromContext(req.Context())); err != nil {
 		r.log.Error().Err(err).Msg("StartTrade error")
+		if errors.Is(err, gamesservice.ErrGamePaused) ||
+			errors.Is(err, gamesservice.ErrAutopilotRunning) ||
+			errors.Is(err, gamesservice.ErrTradeAlreadyStarted) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
 		resp.WriteHeader(http.StatusInternalServerError)
 		_, _ = resp.Write([]byte(err.Error()))
 		return
@@ -189,7 +270,136 @@ func (r *Router) startTrade(resp http.ResponseWriter, req *http.Request) {
 }
 
 func (r *Router) stopTrade(resp http.ResponseWriter, req *http.Request) {
-	r.gamesService.StopTrade(req.Context())
+	if err := r.gamesService.StopTrade(req.Context(), sessionIDFromContext(req.Context())); err != nil {
+		r.log.Error().Err(err).Msg("StopTrade error")
+		resp.WriteHeader(http.StatusConflict)
+		_, _ = resp.Write([]byte(err.Error()))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+type (
+	getAutopilotStateResp struct {
+		Phase     models.AutopilotPhase `json:"phase"`
+		Round     int                   `json:"round"`
+		Remaining string                `json:"remaining"`
+		IsPaused  bool                  `json:"isPaused"`
+	}
+)
+
+func (r *Router) getAutopilotState(resp http.ResponseWriter, req *http.Request) {
+	state, err := r.gamesService.GetAutopilotState(req.Context(), sessionIDFromContext(req.Context()))
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetAutopilotState error")
+		if errors.Is(err, gamesservice.ErrAutopilotNotRunning) {
+			resp.WriteHeader(http.StatusNotFound)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getAutopilotStateResp{
+			Phase:     state.Phase,
+			Round:     state.Round,
+			Remaining: state.Remaining.String(),
+			IsPaused:  state.IsPaused,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
 	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
 	return
 }
+
+func (r *Router) startAutopilot(resp http.ResponseWriter, req *http.Request) {
+	err := r.gamesService.StartAutopilot(req.Context(), sessionIDFromContext(req.Context()))
+	r.writeAutopilotCommandResult(resp, err, "StartAutopilot error")
+	return
+}
+
+func (r *Router) stopAutopilot(resp http.ResponseWriter, req *http.Request) {
+	err := r.gamesService.StopAutopilot(req.Context(), sessionIDFromContext(req.Context()))
+	r.writeAutopilotCommandResult(resp, err, "StopAutopilot error")
+	return
+}
+
+func (r *Router) pauseAutopilot(resp http.ResponseWriter, req *http.Request) {
+	err := r.gamesService.PauseAutopilot(req.Context(), sessionIDFromContext(req.Context()))
+	r.writeAutopilotCommandResult(resp, err, "PauseAutopilot error")
+	return
+}
+
+func (r *Router) resumeAutopilot(resp http.ResponseWriter, req *http.Request) {
+	err := r.gamesService.ResumeAutopilot(req.Context(), sessionIDFromContext(req.Context()))
+	r.writeAutopilotCommandResult(resp, err, "ResumeAutopilot error")
+	return
+}
+
+func (r *Router) skipAutopilotPhase(resp http.ResponseWriter, req *http.Request) {
+	err := r.gamesService.SkipAutopilotPhase(req.Context(), sessionIDFromContext(req.Context()))
+	r.writeAutopilotCommandResult(resp, err, "SkipAutopilotPhase error")
+	return
+}
+
+type (
+	extendAutopilotPhaseReq struct {
+		Duration string `json:"duration"`
+	}
+)
+
+func (r *Router) extendAutopilotPhase(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request extendAutopilotPhaseReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+	dur, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		r.log.Error().Err(err).Msg("parse duration error")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	err = r.gamesService.ExtendAutopilotPhase(req.Context(), sessionIDFromContext(req.Context()), dur)
+	r.writeAutopilotCommandResult(resp, err, "ExtendAutopilotPhase error")
+	return
+}
+
+func (r *Router) writeAutopilotCommandResult(resp http.ResponseWriter, err error, msg string) {
+	if err != nil {
+		r.log.Error().Err(err).Msg(msg)
+		if errors.Is(err, gamesservice.ErrAutopilotNotRunning) ||
+			errors.Is(err, gamesservice.ErrAutopilotAlreadyRunning) {
+			resp.WriteHeader(http.StatusConflict)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(err.Error()))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,255 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"github.com/skip2/go-qrcode"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/teams"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// joinCodeQRSize — сторона PNG с QR-кодом в пикселях, достаточная для проектора в аудитории.
+const joinCodeQRSize = 512
+
+func (r *Router) initJoinCodesRoutes(router chi.Router) {
+	router.Route("/join-code", func(subRouter chi.Router) {
+		subRouter.Use(r.AuthMiddleware, r.RequireRole(adminOnly...), r.AuditAdminAction)
+		subRouter.Get("/", r.getJoinCodes)
+		subRouter.Get("/{code_id}/qr", r.getJoinCodeQR)
+		subRouter.Post("/", r.createJoinCodes)
+		subRouter.Delete("/{code_id}", r.revokeJoinCode)
+	})
+}
+
+// joinCodeErrorStatus возвращает статус ответа для ошибок кодов регистрации.
+func joinCodeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, teams.ErrInvalidJoinCodeParams),
+		errors.Is(err, teams.ErrNoRegistrationPeriod),
+		errors.Is(err, teams.ErrInvalidCredentials):
+		return http.StatusBadRequest
+	case errors.Is(err, teams.ErrJoinCodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, teams.ErrTeamNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, teams.ErrJoinCodeInactive):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type (
+	joinCodeResp struct {
+		ID        int64      `json:"id"`
+		Code      string     `json:"code"`
+		CreatedAt time.Time  `json:"createdAt"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+		MaxUses   int        `json:"maxUses"`
+		Uses      int        `json:"uses"`
+		Revoked   bool       `json:"revoked"`
+		Active    bool       `json:"active"`
+	}
+	getJoinCodesResp struct {
+		Data []joinCodeResp `json:"data"`
+	}
+)
+
+func toJoinCodeResp(code models.JoinCode, now time.Time) joinCodeResp {
+	return joinCodeResp{
+		ID:        code.ID,
+		Code:      code.Code,
+		CreatedAt: code.CreatedAt,
+		ExpiresAt: code.ExpiresAt,
+		MaxUses:   code.MaxUses,
+		Uses:      code.Uses,
+		Revoked:   code.RevokedAt != nil,
+		Active:    code.IsActive(now),
+	}
+}
+
+func (r *Router) writeJoinCodes(resp http.ResponseWriter, status int, codes []models.JoinCode) {
+	now := time.Now()
+	response, err := jsoniter.Marshal(
+		getJoinCodesResp{
+			Data: lo.Map(codes, func(item models.JoinCode, _ int) joinCodeResp {
+				return toJoinCodeResp(item, now)
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(status)
+	_, _ = resp.Write(response)
+}
+
+func (r *Router) getJoinCodes(resp http.ResponseWriter, req *http.Request) {
+	codes, err := r.teamService.GetJoinCodes(req.Context(), sessionIDFromContext(req.Context()))
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetJoinCodes error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	r.writeJoinCodes(resp, http.StatusOK, codes)
+	return
+}
+
+type createJoinCodesReq struct {
+	Count   int `json:"count"`
+	MaxUses int `json:"maxUses"`
+	// TTLMinutes — срок действия кодов в минутах, 0 — бессрочные.
+	TTLMinutes int `json:"ttlMinutes"`
+}
+
+func (r *Router) createJoinCodes(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createJoinCodesReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	codes, err := r.teamService.CreateJoinCodes(
+		req.Context(),
+		teams.CreateJoinCodesParams{
+			SessionID: sessionIDFromContext(req.Context()),
+			Count:     request.Count,
+			MaxUses:   request.MaxUses,
+			TTL:       time.Duration(request.TTLMinutes) * time.Minute,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("create join codes error")
+		status := joinCodeErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	r.writeJoinCodes(resp, http.StatusCreated, codes)
+	return
+}
+
+func (r *Router) revokeJoinCode(resp http.ResponseWriter, req *http.Request) {
+	codeID, err := strconv.ParseInt(chi.URLParam(req, "code_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.teamService.RevokeJoinCode(req.Context(), sessionIDFromContext(req.Context()), codeID); err != nil {
+		r.log.Error().Err(err).Msg("revoke join code error")
+		status := joinCodeErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+// getJoinCodeQR отдаёт PNG с QR-кодом. Если задан joinURL, в QR кодируется ссылка на страницу
+// регистрации с параметром code, иначе сам код.
+func (r *Router) getJoinCodeQR(resp http.ResponseWriter, req *http.Request) {
+	codeID, err := strconv.ParseInt(chi.URLParam(req, "code_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	code, err := r.teamService.GetJoinCode(req.Context(), sessionIDFromContext(req.Context()), codeID)
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetJoinCode error")
+		status := joinCodeErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	content := code.Code
+	if r.joinURL != "" {
+		content = r.joinURL + "?code=" + url.QueryEscape(code.Code)
+	}
+	png, err := qrcode.Encode(content, qrcode.Medium, joinCodeQRSize)
+	if err != nil {
+		r.log.Error().Err(err).Msg("qrcode.Encode error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.Header().Set("Content-Type", "image/png")
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(png)
+	return
+}
+
+type registrationByCodeReq struct {
+	Code     string `json:"code"`
+	TeamName string `json:"teamName"`
+	Password string `json:"password"`
+}
+
+func (r *Router) registrationByCode(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request registrationByCodeReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if _, err = r.teamService.CreateByJoinCode(
+		req.Context(),
+		teams.CreateByJoinCodeParams{
+			Code:     request.Code,
+			Name:     request.TeamName,
+			Password: request.Password,
+		},
+	); err != nil {
+		r.log.Error().Err(err).Msg("create team by join code error")
+		status := joinCodeErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	return
+}
@@ -0,0 +1,248 @@
+package v1
+
+import (
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/market"
+	"investment-game-backend/internal/services/teams"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (r *Router) initMarketRoutes(router chi.Router) {
+	router.Route("/market", func(subRouter chi.Router) {
+		subRouter.Use(r.AuthMiddleware)
+		subRouter.With(r.RequireRole(allRoles...)).Get("/order-book/{company_id}", r.getOrderBook)
+		subRouter.Group(func(teamRouter chi.Router) {
+			teamRouter.Use(r.RequireRole(traders...))
+			teamRouter.With(r.RequireTradePermission).Post("/order", r.placeMarketOrder)
+			teamRouter.With(r.RequireOwnTeam, r.RequireTradePermission).Delete("/{team_id}/order/{order_id}", r.cancelMarketOrder)
+			teamRouter.With(r.RequireOwnTeam).Get("/{team_id}/orders", r.getTeamMarketOrders)
+		})
+	})
+}
+
+const (
+	errSelfTrade     = 10009
+	errInvalidOrder  = 10010
+	errOrderNotFound = 10011
+	errOrderNotOpen  = 10012
+)
+
+// marketErrors сопоставляет ошибки сервиса market кодам ответа.
+var marketErrors = []codedError{
+	{err: market.ErrInvalidOrder, code: errInvalidOrder, status: http.StatusBadRequest},
+	{err: teams.ErrIsNoTradePeriod, code: errIsNoTradePeriod, status: http.StatusBadRequest},
+	{err: teams.ErrGamePaused, code: errGamePaused, status: http.StatusBadRequest},
+	{err: teams.ErrTradingFrozen, code: errTradingFrozen, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrIncorrectCountOfShares, code: errIncorrectCountOfShares, status: http.StatusBadRequest},
+	{err: teams.ErrShortLimitExceeded, code: errShortLimitExceeded, status: http.StatusBadRequest},
+	{err: market.ErrSelfTrade, code: errSelfTrade, status: http.StatusBadRequest},
+	{err: market.ErrOrderNotFound, code: errOrderNotFound, status: http.StatusNotFound},
+	{err: market.ErrOrderNotOpen, code: errOrderNotOpen, status: http.StatusBadRequest},
+}
+
+type (
+	placeMarketOrderReq struct {
+		TeamID    int64            `json:"teamId"`
+		CompanyID int64            `json:"companyId"`
+		Side      models.OrderSide `json:"side"`
+		Price     int64            `json:"price"`
+		Count     int64            `json:"count"`
+	}
+	marketOrderResp struct {
+		ID        int64              `json:"id"`
+		CompanyID int64              `json:"companyId"`
+		Side      models.OrderSide   `json:"side"`
+		Round     int                `json:"round"`
+		Price     int64              `json:"price"`
+		Count     int64              `json:"count"`
+		Filled    int64              `json:"filled"`
+		Status    models.OrderStatus `json:"status"`
+		CreatedAt time.Time          `json:"createdAt"`
+	}
+)
+
+func toMarketOrderResp(order models.MarketOrder) marketOrderResp {
+	return marketOrderResp{
+		ID:        order.ID,
+		CompanyID: order.CompanyID,
+		Side:      order.Side,
+		Round:     order.Round,
+		Price:     order.Price,
+		Count:     order.Count,
+		Filled:    order.Filled,
+		Status:    order.Status,
+		CreatedAt: order.CreatedAt,
+	}
+}
+
+func (r *Router) placeMarketOrder(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request placeMarketOrderReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if writeForbiddenIfForeignTeam(resp, req, request.TeamID) {
+		return
+	}
+
+	order, err := r.marketService.PlaceOrder(
+		req.Context(),
+		market.PlaceOrderParams{
+			TeamID:    request.TeamID,
+			MemberID:  memberIDFromContext(req.Context()),
+			CompanyID: request.CompanyID,
+			Side:      request.Side,
+			Price:     request.Price,
+			Count:     request.Count,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("place market order error")
+		r.writeCodedError(resp, err, marketErrors)
+		return
+	}
+
+	response, err := jsoniter.Marshal(toMarketOrderResp(*order))
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+func (r *Router) cancelMarketOrder(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+	orderID, err := strconv.ParseInt(chi.URLParam(req, "order_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.marketService.CancelOrder(req.Context(), teamID, orderID); err != nil {
+		r.log.Error().Err(err).Msg("cancel market order error")
+		r.writeCodedError(resp, err, marketErrors)
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+type getTeamMarketOrdersResp struct {
+	Data []marketOrderResp `json:"data"`
+}
+
+func (r *Router) getTeamMarketOrders(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	orders, err := r.marketService.GetTeamOrders(req.Context(), teamID)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get team market orders error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getTeamMarketOrdersResp{
+			Data: lo.Map(orders, func(item models.MarketOrder, _ int) marketOrderResp {
+				return toMarketOrderResp(item)
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	getOrderBookResp struct {
+		CompanyID int64                   `json:"companyId"`
+		Bids      []getOrderBookRespLevel `json:"bids"`
+		Asks      []getOrderBookRespLevel `json:"asks"`
+	}
+	getOrderBookRespLevel struct {
+		Price int64 `json:"price"`
+		Count int64 `json:"count"`
+	}
+)
+
+func (r *Router) getOrderBook(resp http.ResponseWriter, req *http.Request) {
+	companyID, err := strconv.ParseInt(chi.URLParam(req, "company_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	book := r.marketService.GetOrderBook(req.Context(), sessionIDFromContext(req.Context()), companyID)
+
+	toLevel := func(item models.OrderBookLevel, _ int) getOrderBookRespLevel {
+		return getOrderBookRespLevel{
+			Price: item.Price,
+			Count: item.Count,
+		}
+	}
+	response, err := jsoniter.Marshal(
+		getOrderBookResp{
+			CompanyID: book.CompanyID,
+			Bids:      lo.Map(book.Bids, toLevel),
+			Asks:      lo.Map(book.Asks, toLevel),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
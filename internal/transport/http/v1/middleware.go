@@ -2,31 +2,242 @@ package v1
 
 import (
 	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v5"
+	"investment-game-backend/internal/models"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+)
+
+// ctxKey — тип ключей контекста запроса, чтобы значения не пересекались с другими пакетами.
+type ctxKey int
+
+const (
+	roleCtxKey ctxKey = iota
+	sessionIDCtxKey
+	teamIDCtxKey
+	adminIDCtxKey
+	authSessionIDCtxKey
+	memberIDCtxKey
+	canTradeCtxKey
+	accessExpiresAtCtxKey
+)
+
+// Наборы ролей, из которых составляются права маршрутов.
+var (
+	adminOnly = []models.Role{models.RoleAdmin}
+	traders   = []models.Role{models.RoleAdmin, models.RoleTeam}
+	allRoles  = []models.Role{models.RoleAdmin, models.RoleTeam, models.RoleSpectator}
 )
 
 func (r *Router) AuthMiddleware(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		token := req.Header.Get("Authorization")
 		token = strings.TrimPrefix(token, "Bearer ")
-		claims := jwt.MapClaims{}
-		t, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(r.secretJWT), nil
-		})
+		ctx, err := r.authenticate(req.Context(), token)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(err.Error()))
+			_, _ = w.Write([]byte(err.Error()))
 			return
 		}
-		if !t.Valid {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(http.StatusText(http.StatusUnauthorized)))
+		handler.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// errAccessTokenRevoked — access-токен отозван при выходе или исключении команды.
+var errAccessTokenRevoked = errors.New("access token revoked")
+
+// authenticate проверяет access-токен и кладёт его claims в контекст. Используется
+// AuthMiddleware и websocket-соединениями, где токен приходит не в заголовке.
+func (r *Router) authenticate(ctx context.Context, token string) (context.Context, error) {
+	claims := jwt.MapClaims{}
+	t, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(r.secretJWT), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !t.Valid {
+		return nil, errors.New(http.StatusText(http.StatusUnauthorized))
+	}
+	// jti отозванных при выходе или исключении команды токенов проверяется до истечения их exp
+	if jti, _ := claims["jti"].(string); jti != "" && r.authService.IsAccessTokenRevoked(jti) {
+		return nil, errAccessTokenRevoked
+	}
+	role, _ := claims["role"].(string)
+	ctx = context.WithValue(ctx, roleCtxKey, models.Role(role))
+	sessionID, _ := claims["sid"].(float64)
+	ctx = context.WithValue(ctx, sessionIDCtxKey, int64(sessionID))
+	authSessionID, _ := claims["aid"].(float64)
+	ctx = context.WithValue(ctx, authSessionIDCtxKey, int64(authSessionID))
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		ctx = context.WithValue(ctx, accessExpiresAtCtxKey, exp.Time)
+	}
+	// sub содержит ID команды или администратора в зависимости от роли
+	sub, _ := claims["sub"].(float64)
+	switch models.Role(role) {
+	case models.RoleTeam:
+		ctx = context.WithValue(ctx, teamIDCtxKey, int64(sub))
+		// mid есть только у токенов участников; cap выдаётся капитану и общему входу команды без участников
+		if memberID, ok := claims["mid"].(float64); ok {
+			ctx = context.WithValue(ctx, memberIDCtxKey, int64(memberID))
+		}
+		canTrade, _ := claims["cap"].(bool)
+		ctx = context.WithValue(ctx, canTradeCtxKey, canTrade)
+	case models.RoleAdmin:
+		// токены, выданные до появления учётных записей администраторов, не содержат ID администратора
+		if sub <= 0 {
+			return nil, errors.New("admin token without admin id")
+		}
+		ctx = context.WithValue(ctx, adminIDCtxKey, int64(sub))
+	}
+	return ctx, nil
+}
+
+// RequireRole пропускает запрос, только если роль из токена входит в roles.
+// Должен подключаться после AuthMiddleware.
+func (r *Router) RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if !slices.Contains(roles, roleFromContext(req.Context())) {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(http.StatusText(http.StatusForbidden)))
+				return
+			}
+			handler.ServeHTTP(w, req)
+		})
+	}
+}
+
+// RequireTradePermission пропускает запросы, изменяющие портфель команды, только от администратора,
+// капитана или общего входа команды без участников. Остальные входы команды получают 403.
+func (r *Router) RequireTradePermission(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if roleFromContext(req.Context()) == models.RoleTeam && !canTradeFromContext(req.Context()) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusForbidden)))
 			return
 		}
-		role := claims["role"]
-		ctx := context.WithValue(req.Context(), "role", role)
-		handler.ServeHTTP(w, req.WithContext(ctx))
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// RequireOwnTeam запрещает команде обращаться к чужому {team_id} из пути. Подключается
+// к конкретному маршруту через With, чтобы параметры пути были уже разобраны.
+func (r *Router) RequireOwnTeam(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusBadRequest)))
+			return
+		}
+		if !canAccessTeam(req.Context(), teamID) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(http.StatusText(http.StatusForbidden)))
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// writeForbiddenIfForeignTeam используется в обработчиках, где ID команды приходит в теле запроса.
+// Возвращает true, если ответ уже записан.
+func writeForbiddenIfForeignTeam(resp http.ResponseWriter, req *http.Request, teamID int64) bool {
+	if canAccessTeam(req.Context(), teamID) {
+		return false
+	}
+	resp.WriteHeader(http.StatusForbidden)
+	_, _ = resp.Write([]byte(http.StatusText(http.StatusForbidden)))
+	return true
+}
+
+// canAccessTeam разрешает администратору любые команды, а команде - только себя.
+func canAccessTeam(ctx context.Context, teamID int64) bool {
+	switch roleFromContext(ctx) {
+	case models.RoleAdmin:
+		return true
+	case models.RoleTeam:
+		return teamIDFromContext(ctx) == teamID
+	default:
+		return false
+	}
+}
+
+func roleFromContext(ctx context.Context) models.Role {
+	role, _ := ctx.Value(roleCtxKey).(models.Role)
+	return role
+}
+
+func sessionIDFromContext(ctx context.Context) int64 {
+	sessionID, _ := ctx.Value(sessionIDCtxKey).(int64)
+	return sessionID
+}
+
+func teamIDFromContext(ctx context.Context) int64 {
+	teamID, _ := ctx.Value(teamIDCtxKey).(int64)
+	return teamID
+}
+
+func adminIDFromContext(ctx context.Context) int64 {
+	adminID, _ := ctx.Value(adminIDCtxKey).(int64)
+	return adminID
+}
+
+func authSessionIDFromContext(ctx context.Context) int64 {
+	authSessionID, _ := ctx.Value(authSessionIDCtxKey).(int64)
+	return authSessionID
+}
+
+// accessExpiresAtFromContext возвращает время истечения access-токена или нулевое время, если его нет.
+func accessExpiresAtFromContext(ctx context.Context) time.Time {
+	expiresAt, _ := ctx.Value(accessExpiresAtCtxKey).(time.Time)
+	return expiresAt
+}
+
+// memberIDFromContext возвращает ID участника команды или nil при входе общим паролем команды.
+func memberIDFromContext(ctx context.Context) *int64 {
+	memberID, ok := ctx.Value(memberIDCtxKey).(int64)
+	if !ok {
+		return nil
+	}
+	return &memberID
+}
+
+func canTradeFromContext(ctx context.Context) bool {
+	canTrade, _ := ctx.Value(canTradeCtxKey).(bool)
+	return canTrade
+}
+
+// AuditAdminAction записывает в аудит успешно выполненные администратором запросы, изменяющие данные.
+// Действие описывается методом и шаблоном маршрута, например "PATCH /api/game/round/start".
+// Подключается ко всем группам маршрутов, доступным только администратору.
+func (r *Router) AuditAdminAction(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		handler.ServeHTTP(ww, req)
+
+		if ww.Status() >= http.StatusBadRequest {
+			return
+		}
+		action := req.Method + " " + chi.RouteContext(req.Context()).RoutePattern()
+		if err := r.adminsService.RecordAction(
+			req.Context(),
+			adminIDFromContext(req.Context()),
+			sessionIDFromContext(req.Context()),
+			action,
+		); err != nil {
+			r.log.Error().Err(err).Str("action", action).Msg("record admin action error")
+		}
 	})
 }
@@ -0,0 +1,247 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	randomevents "investment-game-backend/internal/services/random_events"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+func (r *Router) initRandomEventsRoutes(router chi.Router) {
+	router.Route("/random-event", func(subRouter chi.Router) {
+		subRouter.Use(r.AuthMiddleware)
+		subRouter.With(r.RequireRole(allRoles...)).Get("/", r.getAllRandomEvents)
+		subRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Post("/", r.createRandomEvent)
+			adminRouter.Put("/{random_event_id}", r.updateRandomEvent)
+			adminRouter.Patch("/{random_event_id}", r.archiveRandomEvent)
+		})
+	})
+}
+
+type (
+	createRandomEventReq struct {
+		Name            string                       `json:"name"`
+		Description     string                       `json:"description"`
+		EffectType      models.RandomEventEffectType `json:"effectType"`
+		BalanceDelta    int64                        `json:"balanceDelta"`
+		CompanyID       *int64                       `json:"companyId"`
+		PriceMultiplier float64                      `json:"priceMultiplier"`
+		Probability     int                          `json:"probability"`
+	}
+	randomEventResp struct {
+		ID              int64                        `json:"id"`
+		Name            string                       `json:"name"`
+		Description     string                       `json:"description"`
+		EffectType      models.RandomEventEffectType `json:"effectType"`
+		BalanceDelta    int64                        `json:"balanceDelta"`
+		CompanyID       *int64                       `json:"companyId"`
+		PriceMultiplier float64                      `json:"priceMultiplier"`
+		Probability     int                          `json:"probability"`
+	}
+)
+
+func (r *Router) createRandomEvent(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createRandomEventReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	event, err := r.randomEventsService.Create(
+		req.Context(),
+		randomevents.CreateParams{
+			Name:            request.Name,
+			Description:     request.Description,
+			EffectType:      request.EffectType,
+			BalanceDelta:    request.BalanceDelta,
+			CompanyID:       request.CompanyID,
+			PriceMultiplier: request.PriceMultiplier,
+			Probability:     request.Probability,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("create random event error")
+		if errors.Is(err, randomevents.ErrInvalidParams) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		randomEventResp{
+			ID:              event.ID,
+			Name:            event.Name,
+			Description:     event.Description,
+			EffectType:      event.EffectType,
+			BalanceDelta:    event.BalanceDelta,
+			CompanyID:       event.CompanyID,
+			PriceMultiplier: event.PriceMultiplier,
+			Probability:     event.Probability,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	getAllRandomEventsResp struct {
+		Data []randomEventResp `json:"data"`
+	}
+)
+
+func (r *Router) getAllRandomEvents(resp http.ResponseWriter, req *http.Request) {
+	events, err := r.randomEventsService.GetAll(req.Context())
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetAll random events error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getAllRandomEventsResp{
+			Data: lo.Map(
+				events,
+				func(item models.RandomEvent, _ int) randomEventResp {
+					return randomEventResp{
+						ID:              item.ID,
+						Name:            item.Name,
+						Description:     item.Description,
+						EffectType:      item.EffectType,
+						BalanceDelta:    item.BalanceDelta,
+						CompanyID:       item.CompanyID,
+						PriceMultiplier: item.PriceMultiplier,
+						Probability:     item.Probability,
+					}
+				},
+			),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	updateRandomEventReq struct {
+		Name            string                       `json:"name"`
+		Description     string                       `json:"description"`
+		EffectType      models.RandomEventEffectType `json:"effectType"`
+		BalanceDelta    int64                        `json:"balanceDelta"`
+		CompanyID       *int64                       `json:"companyId"`
+		PriceMultiplier float64                      `json:"priceMultiplier"`
+		Probability     int                          `json:"probability"`
+	}
+)
+
+func (r *Router) updateRandomEvent(resp http.ResponseWriter, req *http.Request) {
+	randomEventIDParam := chi.URLParam(req, "random_event_id")
+	randomEventID, err := strconv.Atoi(randomEventIDParam)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request updateRandomEventReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	err = r.randomEventsService.Update(
+		req.Context(),
+		randomevents.UpdateParams{
+			ID:              int64(randomEventID),
+			Name:            request.Name,
+			Description:     request.Description,
+			EffectType:      request.EffectType,
+			BalanceDelta:    request.BalanceDelta,
+			CompanyID:       request.CompanyID,
+			PriceMultiplier: request.PriceMultiplier,
+			Probability:     request.Probability,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("update random event error")
+		if errors.Is(err, randomevents.ErrInvalidParams) {
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(err.Error()))
+			return
+		}
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
+
+func (r *Router) archiveRandomEvent(resp http.ResponseWriter, req *http.Request) {
+	randomEventIDParam := chi.URLParam(req, "random_event_id")
+	randomEventID, err := strconv.Atoi(randomEventIDParam)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if err = r.randomEventsService.Archive(req.Context(), int64(randomEventID)); err != nil {
+		r.log.Error().Err(err).Msg("archive random event error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
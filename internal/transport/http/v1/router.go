@@ -20,6 +20,11 @@ type Router struct {
 	teamService           services.Teams
 	authService           services.Auth
 	additionalInfoService services.AdditionalInfos
+	randomEventsService   services.RandomEvents
+	marketService         services.Market
+	bondsService          services.Bonds
+	adminsService         services.Admins
+	joinURL               string
 	upgrader              websocket.Upgrader
 	teamsNotifier         *games.TeamsNotifier
 }
@@ -31,9 +36,15 @@ type Config struct {
 	TeamsService          services.Teams
 	AuthService           services.Auth
 	AdditionalInfoService services.AdditionalInfos
-	SecretJWT             string
-	Log                   *zerolog.Logger
-	TeamsNotifier         *games.TeamsNotifier
+	RandomEventsService   services.RandomEvents
+	MarketService         services.Market
+	BondsService          services.Bonds
+	AdminsService         services.Admins
+	// JoinURL — адрес страницы регистрации по коду, кодируется в QR.
+	JoinURL       string
+	SecretJWT     string
+	Log           *zerolog.Logger
+	TeamsNotifier *games.TeamsNotifier
 }
 
 func NewRouter(cfg Config) *Router {
@@ -46,6 +57,11 @@ func NewRouter(cfg Config) *Router {
 		teamService:           cfg.TeamsService,
 		authService:           cfg.AuthService,
 		additionalInfoService: cfg.AdditionalInfoService,
+		randomEventsService:   cfg.RandomEventsService,
+		marketService:         cfg.MarketService,
+		bondsService:          cfg.BondsService,
+		adminsService:         cfg.AdminsService,
+		joinURL:               cfg.JoinURL,
 		log:                   cfg.Log,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool { return true },
@@ -67,11 +83,17 @@ func (r *Router) initRouter() {
 		AllowCredentials: true,
 	}))
 	r.initGamesRoutes(apiRouter)
+	r.initSessionsRoutes(apiRouter)
 	r.initSettingsRoutes(apiRouter)
 	r.initAuthRoutes(apiRouter)
 	r.initCompanyRoutes(apiRouter)
 	r.initAdditionalInfosRoutes(apiRouter)
+	r.initRandomEventsRoutes(apiRouter)
 	r.initTeamsRoutes(apiRouter)
+	r.initMarketRoutes(apiRouter)
+	r.initBondsRoutes(apiRouter)
+	r.initAdminsRoutes(apiRouter)
+	r.initJoinCodesRoutes(apiRouter)
 	r.initWebsocketRouter(apiRouter)
 
 	r.router.Mount("/api", apiRouter)
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"io"
+	"net/http"
+)
+
+func (r *Router) initSessionsRoutes(router chi.Router) {
+	router.Route("/session", func(subRouter chi.Router) {
+		subRouter.Get("/", r.getSessions)
+		subRouter.Group(func(authRouter chi.Router) {
+			authRouter.Use(r.AuthMiddleware, r.RequireRole(adminOnly...), r.AuditAdminAction)
+			authRouter.Post("/", r.createSession)
+		})
+	})
+}
+
+type (
+	getSessionsResp struct {
+		Data []sessionResp `json:"data"`
+	}
+	sessionResp struct {
+		ID    int64            `json:"id"`
+		Name  string           `json:"name"`
+		State models.GameState `json:"state"`
+	}
+)
+
+func (r *Router) getSessions(resp http.ResponseWriter, req *http.Request) {
+	sessions, err := r.gamesService.GetSessions(req.Context())
+	if err != nil {
+		r.log.Error().Err(err).Msg("games service: get sessions error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getSessionsResp{
+			Data: lo.Map(sessions, func(item models.Game, _ int) sessionResp {
+				return sessionResp{
+					ID:    item.ID,
+					Name:  item.Name,
+					State: item.State,
+				}
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type (
+	createSessionReq struct {
+		Name string `json:"name"`
+	}
+)
+
+func (r *Router) createSession(resp http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createSessionReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	session, err := r.gamesService.CreateSession(req.Context(), request.Name)
+	if err != nil {
+		r.log.Error().Err(err).Msg("games service: create session error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		sessionResp{
+			ID:    session.ID,
+			Name:  session.Name,
+			State: session.State,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
@@ -12,8 +12,8 @@ import (
 func (r *Router) initSettingsRoutes(router chi.Router) {
 	router.Route("/settings", func(settingsRouter chi.Router) {
 		settingsRouter.Use(r.AuthMiddleware)
-		settingsRouter.Get("/", r.getSettings)
-		settingsRouter.Put("/", r.updateSettings)
+		settingsRouter.With(r.RequireRole(allRoles...)).Get("/", r.getSettings)
+		settingsRouter.With(r.RequireRole(adminOnly...), r.AuditAdminAction).Put("/", r.updateSettings)
 	})
 }
 
@@ -21,10 +21,21 @@ type (
 	getSettingsResp struct {
 		RoundsCount               int    `json:"roundsCount"`
 		RoundsDuration            string `json:"roundsDuration"`
+		BriefingDuration          string `json:"briefingDuration"`
+		ResultsDuration           string `json:"resultsDuration"`
 		LinkToPDF                 string `json:"linkToPdf"`
 		EnableRandomEvents        bool   `json:"enableRandomEvents"`
 		DefaultBalance            int64  `json:"defaultBalance"`
 		DefaultAdditionalInfoCost int64  `json:"defaultAdditionalInfoCost"`
+		ShortSellingLimit         int64  `json:"shortSellingLimit"`
+		ShortMarginPercent        int64  `json:"shortMarginPercent"`
+		EnableDemandPricing       bool   `json:"enableDemandPricing"`
+		DemandElasticityPercent   int64  `json:"demandElasticityPercent"`
+		CommissionFlat            int64  `json:"commissionFlat"`
+		CommissionPercent         int64  `json:"commissionPercent"`
+		CapitalGainsTaxPercent    int64  `json:"capitalGainsTaxPercent"`
+		CreditLimit               int64  `json:"creditLimit"`
+		LoanInterestPercent       int64  `json:"loanInterestPercent"`
 	}
 )
 
@@ -41,10 +52,21 @@ func (r *Router) getSettings(resp http.ResponseWriter, req *http.Request) {
 		getSettingsResp{
 			RoundsCount:               settings.RoundsCount,
 			RoundsDuration:            settings.RoundsDuration.String(),
+			BriefingDuration:          settings.BriefingDuration.String(),
+			ResultsDuration:           settings.ResultsDuration.String(),
 			LinkToPDF:                 settings.LinkToPDF,
 			EnableRandomEvents:        settings.EnableRandomEvents,
 			DefaultBalance:            settings.DefaultBalanceAmount,
 			DefaultAdditionalInfoCost: settings.DefaultAdditionalInfoCost,
+			ShortSellingLimit:         settings.ShortSellingLimit,
+			ShortMarginPercent:        settings.ShortMarginPercent,
+			EnableDemandPricing:       settings.EnableDemandPricing,
+			DemandElasticityPercent:   settings.DemandElasticityPercent,
+			CommissionFlat:            settings.CommissionFlat,
+			CommissionPercent:         settings.CommissionPercent,
+			CapitalGainsTaxPercent:    settings.CapitalGainsTaxPercent,
+			CreditLimit:               settings.CreditLimit,
+			LoanInterestPercent:       settings.LoanInterestPercent,
 		},
 	)
 	if err != nil {
@@ -63,10 +85,21 @@ type (
 	updateSettingsReq struct {
 		RoundsCount               int    `json:"roundsCount"`
 		RoundsDuration            string `json:"roundsDuration"`
+		BriefingDuration          string `json:"briefingDuration"`
+		ResultsDuration           string `json:"resultsDuration"`
 		LinkToPDF                 string `json:"linkToPdf"`
 		EnableRandomEvents        bool   `json:"enableRandomEvents"`
 		DefaultBalance            int64  `json:"defaultBalance"`
 		DefaultAdditionalInfoCost int64  `json:"defaultAdditionalInfoCost"`
+		ShortSellingLimit         int64  `json:"shortSellingLimit"`
+		ShortMarginPercent        int64  `json:"shortMarginPercent"`
+		EnableDemandPricing       bool   `json:"enableDemandPricing"`
+		DemandElasticityPercent   int64  `json:"demandElasticityPercent"`
+		CommissionFlat            int64  `json:"commissionFlat"`
+		CommissionPercent         int64  `json:"commissionPercent"`
+		CapitalGainsTaxPercent    int64  `json:"capitalGainsTaxPercent"`
+		CreditLimit               int64  `json:"creditLimit"`
+		LoanInterestPercent       int64  `json:"loanInterestPercent"`
 	}
 )
 
@@ -93,16 +126,45 @@ func (r *Router) updateSettings(resp http.ResponseWriter, req *http.Request) {
 		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
 		return
 	}
+	// длительности фаз автопилота необязательны, пустое значение оставляет текущее
+	var briefingDur, resultsDur time.Duration
+	if request.BriefingDuration != "" {
+		if briefingDur, err = time.ParseDuration(request.BriefingDuration); err != nil {
+			r.log.Error().Err(err).Msg("parse briefing duration error")
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+			return
+		}
+	}
+	if request.ResultsDuration != "" {
+		if resultsDur, err = time.ParseDuration(request.ResultsDuration); err != nil {
+			r.log.Error().Err(err).Msg("parse results duration error")
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+			return
+		}
+	}
 
 	if err = r.settingsService.Update(
 		req.Context(),
 		settingsservice.UpdateParams{
 			RoundsCount:               request.RoundsCount,
 			RoundsDuration:            dur,
+			BriefingDuration:          briefingDur,
+			ResultsDuration:           resultsDur,
 			LinkToPDF:                 request.LinkToPDF,
 			EnableRandomEvents:        request.EnableRandomEvents,
 			DefaultBalance:            request.DefaultBalance,
 			DefaultAdditionalInfoCost: request.DefaultAdditionalInfoCost,
+			ShortSellingLimit:         request.ShortSellingLimit,
+			ShortMarginPercent:        request.ShortMarginPercent,
+			EnableDemandPricing:       request.EnableDemandPricing,
+			DemandElasticityPercent:   request.DemandElasticityPercent,
+			CommissionFlat:            request.CommissionFlat,
+			CommissionPercent:         request.CommissionPercent,
+			CapitalGainsTaxPercent:    request.CapitalGainsTaxPercent,
+			CreditLimit:               request.CreditLimit,
+			LoanInterestPercent:       request.LoanInterestPercent,
 		},
 	); err != nil {
 		r.log.Error().Err(err).Msg("settings service: update error")
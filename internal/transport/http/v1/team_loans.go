@@ -0,0 +1,100 @@
+package v1
+
+import (
+	"context"
+	jsoniter "github.com/json-iterator/go"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/teams"
+	"io"
+	"net/http"
+)
+
+const (
+	errInvalidLoanAmount   = 10015
+	errCreditLimitExceeded = 10016
+)
+
+// loanErrors сопоставляет ошибки операций с кредитом кодам ответа.
+var loanErrors = []codedError{
+	{err: teams.ErrInvalidLoanAmount, code: errInvalidLoanAmount, status: http.StatusBadRequest},
+	{err: teams.ErrCreditLimitExceeded, code: errCreditLimitExceeded, status: http.StatusBadRequest},
+	{err: teams.ErrIsNoTradePeriod, code: errIsNoTradePeriod, status: http.StatusBadRequest},
+	{err: teams.ErrGamePaused, code: errGamePaused, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrConcurrentPurchase, code: errConcurrentPurchase, status: http.StatusConflict},
+}
+
+type (
+	teamLoanReq struct {
+		TeamID int64 `json:"teamId"`
+		Amount int64 `json:"amount"`
+	}
+	teamLoanResp struct {
+		BalanceAmount int64 `json:"balanceAmount"`
+		LoanAmount    int64 `json:"loanAmount"`
+	}
+)
+
+func (r *Router) teamBorrow(resp http.ResponseWriter, req *http.Request) {
+	r.handleTeamLoan(resp, req, r.teamService.Borrow)
+}
+
+func (r *Router) teamRepay(resp http.ResponseWriter, req *http.Request) {
+	r.handleTeamLoan(resp, req, r.teamService.Repay)
+}
+
+func (r *Router) handleTeamLoan(
+	resp http.ResponseWriter,
+	req *http.Request,
+	operation func(ctx context.Context, params teams.LoanParams) (models.Balance, error),
+) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request teamLoanReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if writeForbiddenIfForeignTeam(resp, req, request.TeamID) {
+		return
+	}
+
+	balance, err := operation(
+		req.Context(),
+		teams.LoanParams{
+			TeamID: request.TeamID,
+			Amount: request.Amount,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("team loan operation error")
+		r.writeCodedError(resp, err, loanErrors)
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		teamLoanResp{
+			BalanceAmount: balance.Amount,
+			LoanAmount:    balance.Loan,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/teams"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// teamMemberErrorStatus возвращает статус ответа для ошибок управления участниками команды.
+func teamMemberErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, teams.ErrInvalidMemberParams):
+		return http.StatusBadRequest
+	case errors.Is(err, teams.ErrMemberNameTaken):
+		return http.StatusConflict
+	case errors.Is(err, teams.ErrMemberNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type (
+	teamMemberResp struct {
+		ID        int64     `json:"id"`
+		Name      string    `json:"name"`
+		IsCaptain bool      `json:"isCaptain"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	getTeamMembersResp struct {
+		Data []teamMemberResp `json:"data"`
+	}
+)
+
+func toTeamMemberResp(member models.TeamMember) teamMemberResp {
+	return teamMemberResp{
+		ID:        member.ID,
+		Name:      member.Name,
+		IsCaptain: member.IsCaptain,
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+func (r *Router) getTeamMembers(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	members, err := r.teamService.GetMembers(req.Context(), teamID)
+	if err != nil {
+		r.log.Error().Err(err).Msg("GetMembers error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(
+		getTeamMembersResp{
+			Data: lo.Map(members, func(item models.TeamMember, _ int) teamMemberResp {
+				return toTeamMemberResp(item)
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+type createTeamMemberReq struct {
+	Name      string `json:"name"`
+	Password  string `json:"password"`
+	IsCaptain bool   `json:"isCaptain"`
+}
+
+func (r *Router) createTeamMember(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request createTeamMemberReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	member, err := r.teamService.CreateMember(
+		req.Context(),
+		teams.CreateMemberParams{
+			TeamID:    teamID,
+			Name:      request.Name,
+			Password:  request.Password,
+			IsCaptain: request.IsCaptain,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("create team member error")
+		status := teamMemberErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(toTeamMemberResp(*member))
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(response)
+	return
+}
+
+type updateTeamMemberReq struct {
+	Password  string `json:"password"`
+	IsCaptain bool   `json:"isCaptain"`
+}
+
+func (r *Router) updateTeamMember(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+	memberID, err := strconv.ParseInt(chi.URLParam(req, "member_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.log.Error().Err(err).Msg("error on request body read")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	var request updateTeamMemberReq
+	if err = jsoniter.Unmarshal(body, &request); err != nil {
+		r.log.Error().Err(err).Msg("json unmarshal error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	member, err := r.teamService.UpdateMember(
+		req.Context(),
+		teams.UpdateMemberParams{
+			TeamID:    teamID,
+			ID:        memberID,
+			Password:  request.Password,
+			IsCaptain: request.IsCaptain,
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("update team member error")
+		status := teamMemberErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+
+	response, err := jsoniter.Marshal(toTeamMemberResp(*member))
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+	return
+}
+
+// deleteTeamMember удаляет участника и завершает его сессии на всех устройствах.
+func (r *Router) deleteTeamMember(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+	memberID, err := strconv.ParseInt(chi.URLParam(req, "member_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	if err = r.teamService.DeleteMember(req.Context(), teamID, memberID); err != nil {
+		r.log.Error().Err(err).Msg("delete team member error")
+		status := teamMemberErrorStatus(err)
+		resp.WriteHeader(status)
+		_, _ = resp.Write([]byte(http.StatusText(status)))
+		return
+	}
+	if err = r.authService.KickTeamMember(req.Context(), teamID, memberID); err != nil {
+		r.log.Error().Err(err).Int64("member_id", memberID).Msg("kick team member error")
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	return
+}
@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"github.com/go-chi/chi/v5"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/samber/lo"
+	"investment-game-backend/internal/models"
+	"investment-game-backend/internal/services/teams"
+	"net/http"
+	"strconv"
+)
+
+type (
+	getTransactionsResp struct {
+		Data []getTransactionsRespItem `json:"data"`
+	}
+	getTransactionsRespItem struct {
+		ID               int64                          `json:"id"`
+		TeamID           int64                          `json:"teamId"`
+		TeamName         string                         `json:"teamName"`
+		MemberID         *int64                         `json:"memberId"`
+		MemberName       string                         `json:"memberName"`
+		Type             models.BalanceTransactionType  `json:"type"`
+		Round            int                            `json:"round"`
+		Amount           int64                          `json:"amount"`
+		AdditionalInfoID *int64                         `json:"additionalInfoId"`
+		RandomEventID    *int64                         `json:"randomEventId"`
+		ParentID         *int64                         `json:"parentId"`
+		Shares           []getTransactionsRespItemShare `json:"shares"`
+	}
+	getTransactionsRespItemShare struct {
+		CompanyID   int64  `json:"companyId"`
+		CompanyName string `json:"companyName"`
+		Count       int64  `json:"count"`
+		Price       int64  `json:"price"`
+	}
+)
+
+// parseTransactionHistoryFilter читает необязательные query-параметры round и type.
+func parseTransactionHistoryFilter(req *http.Request) (teams.TransactionHistoryFilter, error) {
+	var filter teams.TransactionHistoryFilter
+	if param := req.URL.Query().Get("round"); param != "" {
+		round, err := strconv.Atoi(param)
+		if err != nil {
+			return teams.TransactionHistoryFilter{}, err
+		}
+		filter.Round = &round
+	}
+	if param := req.URL.Query().Get("type"); param != "" {
+		trType, err := strconv.ParseInt(param, 10, 8)
+		if err != nil {
+			return teams.TransactionHistoryFilter{}, err
+		}
+		filter.Type = lo.ToPtr(models.BalanceTransactionType(trType))
+	}
+	return filter, nil
+}
+
+func (r *Router) writeTransactionHistory(resp http.ResponseWriter, history []teams.HistoryTransaction) {
+	response, err := jsoniter.Marshal(
+		getTransactionsResp{
+			Data: lo.Map(history, func(item teams.HistoryTransaction, _ int) getTransactionsRespItem {
+				return getTransactionsRespItem{
+					ID:               item.Transaction.ID,
+					TeamID:           item.TeamID,
+					TeamName:         item.TeamName,
+					MemberID:         item.Transaction.MemberID,
+					MemberName:       item.MemberName,
+					Type:             item.Transaction.Type,
+					Round:            item.Transaction.Round,
+					Amount:           item.Transaction.Amount,
+					AdditionalInfoID: item.Transaction.AdditionalInfoID,
+					RandomEventID:    item.Transaction.RandomEventID,
+					ParentID:         item.Transaction.ParentID,
+					Shares: lo.Map(item.Shares, func(share teams.HistoryTransactionShare, _ int) getTransactionsRespItemShare {
+						return getTransactionsRespItemShare{
+							CompanyID:   share.CompanyID,
+							CompanyName: share.CompanyName,
+							Count:       share.Count,
+							Price:       share.Price,
+						}
+					}),
+				}
+			}),
+		},
+	)
+	if err != nil {
+		r.log.Error().Err(err).Msg("marshal to json error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+	_, _ = resp.Write(response)
+}
+
+func (r *Router) getTeamTransactions(resp http.ResponseWriter, req *http.Request) {
+	teamID, err := strconv.ParseInt(chi.URLParam(req, "team_id"), 10, 64)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get path param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+	filter, err := parseTransactionHistoryFilter(req)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get query param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	history, err := r.teamService.GetTransactionHistory(req.Context(), teamID, filter)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get team transactions error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	r.writeTransactionHistory(resp, history)
+	return
+}
+
+func (r *Router) getGameTransactions(resp http.ResponseWriter, req *http.Request) {
+	filter, err := parseTransactionHistoryFilter(req)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get query param")
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+		return
+	}
+
+	history, err := r.teamService.GetGameTransactionHistory(req.Context(), sessionIDFromContext(req.Context()), filter)
+	if err != nil {
+		r.log.Error().Err(err).Msg("get game transactions error")
+		resp.WriteHeader(http.StatusInternalServerError)
+		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	r.writeTransactionHistory(resp, history)
+	return
+}
@@ -15,13 +15,35 @@ import (
 func (r *Router) initTeamsRoutes(router chi.Router) {
 	router.Route("/team", func(subRouter chi.Router) {
 		subRouter.Use(r.AuthMiddleware)
-		subRouter.Patch("/", r.updateTeam)
-		subRouter.Post("/purchase", r.teamPurchase)
-		subRouter.Post("/{team_id}/purchase/reset", r.teamPurchaseReset)
-		subRouter.Post("/purchase/additional-info/{team_id}", r.teamPurchaseAdditionalInfo)
-		subRouter.Get("/{team_id}", r.getTeamByID)
-		subRouter.Get("/", r.getAllTeams)
-		subRouter.Get("/statistics", r.getStatistics)
+		subRouter.Group(func(allRouter chi.Router) {
+			allRouter.Use(r.RequireRole(allRoles...))
+			allRouter.Get("/", r.getAllTeams)
+			allRouter.Get("/statistics", r.getStatistics)
+		})
+		subRouter.Group(func(teamRouter chi.Router) {
+			// ID команды из тела запроса сверяется с токеном в самих обработчиках
+			teamRouter.Use(r.RequireRole(traders...))
+			teamRouter.With(r.RequireOwnTeam).Get("/{team_id}", r.getTeamByID)
+			teamRouter.With(r.RequireOwnTeam).Get("/{team_id}/transactions", r.getTeamTransactions)
+			teamRouter.With(r.RequireOwnTeam).Get("/{team_id}/member", r.getTeamMembers)
+			teamRouter.Group(func(tradeRouter chi.Router) {
+				tradeRouter.Use(r.RequireTradePermission)
+				tradeRouter.Patch("/", r.updateTeam)
+				tradeRouter.Post("/purchase", r.teamPurchase)
+				tradeRouter.Post("/loan/borrow", r.teamBorrow)
+				tradeRouter.Post("/loan/repay", r.teamRepay)
+				tradeRouter.With(r.RequireOwnTeam).Post("/{team_id}/purchase/reset", r.teamPurchaseReset)
+				tradeRouter.With(r.RequireOwnTeam).Post("/purchase/additional-info/{team_id}", r.teamPurchaseAdditionalInfo)
+				tradeRouter.With(r.RequireOwnTeam).Post("/{team_id}/member", r.createTeamMember)
+				tradeRouter.With(r.RequireOwnTeam).Put("/{team_id}/member/{member_id}", r.updateTeamMember)
+				tradeRouter.With(r.RequireOwnTeam).Delete("/{team_id}/member/{member_id}", r.deleteTeamMember)
+			})
+		})
+		subRouter.Group(func(adminRouter chi.Router) {
+			adminRouter.Use(r.RequireRole(adminOnly...), r.AuditAdminAction)
+			adminRouter.Get("/transactions", r.getGameTransactions)
+			adminRouter.Post("/{team_id}/kick", r.kickTeam)
+		})
 	})
 }
 
@@ -50,6 +72,10 @@ func (r *Router) updateTeam(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if writeForbiddenIfForeignTeam(resp, req, request.ID) {
+		return
+	}
+
 	if err = r.teamService.Update(
 		req.Context(),
 		teams.UpdateParams{
@@ -88,8 +114,57 @@ const (
 	errIncorrectCountOfShares = 10002
 	errInsufficientBalance    = 10003
 	errNoAdditionalInfos      = 10004
+	errTradingFrozen          = 10005
+	errGamePaused             = 10006
+	errConcurrentPurchase     = 10007
+	errShortLimitExceeded     = 10008
+	errTransactionNotFound    = 10013
 )
 
+// codedError сопоставляет ошибку сервиса коду ответа и HTTP-статусу.
+type codedError struct {
+	err    error
+	code   int
+	status int
+}
+
+// writeCodedError пишет purchaseError для первой подходящей ошибки из errs, иначе отвечает 500.
+func (r *Router) writeCodedError(resp http.ResponseWriter, err error, errs []codedError) {
+	for _, item := range errs {
+		if !errors.Is(err, item.err) {
+			continue
+		}
+		response, err := jsoniter.Marshal(
+			purchaseError{
+				Code:    item.code,
+				Message: err.Error(),
+			},
+		)
+		if err != nil {
+			r.log.Error().Err(err).Msg("marshal to json error")
+			resp.WriteHeader(http.StatusInternalServerError)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+			return
+		}
+		resp.WriteHeader(item.status)
+		_, _ = resp.Write(response)
+		return
+	}
+	resp.WriteHeader(http.StatusInternalServerError)
+	_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+}
+
+// purchaseErrors сопоставляет ошибки покупки и продажи акций кодам ответа.
+var purchaseErrors = []codedError{
+	{err: teams.ErrIsNoTradePeriod, code: errIsNoTradePeriod, status: http.StatusBadRequest},
+	{err: teams.ErrIncorrectCountOfShares, code: errIncorrectCountOfShares, status: http.StatusBadRequest},
+	{err: teams.ErrShortLimitExceeded, code: errShortLimitExceeded, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrTradingFrozen, code: errTradingFrozen, status: http.StatusBadRequest},
+	{err: teams.ErrGamePaused, code: errGamePaused, status: http.StatusBadRequest},
+	{err: teams.ErrConcurrentPurchase, code: errConcurrentPurchase, status: http.StatusConflict},
+}
+
 func (r *Router) teamPurchase(resp http.ResponseWriter, req *http.Request) {
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
@@ -107,69 +182,22 @@ func (r *Router) teamPurchase(resp http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if writeForbiddenIfForeignTeam(resp, req, request.TeamID) {
+		return
+	}
+
 	amount, err := r.teamService.Purchase(
 		req.Context(),
 		teams.PurchaseParams{
 			TeamID:           request.TeamID,
 			SharesChanges:    request.SharesChanges,
 			AdditionalInfoID: request.AdditionalInfoID,
+			MemberID:         memberIDFromContext(req.Context()),
 		},
 	)
 	if err != nil {
 		r.log.Error().Err(err).Msg("purchase error")
-		if errors.Is(err, teams.ErrIsNoTradePeriod) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errIsNoTradePeriod,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		if errors.Is(err, teams.ErrIncorrectCountOfShares) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errIncorrectCountOfShares,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		if errors.Is(err, teams.ErrNoMoneyForOperation) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errInsufficientBalance,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		resp.WriteHeader(http.StatusInternalServerError)
-		_, _ = resp.Write([]byte(err.Error()))
+		r.writeCodedError(resp, err, purchaseErrors)
 		return
 	}
 
@@ -200,7 +228,19 @@ type (
 		RandomEventID             *int64                          `json:"randomEventId"`
 		AdditionalInfos           []getTeamByIDRespAdditionalInfo `json:"additionalInfos"`
 		BalanceAmount             int64                           `json:"balanceAmount"`
+		LoanAmount                int64                           `json:"loanAmount"`
 		HasTransactionInThisRound bool                            `json:"hasTransactionInThisRound"`
+		RoundTransactions         []getTeamByIDRespTransaction    `json:"roundTransactions"`
+	}
+	getTeamByIDRespTransaction struct {
+		ID               int64                         `json:"id"`
+		Type             models.BalanceTransactionType `json:"type"`
+		Amount           int64                         `json:"amount"`
+		Details          map[int64]int64               `json:"details"`
+		AdditionalInfoID *int64                        `json:"additionalInfoId"`
+		RandomEventID    *int64                        `json:"randomEventId"`
+		ParentID         *int64                        `json:"parentId"`
+		MemberID         *int64                        `json:"memberId"`
 	}
 	getTeamByIDRespAdditionalInfo struct {
 		ID          int64  `json:"id"`
@@ -252,7 +292,23 @@ func (r *Router) getTeamByID(resp http.ResponseWriter, req *http.Request) {
 				},
 			),
 			BalanceAmount:             detailedTeam.Balance,
+			LoanAmount:                detailedTeam.Loan,
 			HasTransactionInThisRound: detailedTeam.HasTransactionInThisRound,
+			RoundTransactions: lo.Map(
+				detailedTeam.RoundTransactions,
+				func(item models.BalanceTransaction, _ int) getTeamByIDRespTransaction {
+					return getTeamByIDRespTransaction{
+						ID:               item.ID,
+						Type:             item.Type,
+						Amount:           item.Amount,
+						Details:          item.Details,
+						AdditionalInfoID: item.AdditionalInfoID,
+						RandomEventID:    item.RandomEventID,
+						ParentID:         item.ParentID,
+						MemberID:         item.MemberID,
+					}
+				},
+			),
 		},
 	)
 	if err != nil {
@@ -267,6 +323,15 @@ func (r *Router) getTeamByID(resp http.ResponseWriter, req *http.Request) {
 	return
 }
 
+// resetTransactionErrors сопоставляет ошибки отмены сделки кодам ответа.
+var resetTransactionErrors = []codedError{
+	{err: teams.ErrTransactionNotFound, code: errTransactionNotFound, status: http.StatusNotFound},
+	{err: teams.ErrIncorrectCountOfShares, code: errIncorrectCountOfShares, status: http.StatusBadRequest},
+	{err: teams.ErrShortLimitExceeded, code: errShortLimitExceeded, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrConcurrentPurchase, code: errConcurrentPurchase, status: http.StatusConflict},
+}
+
 func (r *Router) teamPurchaseReset(resp http.ResponseWriter, req *http.Request) {
 	teamIDParam := chi.URLParam(req, "team_id")
 	teamID, err := strconv.Atoi(teamIDParam)
@@ -277,11 +342,23 @@ func (r *Router) teamPurchaseReset(resp http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	detailedTeam, err := r.teamService.ResetTransaction(req.Context(), int64(teamID))
+	// без transactionId отменяются все сделки текущего раунда
+	var transactionID *int64
+	if param := req.URL.Query().Get("transactionId"); param != "" {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			r.log.Error().Err(err).Msg("get query param")
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+			return
+		}
+		transactionID = &id
+	}
+
+	detailedTeam, err := r.teamService.ResetTransaction(req.Context(), int64(teamID), transactionID)
 	if err != nil {
 		r.log.Error().Err(err).Msg("reset transaction error")
-		resp.WriteHeader(http.StatusInternalServerError)
-		_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		r.writeCodedError(resp, err, resetTransactionErrors)
 		return
 	}
 
@@ -307,7 +384,23 @@ func (r *Router) teamPurchaseReset(resp http.ResponseWriter, req *http.Request)
 				},
 			),
 			BalanceAmount:             detailedTeam.Balance,
+			LoanAmount:                detailedTeam.Loan,
 			HasTransactionInThisRound: detailedTeam.HasTransactionInThisRound,
+			RoundTransactions: lo.Map(
+				detailedTeam.RoundTransactions,
+				func(item models.BalanceTransaction, _ int) getTeamByIDRespTransaction {
+					return getTeamByIDRespTransaction{
+						ID:               item.ID,
+						Type:             item.Type,
+						Amount:           item.Amount,
+						Details:          item.Details,
+						AdditionalInfoID: item.AdditionalInfoID,
+						RandomEventID:    item.RandomEventID,
+						ParentID:         item.ParentID,
+						MemberID:         item.MemberID,
+					}
+				},
+			),
 		},
 	)
 	if err != nil {
@@ -333,7 +426,7 @@ type (
 )
 
 func (r *Router) getAllTeams(resp http.ResponseWriter, req *http.Request) {
-	ts, err := r.teamService.GetAllForCurrentGame(req.Context())
+	ts, err := r.teamService.GetAllForCurrentGame(req.Context(), sessionIDFromContext(req.Context()))
 	if err != nil {
 		r.log.Error().Err(err).Msg("GetAllForCurrentGame error")
 		resp.WriteHeader(http.StatusInternalServerError)
@@ -375,6 +468,16 @@ type (
 	}
 )
 
+// purchaseAdditionalInfoErrors сопоставляет ошибки покупки дополнительной информации кодам ответа.
+var purchaseAdditionalInfoErrors = []codedError{
+	{err: teams.ErrIsNoTradePeriod, code: errIsNoTradePeriod, status: http.StatusBadRequest},
+	{err: teams.ErrNoAdditionalInfos, code: errNoAdditionalInfos, status: http.StatusBadRequest},
+	{err: teams.ErrNoMoneyForOperation, code: errInsufficientBalance, status: http.StatusBadRequest},
+	{err: teams.ErrTradingFrozen, code: errTradingFrozen, status: http.StatusBadRequest},
+	{err: teams.ErrGamePaused, code: errGamePaused, status: http.StatusBadRequest},
+	{err: teams.ErrConcurrentPurchase, code: errConcurrentPurchase, status: http.StatusConflict},
+}
+
 func (r *Router) teamPurchaseAdditionalInfo(resp http.ResponseWriter, req *http.Request) {
 	teamIDParam := chi.URLParam(req, "team_id")
 	teamID, err := strconv.Atoi(teamIDParam)
@@ -385,62 +488,14 @@ func (r *Router) teamPurchaseAdditionalInfo(resp http.ResponseWriter, req *http.
 		return
 	}
 
-	addInfo, amount, err := r.teamService.PurchaseAdditionalInfoCompanyInfo(req.Context(), int64(teamID))
+	addInfo, amount, err := r.teamService.PurchaseAdditionalInfoCompanyInfo(
+		req.Context(),
+		int64(teamID),
+		memberIDFromContext(req.Context()),
+	)
 	if err != nil {
 		r.log.Error().Err(err).Msg("purchase error")
-		if errors.Is(err, teams.ErrIsNoTradePeriod) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errIsNoTradePeriod,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		if errors.Is(err, teams.ErrNoAdditionalInfos) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errNoAdditionalInfos,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		if errors.Is(err, teams.ErrNoMoneyForOperation) {
-			response, err := jsoniter.Marshal(
-				purchaseError{
-					Code:    errInsufficientBalance,
-					Message: err.Error(),
-				},
-			)
-			if err != nil {
-				r.log.Error().Err(err).Msg("marshal to json error")
-				resp.WriteHeader(http.StatusInternalServerError)
-				_, _ = resp.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-				return
-			}
-			resp.WriteHeader(http.StatusBadRequest)
-			_, _ = resp.Write(response)
-			return
-		}
-		resp.WriteHeader(http.StatusInternalServerError)
-		_, _ = resp.Write([]byte(err.Error()))
+		r.writeCodedError(resp, err, purchaseAdditionalInfoErrors)
 		return
 	}
 
@@ -484,7 +539,7 @@ func (r *Router) getStatistics(resp http.ResponseWriter, req *http.Request) {
 		round = roundParsed
 	}
 
-	stats, err := r.teamService.GetStatisticsByGame(req.Context(), round)
+	stats, err := r.teamService.GetStatisticsByGame(req.Context(), sessionIDFromContext(req.Context()), round)
 	if err != nil {
 		r.log.Error().Err(err).Msg("GetStatisticsByGame error")
 		resp.WriteHeader(http.StatusInternalServerError)
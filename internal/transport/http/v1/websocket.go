@@ -1,27 +1,109 @@
 package v1
 
 import (
+	"context"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	jsoniter "github.com/json-iterator/go"
+	"investment-game-backend/internal/services/games"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// wsAuthTimeout — время, за которое клиент без токена в query должен прислать его первым сообщением.
+const wsAuthTimeout = 10 * time.Second
+
 func (r *Router) initWebsocketRouter(router chi.Router) {
 	router.Route("/websocket", func(subRouter chi.Router) {
 		subRouter.Get("/trade-updates", r.tradeUpdates)
 	})
 }
 
+type wsAuthMessage struct {
+	Token   string `json:"token"`
+	Epoch   string `json:"epoch"`
+	LastSeq *int64 `json:"lastSeq"`
+}
+
+// tradeUpdates подписывает соединение на события игры. Access-токен передаётся в query-параметре
+// token или первым сообщением {"token": "...", "epoch": "...", "lastSeq": N} после подключения.
+// Сессия игры и получатель адресных событий определяются по токену. Переподключившийся клиент
+// передаёт epoch и seq последнего полученного события и сначала получает пропущенные события.
+// Соединение закрывается по истечении access-токена и при завершении сессии входа.
 func (r *Router) tradeUpdates(resp http.ResponseWriter, req *http.Request) {
+	var (
+		ctx        context.Context
+		resumeFrom *games.ResumePoint
+		err        error
+	)
+	if value := req.URL.Query().Get("lastSeq"); value != "" {
+		seq, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			r.log.Error().Err(err).Msg("get query param")
+			resp.WriteHeader(http.StatusBadRequest)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusBadRequest)))
+			return
+		}
+		resumeFrom = &games.ResumePoint{
+			Epoch:   req.URL.Query().Get("epoch"),
+			LastSeq: seq,
+		}
+	}
+	if token := req.URL.Query().Get("token"); token != "" {
+		if ctx, err = r.authenticate(req.Context(), token); err != nil {
+			r.log.Error().Err(err).Msg("websocket auth error")
+			resp.WriteHeader(http.StatusUnauthorized)
+			_, _ = resp.Write([]byte(http.StatusText(http.StatusUnauthorized)))
+			return
+		}
+	}
+
 	conn, err := r.upgrader.Upgrade(resp, req, nil)
 	if err != nil {
 		r.log.Error().Err(err).Msg("failed to upgrade websocket connection")
 		return
 	}
-	r.teamsNotifier.RegisterConnection(conn)
 	r.log.Trace().Msg("websocket connection upgraded")
 
+	if ctx == nil {
+		var msg wsAuthMessage
+		if msg, err = readWebsocketAuthMessage(conn); err == nil {
+			ctx, err = r.authenticate(req.Context(), msg.Token)
+		}
+		if err != nil {
+			r.log.Error().Err(err).Msg("websocket auth error")
+			_ = conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, http.StatusText(http.StatusUnauthorized)),
+				time.Now().Add(time.Second),
+			)
+			_ = conn.Close()
+			return
+		}
+		if msg.LastSeq != nil {
+			resumeFrom = &games.ResumePoint{
+				Epoch:   msg.Epoch,
+				LastSeq: *msg.LastSeq,
+			}
+		}
+	}
+
+	sessionID := sessionIDFromContext(ctx)
+	r.teamsNotifier.RegisterConnection(
+		sessionID,
+		games.Subscriber{
+			Role:          roleFromContext(ctx),
+			TeamID:        teamIDFromContext(ctx),
+			AuthSessionID: authSessionIDFromContext(ctx),
+			ExpiresAt:     accessExpiresAtFromContext(ctx),
+		},
+		conn,
+		resumeFrom,
+	)
+
 	defer func() {
-		r.teamsNotifier.RemoveConnection(conn)
+		r.teamsNotifier.RemoveConnection(sessionID, conn)
 		err = conn.Close()
 		r.log.Trace().Err(err).Msg("websocket connection closed")
 	}()
@@ -32,3 +114,23 @@ func (r *Router) tradeUpdates(resp http.ResponseWriter, req *http.Request) {
 		}
 	}
 }
+
+// readWebsocketAuthMessage ждёт от клиента первое сообщение с access-токеном.
+func readWebsocketAuthMessage(conn *websocket.Conn) (wsAuthMessage, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(wsAuthTimeout)); err != nil {
+		return wsAuthMessage{}, err
+	}
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return wsAuthMessage{}, err
+	}
+	if err = conn.SetReadDeadline(time.Time{}); err != nil {
+		return wsAuthMessage{}, err
+	}
+
+	var msg wsAuthMessage
+	if err = jsoniter.Unmarshal(data, &msg); err != nil {
+		return wsAuthMessage{}, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,39 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrMismatch = errors.New("password mismatch")
+
+// dummyHash — bcrypt-хэш со стоимостью по умолчанию, с которым CompareDummy сравнивает пароль.
+const dummyHash = "$2a$10$58W7PmrCt17IAFnSYX28dOPM6rEBA.YoUrSNh8.oJHPdedCIkH9hS"
+
+// Hash возвращает bcrypt-хэш пароля со стоимостью по умолчанию.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("bcrypt.GenerateFromPassword: %w", err)
+	}
+	return string(hash), nil
+}
+
+// Compare проверяет пароль по хэшу, при несовпадении возвращает ErrMismatch.
+func Compare(hash string, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		return fmt.Errorf("bcrypt.CompareHashAndPassword: %w", err)
+	}
+	return nil
+}
+
+// CompareDummy проверяет пароль по фиксированному хэшу и всегда возвращает ErrMismatch. Вызывается,
+// когда пользователь не найден, чтобы время ответа не выдавало существующие имена.
+func CompareDummy(password string) error {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+	return ErrMismatch
+}